@@ -0,0 +1,31 @@
+package cbor
+
+import "github.com/theobori/nix-converter/converter"
+
+type CBORConverter struct {
+	data    string
+	options *converter.ConverterOptions
+}
+
+func NewCBORConverter(data string, options *converter.ConverterOptions) *CBORConverter {
+	return &CBORConverter{
+		data,
+		options,
+	}
+}
+
+func (c *CBORConverter) FromNix() (string, error) {
+	return FromNix(c.data, c.options)
+}
+
+func (c *CBORConverter) FromNixBytes() ([]byte, error) {
+	return FromNixBytes(c.data, c.options)
+}
+
+func (c *CBORConverter) ToNix() (string, error) {
+	return ToNix(c.data, c.options)
+}
+
+func (c *CBORConverter) Type() string {
+	return "cbor"
+}
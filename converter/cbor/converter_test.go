@@ -0,0 +1,161 @@
+package cbor
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/options"
+	"github.com/theobori/nix-converter/internal/common"
+)
+
+func mustDecodeHex(t *testing.T, s string) string {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(b)
+}
+
+// Some examples from the appendix A of RFC 8949
+var cborHexStrings = []string{
+	"1903e8",
+	"3903e7",
+	"fa47c35000",
+	"fb3ff199999999999a",
+	"f4",
+	"f6",
+	"f7",
+	"f0",
+	"f8ff",
+	"c074323031332d30332d32315432303a30343a30305a",
+	"d82076687474703a2f2f7777772e6578616d706c652e636f6d",
+	"4401020304",
+	"6449455446",
+	"8301820203820405",
+	"a201020304",
+	"a26161016162820203",
+}
+
+var nixStrings = []string{
+	`{
+  name = "server";
+  port = 8080;
+  ratio = 0.1;
+  offset = -1.5;
+  enabled = true;
+  parent = null;
+  hosts = [
+    "a"
+    "b"
+  ];
+  certificate = {
+    _type = "bytes";
+    value = "AAECAw==";
+  };
+  created = {
+    _type = "tag";
+    tag = 1;
+    value = 1363896240;
+  };
+  codes = {
+    _type = "map";
+    value = [
+      {
+        key = 1;
+        value = "one";
+      }
+      {
+        key = -2;
+        value = [];
+      }
+    ];
+  };
+  empty = {};
+}`,
+}
+
+func TestCBORToNix(t *testing.T) {
+	t.Parallel()
+	cborStrings := []string{}
+	for _, s := range cborHexStrings {
+		cborStrings = append(cborStrings, mustDecodeHex(t, s))
+	}
+
+	common.TestHelperToNixStrings(t, cborStrings, FromNix, ToNix, converter.NewDefaultConverterOptions())
+}
+
+func TestCBORFromNix(t *testing.T) {
+	t.Parallel()
+	options := converter.ConverterOptions{
+		SortIterators: *options.NewDefaultSortIterators(),
+		UnsafeKeys:    true,
+	}
+
+	common.TestHelperFromNixStrings(t, nixStrings, FromNix, ToNix, &options)
+}
+
+func TestCBORDecode(t *testing.T) {
+	t.Parallel()
+	options := converter.NewDefaultConverterOptions()
+
+	tests := []struct {
+		data string
+		want string
+	}{
+		// Half precision float
+		{"f93c00", "1.0"},
+		// Indefinite length text string and array
+		{"7f657374726561646d696e67ff", `"streaming"`},
+		{"9f018202039f0405ffff", "[\n  1\n  [\n    2\n    3\n  ]\n  [\n    4\n    5\n  ]\n]"},
+		{"c249010000000000000000", "{\n  \"_type\" = \"tag\";\n  \"tag\" = 2;\n  \"value\" = {\n    \"_type\" = \"bytes\";\n    \"value\" = \"AQAAAAAAAAAA\";\n  };\n}"},
+	}
+
+	for _, test := range tests {
+		output, err := ToNix(mustDecodeHex(t, test.data), options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if output != test.want {
+			t.Errorf("ToNix(%s) = \n%v, want \n%v", test.data, output, test.want)
+		}
+	}
+}
+
+func TestCBORErrors(t *testing.T) {
+	t.Parallel()
+	cborInputs := []string{
+		"",
+		"1b",
+		"1bffffffffffffffff",
+		"3bffffffffffffffff",
+		"62ff",
+		"f97e00",
+		"0101",
+		"a2616101616102",
+		"ff",
+	}
+
+	for _, input := range cborInputs {
+		if _, err := ToNix(mustDecodeHex(t, input), converter.NewDefaultConverterOptions()); err == nil {
+			t.Errorf("ToNix(%q) expected an error", input)
+		}
+	}
+
+	nixInputs := []string{
+		`{ a = { _type = "bytes"; }; }`,
+		`{ a = { _type = "bytes"; value = "!"; }; }`,
+		`{ a = { _type = "tag"; tag = -1; value = 1; }; }`,
+		`{ a = { _type = "ext"; ext = 1; value = ""; }; }`,
+		`{ a = { _type = "simple"; value = 21; }; }`,
+		`{ a = { _type = "map"; value = [ 1 ]; }; }`,
+	}
+
+	for _, input := range nixInputs {
+		if _, err := FromNix(input, converter.NewDefaultConverterOptions()); err == nil {
+			t.Errorf("FromNix(%q) expected an error", input)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package cbor
+
+import (
+	"fmt"
+	"math"
+	"unicode/utf8"
+
+	"github.com/theobori/nix-converter/converter/value"
+)
+
+const (
+	majorUnsigned = iota
+	majorNegative
+	majorBytes
+	majorText
+	majorArray
+	majorMap
+	majorTag
+	majorSimple
+)
+
+const (
+	infoIndefinite = 31
+	breakCode      = 0xFF
+)
+
+type decoder struct {
+	data   []byte
+	offset int
+}
+
+func (d *decoder) read(size uint64) ([]byte, error) {
+	if size > uint64(len(d.data)-d.offset) {
+		return nil, fmt.Errorf("unexpected end of data at offset %d", d.offset)
+	}
+
+	b := d.data[d.offset : d.offset+int(size)]
+	d.offset += int(size)
+
+	return b, nil
+}
+
+// Reads an item head, the argument is a length, a value or a float
+func (d *decoder) head() (byte, byte, uint64, error) {
+	b, err := d.read(1)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	major, info := b[0]>>5, b[0]&0x1F
+
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info <= 27:
+		raw, err := d.read(1 << (info - 24))
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		var u uint64
+		for _, c := range raw {
+			u = u<<8 | uint64(c)
+		}
+		return major, info, u, nil
+	case info == infoIndefinite:
+		return major, info, 0, nil
+	default:
+		return 0, 0, 0, fmt.Errorf("invalid additional information %d at offset %d", info, d.offset-1)
+	}
+}
+
+func (d *decoder) isBreak() bool {
+	if d.offset < len(d.data) && d.data[d.offset] == breakCode {
+		d.offset++
+		return true
+	}
+
+	return false
+}
+
+func (d *decoder) string(major byte, info byte, length uint64) ([]byte, error) {
+	if info != infoIndefinite {
+		return d.read(length)
+	}
+
+	// Indefinite length strings are a sequence of definite length chunks
+	b := []byte{}
+	for !d.isBreak() {
+		chunkMajor, chunkInfo, chunkLength, err := d.head()
+		if err != nil {
+			return nil, err
+		}
+		if chunkMajor != major || chunkInfo == infoIndefinite {
+			return nil, fmt.Errorf("invalid string chunk at offset %d", d.offset)
+		}
+
+		chunk, err := d.read(chunkLength)
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, chunk...)
+	}
+
+	return b, nil
+}
+
+func (d *decoder) array(info byte, length uint64) ([]any, error) {
+	array := []any{}
+
+	for i := uint64(0); info == infoIndefinite || i < length; i++ {
+		if info == infoIndefinite && d.isBreak() {
+			break
+		}
+
+		item, err := d.item()
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, item)
+	}
+
+	return array, nil
+}
+
+func (d *decoder) dict(info byte, length uint64) (value.Map, error) {
+	m := value.Map{}
+
+	for i := uint64(0); info == infoIndefinite || i < length; i++ {
+		if info == infoIndefinite && d.isBreak() {
+			break
+		}
+
+		k, err := d.item()
+		if err != nil {
+			return nil, err
+		}
+		switch k.(type) {
+		case []any, value.Map:
+			return nil, fmt.Errorf("unsupported map key type %T at offset %d", k, d.offset)
+		}
+
+		v, err := d.item()
+		if err != nil {
+			return nil, err
+		}
+		m = append(m, value.Pair{Key: k, Value: v})
+	}
+
+	return m, nil
+}
+
+func halfToFloat(h uint16) float64 {
+	exponent := int(h>>10) & 0x1F
+	mantissa := float64(h & 0x3FF)
+
+	var f float64
+	switch exponent {
+	case 0:
+		f = math.Ldexp(mantissa, -24)
+	case 0x1F:
+		if mantissa == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mantissa+1024, exponent-25)
+	}
+
+	if h&0x8000 != 0 {
+		return -f
+	}
+
+	return f
+}
+
+func (d *decoder) simple(info byte, argument uint64) (any, error) {
+	switch info {
+	case 20, 21:
+		return info == 21, nil
+	case 22:
+		return nil, nil
+	case 23:
+		return value.Undefined{}, nil
+	case 24:
+		if argument < 32 {
+			return nil, fmt.Errorf("invalid simple value %d at offset %d", argument, d.offset)
+		}
+		return value.Simple(argument), nil
+	case 25:
+		return halfToFloat(uint16(argument)), nil
+	case 26:
+		return float64(math.Float32frombits(uint32(argument))), nil
+	case 27:
+		return math.Float64frombits(argument), nil
+	case infoIndefinite:
+		return nil, fmt.Errorf("unexpected break at offset %d", d.offset-1)
+	default:
+		return value.Simple(info), nil
+	}
+}
+
+func (d *decoder) item() (any, error) {
+	major, info, argument, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+
+	if info == infoIndefinite && major != majorBytes && major != majorText &&
+		major != majorArray && major != majorMap && major != majorSimple {
+		return nil, fmt.Errorf("invalid indefinite length item at offset %d", d.offset-1)
+	}
+
+	switch major {
+	case majorUnsigned:
+		return argument, nil
+	case majorNegative:
+		if argument > math.MaxInt64 {
+			return nil, fmt.Errorf("integer -1-%d is out of range", argument)
+		}
+		return -1 - int64(argument), nil
+	case majorBytes:
+		b, err := d.string(major, info, argument)
+		if err != nil {
+			return nil, err
+		}
+		return value.Bytes(append([]byte{}, b...)), nil
+	case majorText:
+		b, err := d.string(major, info, argument)
+		if err != nil {
+			return nil, err
+		}
+		if !utf8.Valid(b) {
+			return nil, fmt.Errorf("invalid UTF-8 text string at offset %d", d.offset)
+		}
+		return string(b), nil
+	case majorArray:
+		return d.array(info, argument)
+	case majorMap:
+		return d.dict(info, argument)
+	case majorTag:
+		content, err := d.item()
+		if err != nil {
+			return nil, err
+		}
+		return value.Tag{Number: argument, Content: content}, nil
+	default:
+		return d.simple(info, argument)
+	}
+}
+
+// Decode reads a single CBOR data item
+func Decode(data []byte) (any, error) {
+	d := decoder{data: data}
+
+	v, err := d.item()
+	if err != nil {
+		return nil, err
+	}
+
+	if d.offset != len(data) {
+		return nil, fmt.Errorf("unexpected data after the item at offset %d", d.offset)
+	}
+
+	return v, nil
+}
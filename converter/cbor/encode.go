@@ -0,0 +1,114 @@
+package cbor
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/theobori/nix-converter/converter/value"
+)
+
+type encoder struct {
+	b []byte
+}
+
+// Writes an item head with the shortest argument
+func (e *encoder) head(major byte, argument uint64) {
+	major <<= 5
+
+	switch {
+	case argument < 24:
+		e.b = append(e.b, major|byte(argument))
+	case argument <= math.MaxUint8:
+		e.b = append(e.b, major|24, byte(argument))
+	case argument <= math.MaxUint16:
+		e.b = binary.BigEndian.AppendUint16(append(e.b, major|25), uint16(argument))
+	case argument <= math.MaxUint32:
+		e.b = binary.BigEndian.AppendUint32(append(e.b, major|26), uint32(argument))
+	default:
+		e.b = binary.BigEndian.AppendUint64(append(e.b, major|27), argument)
+	}
+}
+
+// Floats are written on 32 bits when it does not lose precision
+func (e *encoder) float(f float64) {
+	if float64(float32(f)) == f {
+		e.b = binary.BigEndian.AppendUint32(append(e.b, majorSimple<<5|26), math.Float32bits(float32(f)))
+		return
+	}
+
+	e.b = binary.BigEndian.AppendUint64(append(e.b, majorSimple<<5|27), math.Float64bits(f))
+}
+
+func (e *encoder) item(v any) error {
+	switch v := v.(type) {
+	case nil:
+		e.b = append(e.b, majorSimple<<5|22)
+	case bool:
+		if v {
+			e.b = append(e.b, majorSimple<<5|21)
+		} else {
+			e.b = append(e.b, majorSimple<<5|20)
+		}
+	case int64:
+		if v < 0 {
+			e.head(majorNegative, uint64(-1-v))
+		} else {
+			e.head(majorUnsigned, uint64(v))
+		}
+	case uint64:
+		e.head(majorUnsigned, v)
+	case float64:
+		e.float(v)
+	case string:
+		e.head(majorText, uint64(len(v)))
+		e.b = append(e.b, v...)
+	case value.Bytes:
+		e.head(majorBytes, uint64(len(v)))
+		e.b = append(e.b, v...)
+	case []any:
+		e.head(majorArray, uint64(len(v)))
+		for _, item := range v {
+			if err := e.item(item); err != nil {
+				return err
+			}
+		}
+	case value.Map:
+		e.head(majorMap, uint64(len(v)))
+		for _, pair := range v {
+			if err := e.item(pair.Key); err != nil {
+				return err
+			}
+			if err := e.item(pair.Value); err != nil {
+				return err
+			}
+		}
+	case value.Tag:
+		e.head(majorTag, v.Number)
+		return e.item(v.Content)
+	case value.Simple:
+		if v >= 20 && v < 32 {
+			return fmt.Errorf("invalid simple value %d", v)
+		}
+		e.head(majorSimple, uint64(v))
+	case value.Undefined:
+		e.b = append(e.b, majorSimple<<5|23)
+	case value.Ext:
+		return fmt.Errorf("MessagePack extension values are not supported by CBOR")
+	default:
+		return fmt.Errorf("unsupported value type: %T", v)
+	}
+
+	return nil
+}
+
+// Encode writes a value as a CBOR data item
+func Encode(v any) ([]byte, error) {
+	e := encoder{}
+
+	if err := e.item(v); err != nil {
+		return nil, err
+	}
+
+	return e.b, nil
+}
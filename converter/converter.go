@@ -5,3 +5,10 @@ type Converter interface {
 	ToNix() (string, error)
 	Type() string
 }
+
+// BinaryConverter is a converter for a binary data format, FromNixBytes
+// returns the raw output bytes, FromNix returns them as a string
+type BinaryConverter interface {
+	Converter
+	FromNixBytes() ([]byte, error)
+}
@@ -0,0 +1,40 @@
+package csv
+
+import "github.com/theobori/nix-converter/converter"
+
+type CSVConverter struct {
+	data      string
+	options   *converter.ConverterOptions
+	delimiter rune
+	language  string
+}
+
+func NewCSVConverter(data string, options *converter.ConverterOptions) *CSVConverter {
+	return &CSVConverter{
+		data,
+		options,
+		',',
+		"csv",
+	}
+}
+
+func NewTSVConverter(data string, options *converter.ConverterOptions) *CSVConverter {
+	return &CSVConverter{
+		data,
+		options,
+		'\t',
+		"tsv",
+	}
+}
+
+func (c *CSVConverter) FromNix() (string, error) {
+	return fromNix(c.data, c.delimiter, c.options)
+}
+
+func (c *CSVConverter) ToNix() (string, error) {
+	return toNix(c.data, c.delimiter, c.options)
+}
+
+func (c *CSVConverter) Type() string {
+	return c.language
+}
@@ -0,0 +1,143 @@
+package csv
+
+import (
+	"testing"
+
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/options"
+	"github.com/theobori/nix-converter/internal/common"
+)
+
+var csvStrings = []string{
+	`host,ip,port,comment
+web,10.0.0.1,80,"front, public"
+db,10.0.0.2,5432,"says ""hi"""
+cache,,6379,"two
+lines"`,
+}
+
+var nixStrings = []string{
+	`[
+  {
+    name = "alice";
+    age = 30;
+    ratio = -1.5;
+    admin = true;
+    team = null;
+  }
+  {
+    name = "bob";
+    age = 7;
+    ratio = 0.5;
+    admin = false;
+    team = "ops";
+  }
+]`,
+	`[]`,
+}
+
+func TestCSVToNix(t *testing.T) {
+	t.Parallel()
+	common.TestHelperToNixStrings(t, csvStrings, FromNix, ToNix, converter.NewDefaultConverterOptions())
+}
+
+func TestCSVFromNix(t *testing.T) {
+	t.Parallel()
+	options := converter.ConverterOptions{
+		SortIterators: *options.NewDefaultSortIterators(),
+		UnsafeKeys:    true,
+		CSV: options.CSV{
+			InferTypes: true,
+		},
+	}
+
+	common.TestHelperFromNixStrings(t, nixStrings, FromNix, ToNix, &options)
+}
+
+func TestTSV(t *testing.T) {
+	t.Parallel()
+	tsvStrings := []string{
+		"name\tgroups\nalice\twheel,users\nbob\t",
+	}
+
+	common.TestHelperToNixStrings(t, tsvStrings, TSVFromNix, TSVToNix, converter.NewDefaultConverterOptions())
+}
+
+func TestCSVOptions(t *testing.T) {
+	t.Parallel()
+	options := converter.NewDefaultConverterOptions()
+	options.UnsafeKeys = true
+	options.CSV.NoHeader = true
+	options.CSV.InferTypes = true
+	options.CSV.Delimiter = ';'
+
+	input := "\uFEFFweb;80;true;007;1e3\ndb;;false;-2;x"
+	want := `[
+  [
+    "web"
+    80
+    true
+    "007"
+    1000.0
+  ]
+  [
+    "db"
+    null
+    false
+    (-2)
+    "x"
+  ]
+]`
+
+	output, err := ToNix(input, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if output != want {
+		t.Errorf("ToNix() = \n%v, want \n%v", output, want)
+	}
+
+	output, err = FromNix(want, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "web;80;true;007;1000.0\ndb;;false;-2;x"; output != want {
+		t.Errorf("FromNix() = \n%v, want \n%v", output, want)
+	}
+}
+
+func TestCSVErrors(t *testing.T) {
+	t.Parallel()
+	csvInputs := []struct {
+		input    string
+		expected string
+	}{
+		{"a,b\n1,2,3", "line 2, column 1: wrong number of fields"},
+		{"a,a\n1,2", "line 1: the key 'a' is defined twice"},
+		{"a,b\n1,\"b", "line 2, column 5: extraneous or missing \" in quoted-field"},
+	}
+
+	for _, test := range csvInputs {
+		_, err := ToNix(test.input, converter.NewDefaultConverterOptions())
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("ToNix(%q) expected the error %q, got %v", test.input, test.expected, err)
+		}
+	}
+
+	nixInputs := []string{
+		`{ a = 1; }`,
+		`[ 1 ]`,
+		`[ [ 1 ] ]`,
+		`[ { a = { b = 1; }; } ]`,
+		`[ { a.b = 1; } ]`,
+		`[ { a = [ 1 ]; } ]`,
+	}
+
+	for _, input := range nixInputs {
+		if _, err := FromNix(input, converter.NewDefaultConverterOptions()); err == nil {
+			t.Errorf("FromNix(%q) expected an error", input)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+// Package csv converts CSV and TSV tables to Nix and vice versa.
+//
+// With a header, the table is a list of sets, one per record, whose keys are
+// the column names. Without a header, it is a list of lists.
+//
+// Fields are strings by default. With type inference, empty fields are null,
+// true and false are booleans, and decimal numbers without leading zeros are
+// numbers, any other field stays a string.
+package csv
+
+import "regexp"
+
+var (
+	integerRegexp = regexp.MustCompile(`^-?(0|[1-9][0-9]*)$`)
+	floatRegexp   = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+)
+
+func IsInteger(s string) bool {
+	return integerRegexp.MatchString(s)
+}
+
+func IsFloat(s string) bool {
+	return floatRegexp.MatchString(s) && !IsInteger(s)
+}
@@ -0,0 +1,182 @@
+package csv
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/nix"
+	"github.com/theobori/nix-converter/internal/common"
+)
+
+type CSVVisitor struct {
+	i       common.Indentation
+	records [][]string
+	limiter *converter.Limiter
+	options *converter.ConverterOptions
+}
+
+func NewCSVVisitor(records [][]string, options *converter.ConverterOptions) *CSVVisitor {
+	return &CSVVisitor{
+		i:       *common.NewIndentationUnit(options.NixIndentUnit()),
+		records: records,
+		limiter: converter.NewLimiter(options),
+		options: options,
+	}
+}
+
+func (c *CSVVisitor) visitString(s string) string {
+	if strings.Contains(s, "\n") {
+		return common.MakeIndentedString(s, c.i.IndentValue(), c.i.Unit())
+	}
+
+	return common.MakeStringSafe(s)
+}
+
+func (c *CSVVisitor) visitField(s string) string {
+	if !c.options.CSV.InferTypes {
+		return c.visitString(s)
+	}
+
+	switch {
+	case s == "":
+		return "null"
+	case s == "true" || s == "false":
+		return s
+	case IsInteger(s):
+		if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return nix.FormatIntLiteral(s)
+		}
+	case IsFloat(s):
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return nix.FormatFloat(f)
+		}
+	}
+
+	return c.visitString(s)
+}
+
+func (c *CSVVisitor) visitRecord(header []nix.Entry[int], record []string) string {
+	e := []string{}
+	if header == nil {
+		for _, field := range record {
+			c.i.Indent()
+			e = append(e, c.i.IndentValue()+nix.MakeElementSafe(c.visitField(field)))
+			c.i.UnIndent()
+		}
+	}
+	for _, column := range header {
+		c.i.Indent()
+		left := nix.MakeNameSafe(column.Key, c.options.UnsafeKeys)
+		e = append(e, c.i.IndentValue()+left+" = "+c.visitField(record[column.Value])+";")
+		c.i.UnIndent()
+	}
+
+	if header == nil {
+		if c.options.SortIterators.SortList {
+			slices.Sort(e)
+		}
+		return "[\n" + strings.Join(e, "\n") + "\n" + c.i.IndentValue() + "]"
+	}
+
+	if c.options.SortIterators.SortHashmap {
+		slices.Sort(e)
+	}
+
+	return "{\n" + strings.Join(e, "\n") + "\n" + c.i.IndentValue() + "}"
+}
+
+func (c *CSVVisitor) Visit() (string, error) {
+	records := c.records
+
+	var header []nix.Entry[int]
+	if !c.options.CSV.NoHeader && len(records) > 0 {
+		// A duplicated column name keeps the column chosen by the policy
+		columns := []nix.Entry[int]{}
+		for i, name := range records[0] {
+			columns = append(columns, nix.Entry[int]{Key: name, Value: i, Line: 1})
+		}
+
+		var err error
+		header, err = nix.MergeEntries(columns, c.options.DuplicateKeys.Policy, nil)
+		if err != nil {
+			return "", err
+		}
+		records = records[1:]
+	}
+
+	if len(records) == 0 {
+		return "[]", nil
+	}
+
+	e := []string{}
+	for _, record := range records {
+		// The records are flat, the limits only count them with their fields
+		for range len(record) + 1 {
+			if err := c.limiter.Enter(); err != nil {
+				return "", err
+			}
+			c.limiter.Leave()
+		}
+
+		c.i.Indent()
+		e = append(e, c.i.IndentValue()+c.visitRecord(header, record))
+		c.i.UnIndent()
+	}
+
+	if c.options.SortIterators.SortList {
+		slices.Sort(e)
+	}
+
+	return c.limiter.Done("[\n" + strings.Join(e, "\n") + "\n" + c.i.IndentValue() + "]")
+}
+
+func delimiterOrDefault(options *converter.ConverterOptions, delimiter rune) rune {
+	if options.CSV.Delimiter != 0 {
+		return options.CSV.Delimiter
+	}
+
+	return delimiter
+}
+
+func toNix(data string, delimiter rune, options *converter.ConverterOptions) (string, error) {
+	// Spreadsheets often write a byte order mark
+	r := csv.NewReader(strings.NewReader(strings.TrimPrefix(data, "\uFEFF")))
+	r.Comma = delimiterOrDefault(options, delimiter)
+
+	records := [][]string{}
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return "", converter.Errorf(parseErr.Line, parseErr.Column, "%w", parseErr.Err)
+		}
+		if err != nil {
+			return "", err
+		}
+
+		records = append(records, record)
+	}
+
+	out, err := NewCSVVisitor(records, options).Visit()
+	if err != nil {
+		return "", err
+	}
+
+	return nix.Layout(out, &options.NixOutput), nil
+}
+
+func ToNix(data string, options *converter.ConverterOptions) (string, error) {
+	return toNix(data, ',', options)
+}
+
+func TSVToNix(data string, options *converter.ConverterOptions) (string, error) {
+	return toNix(data, '\t', options)
+}
@@ -0,0 +1,224 @@
+package csv
+
+import (
+	"encoding/csv"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/orivej/go-nix/nix/parser"
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/nix"
+)
+
+type NixVisitor struct {
+	p         *parser.Parser
+	errors    *nix.Collector
+	node      *parser.Node
+	delimiter rune
+	options   *converter.ConverterOptions
+}
+
+func NewNixVisitor(p *parser.Parser, node *parser.Node, delimiter rune, options *converter.ConverterOptions) *NixVisitor {
+	return &NixVisitor{
+		p:         p,
+		errors:    nix.NewCollector(p, node, options),
+		node:      node,
+		delimiter: delimiter,
+		options:   options,
+	}
+}
+
+func unwrapParens(node *parser.Node) *parser.Node {
+	for node.Type == parser.ParensNode {
+		node = node.Nodes[0]
+	}
+
+	return node
+}
+
+func (n *NixVisitor) visitField(node *parser.Node) (string, error) {
+	return nix.Visit(n.errors, node, n.visitFieldNode, "")
+}
+
+func (n *NixVisitor) visitFieldNode(node *parser.Node) (string, error) {
+	node = unwrapParens(node)
+
+	switch node.Type {
+	case parser.StringNode, parser.IStringNode:
+		return nix.VisitStringValue(n.p, node)
+	case parser.IDNode:
+		id, err := nix.VisitID(n.p, node)
+		if err != nil {
+			return "", err
+		}
+		switch id {
+		case "true", "false":
+			return id, nil
+		case "null":
+			return "", nil
+		}
+	case parser.IntNode:
+		return nix.VisitInt(n.p, node)
+	case parser.FloatNode:
+		return nix.VisitFloat(n.p, node)
+	case parser.ApplyNode:
+		return nix.VisitApply(n.p, node)
+	case parser.OpNode + 57378: // The negative unary operator
+		s, err := n.visitField(node.Nodes[0])
+		if err != nil {
+			return "", err
+		}
+		return "-" + s, nil
+	}
+
+	return "", fmt.Errorf("a field must be a string, a number, a boolean or null, got %s", node.Type.String())
+}
+
+func (n *NixVisitor) visitRows(rows []*parser.Node) ([][]string, error) {
+	records := [][]string{}
+	for i, row := range rows {
+		row = unwrapParens(row)
+		if row.Type != parser.ListNode {
+			return nil, fmt.Errorf("without a header, the Nix expression must be a list of lists, element %d is a %s", i+1, row.Type.String())
+		}
+
+		record := []string{}
+		for j, child := range row.Nodes {
+			field, err := n.visitField(child)
+			if err != nil {
+				return nil, converter.AtIndex(converter.AtIndex(err, j), i)
+			}
+			record = append(record, field)
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+func (n *NixVisitor) visitSets(rows []*parser.Node) ([][]string, error) {
+	header := []string{}
+	sets := []*nix.AttrNode{}
+
+	for i, row := range rows {
+		row = unwrapParens(row)
+		if row.Type != parser.SetNode {
+			return nil, fmt.Errorf("the Nix expression must be a list of flat sets, element %d is a %s", i+1, row.Type.String())
+		}
+
+		tree, err := n.errors.BuildAttrTree(row, n.options.DuplicateKeys.Policy)
+		if err != nil {
+			return nil, converter.AtIndex(err, i)
+		}
+
+		// The columns are the keys in the order of their first appearance
+		for _, k := range tree.Order {
+			if tree.Children[k].Value == nil {
+				return nil, fmt.Errorf("the Nix expression must be a list of flat sets, element %d has a nested set under '%s'", i+1, k)
+			}
+			if !slices.Contains(header, k) {
+				header = append(header, k)
+			}
+		}
+
+		sets = append(sets, tree)
+	}
+
+	if n.options.SortIterators.SortHashmap {
+		slices.Sort(header)
+	}
+
+	records := [][]string{header}
+	for i, tree := range sets {
+		record := []string{}
+		for _, k := range header {
+			attr, exists := tree.Children[k]
+			if !exists {
+				record = append(record, "")
+				continue
+			}
+
+			field, err := n.visitField(attr.Value)
+			if err != nil {
+				return nil, converter.AtIndex(converter.AtKey(err, k), i)
+			}
+			record = append(record, field)
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+func (n *NixVisitor) visitRoot() (string, error) {
+	node := unwrapParens(n.node)
+	if node.Type != parser.ListNode {
+		return "", fmt.Errorf("the Nix expression must be a list of flat sets, got %s", node.Type.String())
+	}
+
+	var (
+		records [][]string
+		err     error
+	)
+	if n.options.CSV.NoHeader {
+		records, err = n.visitRows(node.Nodes)
+	} else {
+		records, err = n.visitSets(node.Nodes)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// The header of an empty list is empty too
+	if len(records) == 1 && len(records[0]) == 0 {
+		records = nil
+	}
+
+	if n.options.SortIterators.SortList {
+		start := 1
+		if n.options.CSV.NoHeader {
+			start = 0
+		}
+		slices.SortFunc(records[min(start, len(records)):], slices.Compare)
+	}
+
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.Comma = n.delimiter
+
+	if err := w.WriteAll(records); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+func (n *NixVisitor) Visit() (string, error) {
+	v, err := n.visitRoot()
+	return n.errors.Done(v, err)
+}
+
+func fromNix(data string, delimiter rune, options *converter.ConverterOptions) (string, error) {
+	p, err := nix.Parse(data)
+	if err != nil {
+		return "", err
+	}
+
+	out, err := NewNixVisitor(p, p.Result, delimiterOrDefault(options, delimiter), options).Visit()
+	if err != nil {
+		return "", err
+	}
+
+	return out, nil
+}
+
+func FromNix(data string, options *converter.ConverterOptions) (string, error) {
+	return fromNix(data, ',', options)
+}
+
+func TSVFromNix(data string, options *converter.ConverterOptions) (string, error) {
+	return fromNix(data, '\t', options)
+}
@@ -0,0 +1,27 @@
+package dotenv
+
+import "github.com/theobori/nix-converter/converter"
+
+type DotenvConverter struct {
+	data    string
+	options *converter.ConverterOptions
+}
+
+func NewDotenvConverter(data string, options *converter.ConverterOptions) *DotenvConverter {
+	return &DotenvConverter{
+		data,
+		options,
+	}
+}
+
+func (d *DotenvConverter) FromNix() (string, error) {
+	return FromNix(d.data, d.options)
+}
+
+func (d *DotenvConverter) ToNix() (string, error) {
+	return ToNix(d.data, d.options)
+}
+
+func (d *DotenvConverter) Type() string {
+	return "dotenv"
+}
@@ -0,0 +1,112 @@
+package dotenv
+
+import (
+	"testing"
+
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/options"
+	"github.com/theobori/nix-converter/internal/common"
+)
+
+var dotenvStrings = []string{
+	`DATABASE_URL=postgres://user@localhost:5432/db
+SECRET='pa$$word with spaces'
+GREETING="it's\n\"quoted\""
+EMPTY=
+_PRIVATE=1`,
+}
+
+var nixStrings = []string{
+	`{
+  NAME = "my app";
+  PATH_PREFIX = "$HOME/bin";
+  MOTD = ''
+    Welcome
+    to the server'';
+}`,
+}
+
+func TestDotenvToNix(t *testing.T) {
+	t.Parallel()
+	common.TestHelperToNixStrings(t, dotenvStrings, FromNix, ToNix, converter.NewDefaultConverterOptions())
+}
+
+func TestDotenvFromNix(t *testing.T) {
+	t.Parallel()
+	options := converter.ConverterOptions{
+		SortIterators: *options.NewDefaultSortIterators(),
+		UnsafeKeys:    true,
+	}
+
+	common.TestHelperFromNixStrings(t, nixStrings, FromNix, ToNix, &options)
+}
+
+func TestDotenvSyntax(t *testing.T) {
+	t.Parallel()
+	// Like shells, the last value of a repeated variable wins
+	duplicateKeys := options.DuplicateKeys{Policy: options.DuplicateKeysLastWins}
+	options := converter.NewDefaultConverterOptions()
+	options.UnsafeKeys = true
+	options.DuplicateKeys = duplicateKeys
+
+	input := `# Application
+export APP_ENV=production
+APP_NAME=My App # inline comment
+PRIVATE_KEY="-----BEGIN KEY-----
+abc
+-----END KEY-----"
+RAW='${NOT_EXPANDED}'
+APP_ENV=staging`
+
+	want := `{
+  # Application
+  APP_ENV = "staging";
+  # inline comment
+  APP_NAME = "My App";
+  PRIVATE_KEY = ''
+    -----BEGIN KEY-----
+    abc
+    -----END KEY-----'';
+  RAW = "\${NOT_EXPANDED}";
+}`
+
+	output, err := ToNix(input, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if output != want {
+		t.Errorf("ToNix() = \n%v, want \n%v", output, want)
+	}
+}
+
+func TestDotenvErrors(t *testing.T) {
+	t.Parallel()
+	dotenvInputs := []string{
+		`NAME`,
+		`1NAME=x`,
+		`NAME="unterminated`,
+		`NAME='unterminated`,
+		`NAME="x" y`,
+	}
+
+	for _, input := range dotenvInputs {
+		if _, err := ToNix(input, converter.NewDefaultConverterOptions()); err == nil {
+			t.Errorf("ToNix(%q) expected an error", input)
+		}
+	}
+
+	nixInputs := []string{
+		`[ "a" ]`,
+		`{ a.b = "x"; }`,
+		`{ A = [ "x" ]; }`,
+		`{ "A-B" = "x"; }`,
+		`{ A = null; }`,
+	}
+
+	for _, input := range nixInputs {
+		if _, err := FromNix(input, converter.NewDefaultConverterOptions()); err == nil {
+			t.Errorf("FromNix(%q) expected an error", input)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package dotenv
+
+import (
+	"fmt"
+	"strings"
+)
+
+var doubleQuoteReplacer = strings.NewReplacer(
+	"\\", "\\\\",
+	"\"", "\\\"",
+	"$", "\\$",
+	"\n", "\\n",
+	"\r", "\\r",
+	"\t", "\\t",
+)
+
+// Quote a value only when it is needed, single quotes are preferred
+// because their content is never expanded
+func Quote(s string) string {
+	if !IsValueUnsafe(s) {
+		return s
+	}
+
+	if !strings.ContainsAny(s, "'\n\r") {
+		return "'" + s + "'"
+	}
+
+	return "\"" + doubleQuoteReplacer.Replace(s) + "\""
+}
+
+// Unquote reads a quoted value at the start of s, it may span several
+// lines, and returns it with the remaining text after the closing quote
+func Unquote(s string) (string, string, error) {
+	quote := s[0]
+
+	if quote == '\'' {
+		end := strings.IndexByte(s[1:], '\'')
+		if end == -1 {
+			return "", "", fmt.Errorf("missing closing single quote")
+		}
+
+		return s[1 : end+1], s[end+2:], nil
+	}
+
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			return b.String(), s[i+1:], nil
+		case '\\':
+			i++
+			if i == len(s) {
+				return "", "", fmt.Errorf("missing closing double quote")
+			}
+
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '"', '\\', '$', '\'':
+				b.WriteByte(s[i])
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(s[i])
+			}
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+
+	return "", "", fmt.Errorf("missing closing double quote")
+}
+
+// Split an unquoted value from its inline comment
+func splitInlineComment(s string) (string, string) {
+	for i := 1; i < len(s); i++ {
+		if s[i] == '#' && (s[i-1] == ' ' || s[i-1] == '\t') {
+			return strings.TrimSpace(s[:i]), strings.TrimPrefix(s[i+1:], " ")
+		}
+	}
+
+	return s, ""
+}
@@ -0,0 +1,26 @@
+package dotenv
+
+import (
+	"strings"
+
+	"github.com/theobori/nix-converter/internal/common"
+)
+
+func IsNameValid(s string) bool {
+	if s == "" || common.IsNumeric(s[0]) {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if !common.IsCharAlphaNumeric(s[i]) && s[i] != '_' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func IsValueUnsafe(s string) bool {
+	return strings.TrimSpace(s) != s ||
+		strings.ContainsAny(s, " \t\n\r#'\"\\$`")
+}
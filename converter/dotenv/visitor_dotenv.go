@@ -0,0 +1,178 @@
+package dotenv
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/nix"
+	"github.com/theobori/nix-converter/converter/options"
+	"github.com/theobori/nix-converter/internal/common"
+)
+
+type Variable struct {
+	name     string
+	value    string
+	comments []string
+}
+
+type DotenvVisitor struct {
+	i         common.Indentation
+	variables []*Variable
+	limiter   *converter.Limiter
+	options   *converter.ConverterOptions
+}
+
+func NewDotenvVisitor(variables []*Variable, options *converter.ConverterOptions) *DotenvVisitor {
+	return &DotenvVisitor{
+		i:         *common.NewIndentationUnit(options.NixIndentUnit()),
+		variables: variables,
+		limiter:   converter.NewLimiter(options),
+		options:   options,
+	}
+}
+
+func (d *DotenvVisitor) Visit() string {
+	if len(d.variables) == 0 {
+		return "{}"
+	}
+
+	variables := d.variables
+	if d.options.SortIterators.SortHashmap {
+		variables = slices.Clone(variables)
+		slices.SortStableFunc(variables, func(a, b *Variable) int {
+			return strings.Compare(a.name, b.name)
+		})
+	}
+
+	e := []string{}
+	d.i.Indent()
+	for _, v := range variables {
+		// The variables are flat, the limits only count them
+		if d.limiter.Enter() != nil {
+			return ""
+		}
+		d.limiter.Leave()
+
+		for _, comment := range v.comments {
+			e = append(e, d.i.IndentValue()+strings.TrimRight("# "+comment, " "))
+		}
+
+		var value string
+		if strings.Contains(v.value, "\n") {
+			value = common.MakeIndentedString(v.value, d.i.IndentValue(), d.i.Unit())
+		} else {
+			value = common.MakeStringSafe(v.value)
+		}
+
+		key := nix.MakeNameSafe(v.name, d.options.UnsafeKeys)
+		e = append(e, d.i.IndentValue()+key+" = "+value+";")
+	}
+	d.i.UnIndent()
+
+	return "{\n" + strings.Join(e, "\n") + "\n}"
+}
+
+// Err returns the error of the first limit exceeded by Visit
+func (d *DotenvVisitor) Err() error {
+	return d.limiter.Err()
+}
+
+// Parse reads the variables of a dotenv document, a repeated variable keeps
+// the position of its first definition and the value chosen by the policy
+func Parse(data string, policy string) ([]*Variable, error) {
+	variables := []*Variable{}
+	index := map[string]*Variable{}
+	comments := []string{}
+
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	lineNumber := 0
+
+	for data != "" {
+		lineNumber++
+
+		line, rest, _ := strings.Cut(data, "\n")
+		data = rest
+		line = strings.TrimSpace(line)
+
+		if line == "" {
+			continue
+		}
+
+		if line[0] == '#' {
+			comments = append(comments, strings.TrimPrefix(line[1:], " "))
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+
+		name, value, found := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !found {
+			return nil, converter.Errorf(lineNumber, 0, "missing '=' after the variable name")
+		}
+		if !IsNameValid(name) {
+			return nil, converter.Errorf(lineNumber, 0, "invalid variable name '%s'", name)
+		}
+
+		value = strings.TrimLeft(value, " \t")
+
+		var comment string
+		if value != "" && (value[0] == '"' || value[0] == '\'') {
+			// Quoted values may span several lines
+			start := lineNumber
+			quoted := value
+			if data != "" {
+				quoted += "\n" + data
+			}
+
+			s, after, err := Unquote(quoted)
+			if err != nil {
+				return nil, converter.Errorf(start, 0, "%w", err)
+			}
+
+			lineNumber += strings.Count(quoted[:len(quoted)-len(after)], "\n")
+			line, data, _ = strings.Cut(after, "\n")
+
+			line = strings.TrimSpace(line)
+			if line != "" && line[0] != '#' {
+				return nil, converter.Errorf(lineNumber, 0, "unexpected text after the quoted value")
+			}
+			comment = strings.TrimPrefix(strings.TrimPrefix(line, "#"), " ")
+			value = s
+		} else {
+			value, comment = splitInlineComment(strings.TrimSpace(value))
+		}
+
+		if comment != "" {
+			comments = append(comments, comment)
+		}
+
+		v, exists := index[name]
+		if !exists {
+			v = &Variable{name: name}
+			index[name] = v
+			variables = append(variables, v)
+		} else if policy == options.DuplicateKeysError {
+			return nil, nix.DuplicateKeyError(lineNumber, 0, name)
+		}
+		if !exists || policy != options.DuplicateKeysFirstWins {
+			v.value = value
+		}
+		v.comments = append(v.comments, comments...)
+		comments = []string{}
+	}
+
+	return variables, nil
+}
+
+func ToNix(data string, options *converter.ConverterOptions) (string, error) {
+	variables, err := Parse(data, options.DuplicateKeys.Policy)
+	if err != nil {
+		return "", err
+	}
+
+	visitor := NewDotenvVisitor(variables, options)
+
+	return visitor.limiter.Done(nix.Layout(visitor.Visit(), &options.NixOutput))
+}
@@ -0,0 +1,128 @@
+package dotenv
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/orivej/go-nix/nix/parser"
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/nix"
+)
+
+type NixVisitor struct {
+	p       *parser.Parser
+	errors  *nix.Collector
+	node    *parser.Node
+	options *converter.ConverterOptions
+}
+
+func NewNixVisitor(p *parser.Parser, node *parser.Node, options *converter.ConverterOptions) *NixVisitor {
+	return &NixVisitor{
+		node:    node,
+		p:       p,
+		errors:  nix.NewCollector(p, node, options),
+		options: options,
+	}
+}
+
+func (n *NixVisitor) visitValue(node *parser.Node) (string, error) {
+	return nix.Visit(n.errors, node, n.visitValueNode, "")
+}
+
+func (n *NixVisitor) visitValueNode(node *parser.Node) (string, error) {
+	switch node.Type {
+	case parser.StringNode, parser.IStringNode:
+		s, err := nix.VisitStringValue(n.p, node)
+		if err != nil {
+			return "", err
+		}
+		return Quote(s), nil
+	case parser.IntNode:
+		return nix.VisitInt(n.p, node)
+	case parser.FloatNode:
+		return nix.VisitFloat(n.p, node)
+	case parser.IDNode:
+		s, err := nix.VisitID(n.p, node)
+		if err != nil {
+			return "", err
+		}
+		if s != "true" && s != "false" {
+			return "", fmt.Errorf("unsupported identifier '%s'", s)
+		}
+		return s, nil
+	case parser.OpNode + 57378: // The negative unary operator
+		s, err := n.visitValue(node.Nodes[0])
+		if err != nil {
+			return "", err
+		}
+		return "-" + s, nil
+	case parser.ApplyNode:
+		return nix.VisitApply(n.p, node)
+	case parser.ParensNode:
+		return n.visitValue(node.Nodes[0])
+	default:
+		return "", fmt.Errorf("unsupported node type: %s", node.Type.String())
+	}
+}
+
+func (n *NixVisitor) visitRoot() (string, error) {
+	node := n.node
+	for node.Type == parser.ParensNode {
+		node = node.Nodes[0]
+	}
+
+	if node.Type != parser.SetNode {
+		return "", fmt.Errorf("the Nix expression must be a set")
+	}
+
+	tree, err := n.errors.BuildAttrTree(node, n.options.DuplicateKeys.Policy)
+	if err != nil {
+		return "", err
+	}
+
+	keys := tree.Order
+	if n.options.SortIterators.SortHashmap {
+		keys = slices.Clone(tree.Order)
+		slices.Sort(keys)
+	}
+
+	e := []string{}
+	for _, k := range keys {
+		if !IsNameValid(k) {
+			return "", fmt.Errorf("invalid variable name '%s'", k)
+		}
+
+		if tree.Children[k].Value == nil {
+			return "", fmt.Errorf("variable '%s': nested sets are not supported", k)
+		}
+
+		value, err := n.visitValue(tree.Children[k].Value)
+		if err != nil {
+			return "", converter.AtKey(err, k)
+		}
+
+		e = append(e, k+"="+value)
+	}
+
+	return strings.Join(e, "\n"), nil
+}
+
+func (n *NixVisitor) Visit() (string, error) {
+	v, err := n.visitRoot()
+	return n.errors.Done(v, err)
+}
+
+func FromNix(data string, options *converter.ConverterOptions) (string, error) {
+	p, err := nix.Parse(data)
+	if err != nil {
+		return "", err
+	}
+
+	out, err := NewNixVisitor(p, p.Result, options).Visit()
+	if err != nil {
+		return "", err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,27 @@
+package edn
+
+import "github.com/theobori/nix-converter/converter"
+
+type EDNConverter struct {
+	data    string
+	options *converter.ConverterOptions
+}
+
+func NewEDNConverter(data string, options *converter.ConverterOptions) *EDNConverter {
+	return &EDNConverter{
+		data,
+		options,
+	}
+}
+
+func (e *EDNConverter) FromNix() (string, error) {
+	return FromNix(e.data, e.options)
+}
+
+func (e *EDNConverter) ToNix() (string, error) {
+	return ToNix(e.data, e.options)
+}
+
+func (e *EDNConverter) Type() string {
+	return "edn"
+}
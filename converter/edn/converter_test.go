@@ -0,0 +1,185 @@
+package edn
+
+import (
+	"testing"
+
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/options"
+	"github.com/theobori/nix-converter/internal/common"
+)
+
+var ednStrings = []string{
+	`{
+  :server {
+    :port 8080
+    :host "0.0.0.0"
+  }
+  :log-level :info
+  :handler my.ns/handler
+  :roles #{
+    :admin
+    :user
+  }
+  :started #inst "1985-04-12T23:20:50.52Z"
+  :ratio -1.5
+  :big 123N
+  :money 1.50M
+  :sep \space
+  :c \a
+  :args (
+    1
+    -2
+    "three\n"
+  )
+  :lookup {
+    "a" 1
+    2 [
+      nil
+      true
+    ]
+  }
+  "not a keyword" {}
+  :empty []
+}`,
+	`[]`,
+	`"a \"string\""`,
+}
+
+var nixStrings = []string{
+	`{
+  name = "nix-converter";
+  tags = [
+    {
+      _type = "keyword";
+      value = "a/b";
+    }
+    {
+      _type = "symbol";
+      value = "+";
+    }
+    {
+      _type = "char";
+      value = "x";
+    }
+  ];
+  nested = {
+    _type = "tagged";
+    tag = "my/tag";
+    value = {
+      _type = "list";
+      value = [];
+    };
+  };
+  value = -1;
+  float = 1.0;
+  null = null;
+}`,
+	`[]`,
+}
+
+func TestEDNToNix(t *testing.T) {
+	t.Parallel()
+	common.TestHelperToNixStrings(t, ednStrings, FromNix, ToNix, converter.NewDefaultConverterOptions())
+}
+
+func TestEDNFromNix(t *testing.T) {
+	t.Parallel()
+	options := converter.ConverterOptions{
+		SortIterators: *options.NewDefaultSortIterators(),
+		UnsafeKeys:    true,
+	}
+
+	common.TestHelperFromNixStrings(t, nixStrings, FromNix, ToNix, &options)
+}
+
+func TestEDNSyntax(t *testing.T) {
+	t.Parallel()
+	options := converter.NewDefaultConverterOptions()
+	options.UnsafeKeys = true
+
+	input := `; comment
+#_ discarded {:a 1, :b [2 3] #_ 4 :c \A}`
+
+	want := `{
+  a = 1;
+  b = [
+    2
+    3
+  ];
+  c = {
+    _type = "char";
+    value = "A";
+  };
+}`
+
+	output, err := ToNix(input, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if output != want {
+		t.Errorf("ToNix() = \n%v, want \n%v", output, want)
+	}
+}
+
+func TestEDNErrors(t *testing.T) {
+	t.Parallel()
+	ednInputs := []string{
+		`{:a 1`,
+		`{:a}`,
+		`{:a 1 :a 2}`,
+		`[1 2`,
+		`"unterminated`,
+		`#{1 1}`,
+		`1 2`,
+		`)`,
+		`\`,
+		`#_`,
+	}
+
+	for _, input := range ednInputs {
+		if _, err := ToNix(input, converter.NewDefaultConverterOptions()); err == nil {
+			t.Errorf("ToNix(%q) expected an error", input)
+		}
+	}
+
+	nixInputs := []string{
+		`{ _type = "keyword"; value = "a b"; }`,
+		`{ _type = "keyword"; }`,
+		`{ _type = "symbol"; value = "1a"; }`,
+		`{ _type = "char"; value = "ab"; }`,
+		`{ _type = "bigint"; value = "1.5"; }`,
+		`{ _type = "decimal"; value = "a"; }`,
+		`{ _type = "list"; value = 1; }`,
+		`{ _type = "tagged"; tag = "_x"; value = 1; }`,
+		`{ _type = "map"; value = [ { key = 1; } ]; }`,
+		`{ _type = "keyword"; value = "a"; extra = 1; }`,
+		`{ a = x; }`,
+	}
+
+	for _, input := range nixInputs {
+		if _, err := FromNix(input, converter.NewDefaultConverterOptions()); err == nil {
+			t.Errorf("FromNix(%q) expected an error", input)
+		}
+	}
+}
+
+// The keys defined twice are located at their second definition
+func TestEDNDuplicateKeyPosition(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"{:a 1\n :a 2}", "line 2, column 2: the key 'a' is defined twice"},
+		{"{:b {:a 1}\n :c {:a 1 :a 2}}", "line 2, column 11: the key 'a' is defined twice"},
+		{"{\"a\" 1\n \"a\" 2}", "line 2, column 2: duplicate map key string \"a\""},
+	}
+
+	for _, tt := range tests {
+		_, err := ToNix(tt.input, converter.NewDefaultConverterOptions())
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("ToNix(%q): expected the error %q, got %v", tt.input, tt.expected, err)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// Package edn converts EDN (extensible data notation) values to Nix and vice
+// versa.
+//
+// Vectors are lists, strings, integers, floats, booleans and nil are the
+// matching Nix values. A map whose keys are all keywords is a set, the keys
+// being the keyword names without the colon, like { port = 8080; } for
+// {:port 8080}.
+//
+// Any other value is a tagged set, a set whose "_type" key names the kind of
+// value.
+//
+//   - A keyword is { _type = "keyword"; value = "ns/name"; }.
+//   - A symbol is { _type = "symbol"; value = "ns/name"; }.
+//   - A character is { _type = "char"; value = "c"; }.
+//   - A list is { _type = "list"; value = [ ... ]; }.
+//   - A set is { _type = "set"; value = [ ... ]; }.
+//   - A tagged literal is { _type = "tagged"; tag = "inst"; value = "1985-04-12T23:20:50.52Z"; }.
+//   - An arbitrary precision integer or decimal, like 1N and 1.5M, is
+//     { _type = "bigint"; value = "1"; } or { _type = "decimal"; value = "1.5"; }.
+//   - A map with a key that is not a keyword is
+//     { _type = "map"; value = [ { key = "a"; value = 1; } ]; }.
+//
+// From Nix, a set key that is not a valid keyword name is written as a string.
+// Comments and discarded values (#_) are dropped.
+package edn
+
+const (
+	TypeKey  = "_type"
+	ValueKey = "value"
+	TagKey   = "tag"
+	KeyKey   = "key"
+
+	TypeKeyword = "keyword"
+	TypeSymbol  = "symbol"
+	TypeChar    = "char"
+	TypeList    = "list"
+	TypeSet     = "set"
+	TypeTagged  = "tagged"
+	TypeBigInt  = "bigint"
+	TypeDecimal = "decimal"
+	TypeMap     = "map"
+)
+
+type (
+	Keyword string
+	Symbol  string
+	Char    rune
+	// List is a list written between parentheses, vectors are []any
+	List    []any
+	Set     []any
+	BigInt  string
+	Decimal string
+)
+
+type Tagged struct {
+	Tag   string
+	Value any
+}
+
+// Pair is a map entry, with the line and the column of its key when they are
+// known
+type Pair struct {
+	Key    any
+	Value  any
+	Line   int
+	Column int
+}
+
+// Map is a map keeping the order of its entries
+type Map []Pair
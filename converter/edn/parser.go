@@ -0,0 +1,374 @@
+package edn
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/theobori/nix-converter/converter"
+)
+
+type ednParser struct {
+	s   string
+	pos int
+	// The lines of s, indexed on the first position
+	lines converter.Lines
+}
+
+// Returns the line and the column of an offset
+func (p *ednParser) position(offset int) (int, int) {
+	if p.lines == nil {
+		p.lines = converter.NewLines([]byte(p.s))
+	}
+
+	return p.lines.LineColumn(min(offset, len(p.s)))
+}
+
+func (p *ednParser) errorf(format string, a ...any) error {
+	line, column := p.position(p.pos)
+	return converter.Errorf(line, column, format, a...)
+}
+
+func (p *ednParser) peek() int {
+	if p.pos >= len(p.s) {
+		return -1
+	}
+
+	return int(p.s[p.pos])
+}
+
+func isWhitespace(c int) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',' || c == '\f'
+}
+
+func isDelimiter(c int) bool {
+	return c == -1 || isWhitespace(c) || strings.IndexByte("()[]{}\";", byte(c)) >= 0
+}
+
+// Skips whitespace, commas and comments
+func (p *ednParser) skipSpace() {
+	for {
+		switch c := p.peek(); {
+		case isWhitespace(c):
+			p.pos++
+		case c == ';':
+			for p.peek() != -1 && p.peek() != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *ednParser) token() string {
+	start := p.pos
+	for !isDelimiter(p.peek()) {
+		p.pos++
+	}
+
+	return p.s[start:p.pos]
+}
+
+func (p *ednParser) string() (string, error) {
+	p.pos++
+
+	var b strings.Builder
+	for {
+		c := p.peek()
+		p.pos++
+
+		switch c {
+		case -1:
+			return "", p.errorf("unterminated string")
+		case '"':
+			return b.String(), nil
+		case '\\':
+			e := p.peek()
+			p.pos++
+			switch e {
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case 'n':
+				b.WriteByte('\n')
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case '\\', '"':
+				b.WriteByte(byte(e))
+			case 'u':
+				if p.pos+4 > len(p.s) {
+					return "", p.errorf("invalid unicode escape")
+				}
+				code, err := strconv.ParseUint(p.s[p.pos:p.pos+4], 16, 16)
+				if err != nil {
+					return "", p.errorf("invalid unicode escape")
+				}
+				b.WriteRune(rune(code))
+				p.pos += 4
+			default:
+				return "", p.errorf("invalid escape '\\%c'", e)
+			}
+		default:
+			b.WriteByte(byte(c))
+		}
+	}
+}
+
+func (p *ednParser) char() (any, error) {
+	p.pos++
+
+	// A character may be a delimiter itself, like \( or \space
+	r, size := utf8.DecodeRuneInString(p.s[p.pos:])
+	if size == 0 {
+		return nil, p.errorf("unexpected end of input after '\\'")
+	}
+	p.pos += size
+	name := string(r) + p.token()
+
+	if utf8.RuneCountInString(name) == 1 {
+		return Char(r), nil
+	}
+
+	for c, n := range charNames {
+		if n == name {
+			return Char(c), nil
+		}
+	}
+
+	if strings.HasPrefix(name, "u") && len(name) == 5 {
+		if code, err := strconv.ParseUint(name[1:], 16, 16); err == nil {
+			return Char(rune(code)), nil
+		}
+	}
+
+	return nil, p.errorf("invalid character '\\%s'", name)
+}
+
+func (p *ednParser) number(token string) (any, error) {
+	switch {
+	case strings.HasSuffix(token, "N"):
+		digits := strings.TrimSuffix(token, "N")
+		if _, err := strconv.ParseInt(digits, 10, 64); err != nil && !isBigInteger(digits) {
+			return nil, p.errorf("invalid number '%s'", token)
+		}
+		return BigInt(strings.TrimPrefix(digits, "+")), nil
+	case strings.HasSuffix(token, "M"):
+		digits := strings.TrimSuffix(token, "M")
+		if _, err := strconv.ParseFloat(digits, 64); err != nil {
+			return nil, p.errorf("invalid number '%s'", token)
+		}
+		return Decimal(strings.TrimPrefix(digits, "+")), nil
+	case strings.ContainsAny(token, ".eE"):
+		f, err := strconv.ParseFloat(token, 64)
+		if err != nil || strings.ContainsAny(token, "xX_") {
+			return nil, p.errorf("invalid number '%s'", token)
+		}
+		return f, nil
+	default:
+		i, err := strconv.ParseInt(token, 10, 64)
+		if err != nil {
+			return nil, p.errorf("invalid integer '%s', use the N suffix for large integers", token)
+		}
+		return i, nil
+	}
+}
+
+func isBigInteger(s string) bool {
+	s = strings.TrimLeft(s, "+-")
+	if s == "" {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Reads the values until the closing character, with their offsets
+func (p *ednParser) values(end byte) ([]any, []int, error) {
+	p.pos++
+
+	values := []any{}
+	offsets := []int{}
+	for {
+		p.skipSpace()
+
+		switch p.peek() {
+		case -1:
+			return nil, nil, p.errorf("expected '%c'", end)
+		case int(end):
+			p.pos++
+			return values, offsets, nil
+		}
+
+		offset := p.pos
+		v, isDiscarded, err := p.value()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if !isDiscarded {
+			values = append(values, v)
+			offsets = append(offsets, offset)
+		}
+	}
+}
+
+func (p *ednParser) dict() (any, error) {
+	values, offsets, err := p.values('}')
+	if err != nil {
+		return nil, err
+	}
+
+	if len(values)%2 != 0 {
+		return nil, p.errorf("a map needs an even amount of values")
+	}
+
+	m := Map{}
+	for i := 0; i < len(values); i += 2 {
+		line, column := p.position(offsets[i])
+
+		// The duplicated keywords are left to the duplicate keys policy
+		_, isKeyword := values[i].(Keyword)
+		for _, pair := range m {
+			if !isKeyword && keyString(pair.Key) == keyString(values[i]) {
+				return nil, converter.Errorf(line, column, "duplicate map key %s", keyString(values[i]))
+			}
+		}
+
+		m = append(m, Pair{Key: values[i], Value: values[i+1], Line: line, Column: column})
+	}
+
+	return m, nil
+}
+
+// Returns a comparable representation of a map key
+func keyString(v any) string {
+	return fmt.Sprintf("%T %#v", v, v)
+}
+
+func (p *ednParser) dispatch() (any, bool, error) {
+	p.pos++
+
+	switch p.peek() {
+	case '{':
+		values, _, err := p.values('}')
+		if err != nil {
+			return nil, false, err
+		}
+		for i := range values {
+			for _, v := range values[:i] {
+				if keyString(v) == keyString(values[i]) {
+					return nil, false, p.errorf("duplicate set element %s", keyString(v))
+				}
+			}
+		}
+		return Set(values), false, nil
+	case '_':
+		p.pos++
+		p.skipSpace()
+		_, _, err := p.value()
+		return nil, true, err
+	}
+
+	tag := p.token()
+	if !IsSymbolValid(tag) || !(tag[0] >= 'a' && tag[0] <= 'z' || tag[0] >= 'A' && tag[0] <= 'Z') {
+		return nil, false, p.errorf("invalid tag '#%s'", tag)
+	}
+
+	p.skipSpace()
+	v, isDiscarded, err := p.value()
+	if err != nil {
+		return nil, false, err
+	}
+	if isDiscarded {
+		return nil, false, p.errorf("the tag '#%s' needs a value", tag)
+	}
+
+	return Tagged{tag, v}, false, nil
+}
+
+// Reads a value, discarded values are reported
+func (p *ednParser) value() (any, bool, error) {
+	switch c := p.peek(); c {
+	case -1:
+		return nil, false, p.errorf("unexpected end of input")
+	case '"':
+		s, err := p.string()
+		return s, false, err
+	case '\\':
+		v, err := p.char()
+		return v, false, err
+	case '[':
+		v, _, err := p.values(']')
+		return v, false, err
+	case '(':
+		v, _, err := p.values(')')
+		return List(v), false, err
+	case '{':
+		v, err := p.dict()
+		return v, false, err
+	case '#':
+		return p.dispatch()
+	case ')', ']', '}':
+		return nil, false, p.errorf("unexpected '%c'", c)
+	}
+
+	token := p.token()
+
+	switch {
+	case token == "nil":
+		return nil, false, nil
+	case token == "true" || token == "false":
+		return token == "true", false, nil
+	case token == "":
+		return nil, false, p.errorf("unexpected character '%c'", p.peek())
+	case isDigit(token[0]) || len(token) > 1 && (token[0] == '-' || token[0] == '+') && isDigit(token[1]):
+		v, err := p.number(token)
+		return v, false, err
+	case token[0] == ':':
+		if !IsKeywordValid(token[1:]) {
+			return nil, false, p.errorf("invalid keyword '%s'", token)
+		}
+		return Keyword(token[1:]), false, nil
+	default:
+		if !IsSymbolValid(token) {
+			return nil, false, p.errorf("invalid symbol '%s'", token)
+		}
+		return Symbol(token), false, nil
+	}
+}
+
+// Parse reads a single EDN value
+func Parse(data string) (any, error) {
+	p := ednParser{s: data}
+
+	var (
+		v           any
+		isDiscarded = true
+		err         error
+	)
+	for isDiscarded {
+		p.skipSpace()
+		v, isDiscarded, err = p.value()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	p.skipSpace()
+	if p.peek() != -1 {
+		return nil, p.errorf("expected a single value")
+	}
+
+	return v, nil
+}
@@ -0,0 +1,52 @@
+package edn
+
+import (
+	"fmt"
+	"strings"
+)
+
+var stringReplacer = strings.NewReplacer(
+	"\\", "\\\\",
+	"\"", "\\\"",
+	"\n", "\\n",
+	"\r", "\\r",
+	"\t", "\\t",
+)
+
+// Quote writes an EDN string, other control characters are written as
+// unicode escapes
+func Quote(s string) string {
+	var b strings.Builder
+
+	for _, r := range stringReplacer.Replace(s) {
+		if r < 0x20 || r == 0x7F {
+			fmt.Fprintf(&b, "\\u%04x", r)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+
+	return "\"" + b.String() + "\""
+}
+
+var charNames = map[rune]string{
+	'\n': "newline",
+	'\r': "return",
+	' ':  "space",
+	'\t': "tab",
+	'\b': "backspace",
+	'\f': "formfeed",
+}
+
+// QuoteChar writes an EDN character
+func QuoteChar(r rune) string {
+	if name, exists := charNames[r]; exists {
+		return "\\" + name
+	}
+
+	if r < 0x20 || r == 0x7F {
+		return fmt.Sprintf("\\u%04x", r)
+	}
+
+	return "\\" + string(r)
+}
@@ -0,0 +1,64 @@
+package edn
+
+import "strings"
+
+const symbolChars = ".*+!-_?$%&=<>/:#'"
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func IsSymbolChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || isDigit(c) ||
+		c >= 0x80 || strings.IndexByte(symbolChars, c) >= 0
+}
+
+func isNamePartValid(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if !IsSymbolChar(s[i]) || s[i] == '/' {
+			return false
+		}
+	}
+
+	// It must not start like a number
+	if isDigit(s[0]) || s[0] == ':' || s[0] == '#' {
+		return false
+	}
+	if (s[0] == '-' || s[0] == '+' || s[0] == '.') && len(s) > 1 && isDigit(s[1]) {
+		return false
+	}
+
+	return true
+}
+
+// IsSymbolValid reports if a string is a valid symbol, with an optional
+// namespace prefix
+func IsSymbolValid(s string) bool {
+	switch s {
+	case "/":
+		return true
+	case "nil", "true", "false":
+		return false
+	}
+
+	prefix, name, hasPrefix := strings.Cut(s, "/")
+	if hasPrefix {
+		return isNamePartValid(prefix) && (name == "/" || isNamePartValid(name))
+	}
+
+	return isNamePartValid(s)
+}
+
+// IsKeywordValid reports if a string is a valid keyword name, without its colon
+func IsKeywordValid(s string) bool {
+	switch s {
+	case "nil", "true", "false":
+		return true
+	}
+
+	return s != "/" && IsSymbolValid(s)
+}
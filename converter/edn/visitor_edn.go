@@ -0,0 +1,252 @@
+package edn
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/nix"
+	"github.com/theobori/nix-converter/internal/common"
+)
+
+type EDNVisitor struct {
+	i       common.Indentation
+	node    any
+	limiter *converter.Limiter
+	options *converter.ConverterOptions
+}
+
+func NewEDNVisitor(node any, options *converter.ConverterOptions) *EDNVisitor {
+	return &EDNVisitor{
+		i:       *common.NewIndentationUnit(options.NixIndentUnit()),
+		node:    node,
+		limiter: converter.NewLimiter(options),
+		options: options,
+	}
+}
+
+func (e *EDNVisitor) visitString(s string) string {
+	if strings.Contains(s, "\n") {
+		return common.MakeIndentedString(s, e.i.IndentValue(), e.i.Unit())
+	}
+
+	return common.MakeStringSafe(s)
+}
+
+// Builds a tagged set, the values are visited one indentation level deeper
+func (e *EDNVisitor) visitTagged(kind string, keys []string, values []any) (string, error) {
+	lines := []string{e.visitString(kind)}
+
+	e.i.Indent()
+	for _, value := range values {
+		s, err := e.visit(value)
+		if err != nil {
+			return "", err
+		}
+		lines = append(lines, s)
+	}
+
+	for i, key := range append([]string{TypeKey}, keys...) {
+		left := nix.MakeNameSafe(key, e.options.UnsafeKeys)
+		lines[i] = e.i.IndentValue() + left + " = " + lines[i] + ";"
+	}
+	e.i.UnIndent()
+
+	return "{\n" + strings.Join(lines, "\n") + "\n" + e.i.IndentValue() + "}", nil
+}
+
+// Returns the single keyword of a map and its value
+func (e *EDNVisitor) single(v any) (string, any, bool) {
+	m, isMap := v.(Map)
+	if !isMap || len(m) != 1 {
+		return "", nil, false
+	}
+
+	key, isKeyword := m[0].Key.(Keyword)
+	if !isKeyword {
+		return "", nil, false
+	}
+
+	return string(key), m[0].Value, true
+}
+
+func hasKeywordKeys(m Map) bool {
+	for _, pair := range m {
+		if _, isKeyword := pair.Key.(Keyword); !isKeyword {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Applies the duplicate keys policy to a map whose keys are keywords
+func (e *EDNVisitor) mergeKeys(m Map) (Map, error) {
+	entries := make([]nix.Entry[any], len(m))
+	for i, pair := range m {
+		entries[i] = nix.Entry[any]{Key: string(pair.Key.(Keyword)), Value: pair.Value, Line: pair.Line, Column: pair.Column}
+	}
+
+	merged, err := nix.MergeEntries(entries, e.options.DuplicateKeys.Policy, e.mergeMaps)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(Map, len(merged))
+	for i, entry := range merged {
+		out[i] = Pair{Key: Keyword(entry.Key), Value: entry.Value, Line: entry.Line, Column: entry.Column}
+	}
+
+	return out, nil
+}
+
+// Returns the deep merge of two maps whose keys are keywords
+func (e *EDNVisitor) mergeMaps(a any, b any) (any, bool, error) {
+	ma, isMap := a.(Map)
+	mb, isOtherMap := b.(Map)
+	if !isMap || !isOtherMap || !hasKeywordKeys(ma) || !hasKeywordKeys(mb) {
+		return nil, false, nil
+	}
+
+	merged, err := e.mergeKeys(append(slices.Clone(ma), mb...))
+	if err != nil {
+		return nil, false, err
+	}
+
+	return merged, true, nil
+}
+
+func (e *EDNVisitor) visitMap(m Map) (string, error) {
+	if len(m) == 0 {
+		return "{}", nil
+	}
+
+	if !hasKeywordKeys(m) {
+		entries := []any{}
+		for _, pair := range m {
+			entries = append(entries, Map{{Key: Keyword(KeyKey), Value: pair.Key}, {Key: Keyword(ValueKey), Value: pair.Value}})
+		}
+		return e.visitTagged(TypeMap, []string{ValueKey}, []any{entries})
+	}
+
+	m, err := e.mergeKeys(m)
+	if err != nil {
+		return "", err
+	}
+
+	lines := []string{}
+	for _, pair := range m {
+		path, value := nix.CollapsePath(string(pair.Key.(Keyword)), pair.Value, e.single, &e.options.NixOutput)
+
+		e.i.Indent()
+		s, err := e.visit(value)
+		if err != nil {
+			return "", err
+		}
+
+		left := nix.MakeAttrPath(path, e.options.UnsafeKeys)
+		lines = append(lines, e.i.IndentValue()+left+" = "+s+";")
+		e.i.UnIndent()
+	}
+
+	if e.options.SortIterators.SortHashmap {
+		slices.Sort(lines)
+	}
+
+	return "{\n" + strings.Join(lines, "\n") + "\n" + e.i.IndentValue() + "}", nil
+}
+
+func (e *EDNVisitor) visitVector(vector []any) (string, error) {
+	if len(vector) == 0 {
+		return "[]", nil
+	}
+
+	lines := []string{}
+	for _, item := range vector {
+		e.i.Indent()
+		s, err := e.visit(item)
+		if err != nil {
+			return "", err
+		}
+
+		lines = append(lines, e.i.IndentValue()+nix.MakeElementSafe(s))
+		e.i.UnIndent()
+	}
+
+	if e.options.SortIterators.SortList {
+		slices.Sort(lines)
+	}
+
+	return "[\n" + strings.Join(lines, "\n") + "\n" + e.i.IndentValue() + "]", nil
+}
+
+func (e *EDNVisitor) visit(node any) (string, error) {
+	if err := e.limiter.Enter(); err != nil {
+		return "", err
+	}
+	defer e.limiter.Leave()
+
+	switch v := node.(type) {
+	case nil:
+		return "null", nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int64:
+		return nix.FormatInt(v), nil
+	case float64:
+		if math.IsInf(v, 0) {
+			return "", fmt.Errorf("the number %v is out of range", v)
+		}
+		return nix.FormatFloat(v), nil
+	case string:
+		return e.visitString(v), nil
+	case []any:
+		return e.visitVector(v)
+	case Map:
+		return e.visitMap(v)
+	case Keyword:
+		return e.visitTagged(TypeKeyword, []string{ValueKey}, []any{string(v)})
+	case Symbol:
+		return e.visitTagged(TypeSymbol, []string{ValueKey}, []any{string(v)})
+	case Char:
+		return e.visitTagged(TypeChar, []string{ValueKey}, []any{string(v)})
+	case List:
+		return e.visitTagged(TypeList, []string{ValueKey}, []any{[]any(v)})
+	case Set:
+		return e.visitTagged(TypeSet, []string{ValueKey}, []any{[]any(v)})
+	case Tagged:
+		return e.visitTagged(TypeTagged, []string{TagKey, ValueKey}, []any{v.Tag, v.Value})
+	case BigInt:
+		return e.visitTagged(TypeBigInt, []string{ValueKey}, []any{string(v)})
+	case Decimal:
+		return e.visitTagged(TypeDecimal, []string{ValueKey}, []any{string(v)})
+	default:
+		return "", fmt.Errorf("unsupported value type: %T", v)
+	}
+}
+
+func (e *EDNVisitor) Visit() (string, error) {
+	out, err := e.visit(e.node)
+	if err != nil {
+		return "", err
+	}
+
+	return e.limiter.Done(out)
+}
+
+func ToNix(data string, options *converter.ConverterOptions) (string, error) {
+	node, err := Parse(data)
+	if err != nil {
+		return "", err
+	}
+
+	out, err := NewEDNVisitor(node, options).Visit()
+	if err != nil {
+		return "", err
+	}
+
+	return nix.Layout(out, &options.NixOutput), nil
+}
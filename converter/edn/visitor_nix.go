@@ -0,0 +1,382 @@
+package edn
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/orivej/go-nix/nix/parser"
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/nix"
+	"github.com/theobori/nix-converter/internal/common"
+)
+
+// Keys of the tagged sets, the type key excluded
+var taggedKeys = map[string][]string{
+	TypeKeyword: {ValueKey},
+	TypeSymbol:  {ValueKey},
+	TypeChar:    {ValueKey},
+	TypeList:    {ValueKey},
+	TypeSet:     {ValueKey},
+	TypeTagged:  {TagKey, ValueKey},
+	TypeBigInt:  {ValueKey},
+	TypeDecimal: {ValueKey},
+	TypeMap:     {ValueKey},
+}
+
+type NixVisitor struct {
+	i       common.Indentation
+	p       *parser.Parser
+	errors  *nix.Collector
+	node    *parser.Node
+	options *converter.ConverterOptions
+}
+
+func NewNixVisitor(p *parser.Parser, node *parser.Node, options *converter.ConverterOptions) *NixVisitor {
+	return &NixVisitor{
+		i:       *common.NewIndentationUnit(options.Indentation.Unit()),
+		node:    node,
+		p:       p,
+		errors:  nix.NewCollector(p, node, options),
+		options: options,
+	}
+}
+
+func unwrapParens(node *parser.Node) *parser.Node {
+	for node.Type == parser.ParensNode {
+		node = node.Nodes[0]
+	}
+
+	return node
+}
+
+func (n *NixVisitor) keys(tree *nix.AttrNode) []string {
+	if !n.options.SortIterators.SortHashmap {
+		return tree.Order
+	}
+
+	keys := slices.Clone(tree.Order)
+	slices.Sort(keys)
+
+	return keys
+}
+
+// Joins already indented elements between delimiters
+func (n *NixVisitor) join(open string, e []string, close string) string {
+	if len(e) == 0 {
+		return open + close
+	}
+
+	return open + "\n" + strings.Join(e, "\n") + "\n" + n.i.IndentValue() + close
+}
+
+func (n *NixVisitor) visitAttr(attr *nix.AttrNode) (string, error) {
+	tree, isSet, err := attr.Set(n.p)
+	if err != nil {
+		return "", err
+	}
+
+	if isSet {
+		return n.visitSet(tree)
+	}
+
+	return n.visit(attr.Value)
+}
+
+func (n *NixVisitor) visitSet(tree *nix.AttrNode) (string, error) {
+	kind, isTagged, err := n.taggedKind(tree)
+	if err != nil {
+		return "", err
+	}
+	if isTagged {
+		return n.visitTagged(kind, tree)
+	}
+
+	e := []string{}
+	n.i.Indent()
+	for _, k := range n.keys(tree) {
+		s, err := n.visitAttr(tree.Children[k])
+		if err != nil {
+			return "", converter.AtKey(err, k)
+		}
+
+		key := Quote(k)
+		if IsKeywordValid(k) {
+			key = ":" + k
+		}
+		e = append(e, n.i.IndentValue()+key+" "+s)
+	}
+	n.i.UnIndent()
+
+	return n.join("{", e, "}"), nil
+}
+
+func (n *NixVisitor) taggedKind(tree *nix.AttrNode) (string, bool, error) {
+	attr, exists := tree.Children[TypeKey]
+	if !exists || attr.Value == nil {
+		return "", false, nil
+	}
+
+	kind, err := nix.VisitStringValue(n.p, attr.Value)
+	if err != nil {
+		return "", false, nil
+	}
+
+	keys, isKnown := taggedKeys[kind]
+	if !isKnown {
+		return "", false, nil
+	}
+
+	if len(tree.Order) != len(keys)+1 {
+		return "", false, fmt.Errorf("a set of type '%s' must only have the keys %s and %v", kind, TypeKey, keys)
+	}
+	for _, key := range keys {
+		if _, exists := tree.Children[key]; !exists {
+			return "", false, fmt.Errorf("a set of type '%s' needs the key '%s'", kind, key)
+		}
+	}
+
+	return kind, true, nil
+}
+
+func (n *NixVisitor) visitStringAttr(kind string, attr *nix.AttrNode) (string, error) {
+	if attr.Value == nil {
+		return "", fmt.Errorf("%s: expected a string", kind)
+	}
+
+	s, err := nix.VisitStringValue(n.p, attr.Value)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", kind, err)
+	}
+
+	return s, nil
+}
+
+func (n *NixVisitor) visitElements(attr *nix.AttrNode) ([]string, error) {
+	if attr.Value == nil || unwrapParens(attr.Value).Type != parser.ListNode {
+		return nil, fmt.Errorf("expected a list")
+	}
+
+	e := []string{}
+	n.i.Indent()
+	for index, child := range unwrapParens(attr.Value).Nodes {
+		s, err := n.visit(child)
+		if err != nil {
+			return nil, converter.AtIndex(err, index)
+		}
+
+		e = append(e, n.i.IndentValue()+s)
+	}
+	n.i.UnIndent()
+
+	if n.options.SortIterators.SortList {
+		slices.Sort(e)
+	}
+
+	return e, nil
+}
+
+func (n *NixVisitor) visitTaggedMap(attr *nix.AttrNode) (string, error) {
+	errEntries := fmt.Errorf("map: expected a list of sets with the keys '%s' and '%s'", KeyKey, ValueKey)
+	if attr.Value == nil || unwrapParens(attr.Value).Type != parser.ListNode {
+		return "", errEntries
+	}
+
+	e := []string{}
+	n.i.Indent()
+	for index, child := range unwrapParens(attr.Value).Nodes {
+		child = unwrapParens(child)
+		if child.Type != parser.SetNode {
+			return "", errEntries
+		}
+
+		tree, err := n.errors.BuildAttrTree(child, n.options.DuplicateKeys.Policy)
+		if err != nil {
+			return "", converter.AtIndex(err, index)
+		}
+
+		key, hasKey := tree.Children[KeyKey]
+		value, hasValue := tree.Children[ValueKey]
+		if !hasKey || !hasValue || len(tree.Order) != 2 {
+			return "", errEntries
+		}
+
+		k, err := n.visitAttr(key)
+		if err != nil {
+			return "", converter.AtIndex(err, index)
+		}
+
+		v, err := n.visitAttr(value)
+		if err != nil {
+			return "", converter.AtIndex(err, index)
+		}
+
+		e = append(e, n.i.IndentValue()+k+" "+v)
+	}
+	n.i.UnIndent()
+
+	return n.join("{", e, "}"), nil
+}
+
+func (n *NixVisitor) visitTagged(kind string, tree *nix.AttrNode) (string, error) {
+	value := tree.Children[ValueKey]
+
+	switch kind {
+	case TypeList, TypeSet:
+		e, err := n.visitElements(value)
+		if err != nil {
+			return "", converter.AtKey(err, ValueKey)
+		}
+		if kind == TypeList {
+			return n.join("(", e, ")"), nil
+		}
+		return n.join("#{", e, "}"), nil
+	case TypeTagged:
+		tag, err := n.visitStringAttr(kind, tree.Children[TagKey])
+		if err != nil {
+			return "", err
+		}
+		if !IsSymbolValid(tag) || !(tag[0] >= 'a' && tag[0] <= 'z' || tag[0] >= 'A' && tag[0] <= 'Z') {
+			return "", fmt.Errorf("invalid tag '%s'", tag)
+		}
+		s, err := n.visitAttr(value)
+		if err != nil {
+			return "", err
+		}
+		return "#" + tag + " " + s, nil
+	case TypeMap:
+		return n.visitTaggedMap(value)
+	}
+
+	s, err := n.visitStringAttr(kind, value)
+	if err != nil {
+		return "", err
+	}
+
+	switch kind {
+	case TypeKeyword:
+		if !IsKeywordValid(s) {
+			return "", fmt.Errorf("invalid keyword '%s'", s)
+		}
+		return ":" + s, nil
+	case TypeSymbol:
+		if !IsSymbolValid(s) {
+			return "", fmt.Errorf("invalid symbol '%s'", s)
+		}
+		return s, nil
+	case TypeChar:
+		if utf8.RuneCountInString(s) != 1 {
+			return "", fmt.Errorf("a character must be a single character, got '%s'", s)
+		}
+		r, _ := utf8.DecodeRuneInString(s)
+		return QuoteChar(r), nil
+	case TypeBigInt:
+		if !isBigInteger(s) {
+			return "", fmt.Errorf("invalid integer '%s'", s)
+		}
+		return s + "N", nil
+	default:
+		if _, err := strconv.ParseFloat(s, 64); err != nil || strings.ContainsAny(s, "xX_") {
+			return "", fmt.Errorf("invalid decimal '%s'", s)
+		}
+		return s + "M", nil
+	}
+}
+
+func (n *NixVisitor) visitNumber(node *parser.Node, sign string) (string, error) {
+	switch node.Type {
+	case parser.IntNode:
+		s, err := nix.VisitInt(n.p, node)
+		if err != nil {
+			return "", err
+		}
+		return sign + s, nil
+	case parser.FloatNode, parser.ApplyNode:
+		var (
+			f   float64
+			err error
+		)
+		if node.Type == parser.FloatNode {
+			f, err = nix.VisitFloatRaw(n.p, node)
+		} else {
+			f, err = nix.VisitApplyRaw(n.p, node)
+		}
+		if err != nil {
+			return "", err
+		}
+		return sign + nix.FormatFloat(f), nil
+	case parser.ParensNode:
+		return n.visitNumber(node.Nodes[0], sign)
+	default:
+		return "", fmt.Errorf("expected a number, got %s", node.Type.String())
+	}
+}
+
+func (n *NixVisitor) visit(node *parser.Node) (string, error) {
+	return nix.Visit(n.errors, node, n.visitNode, "nil")
+}
+
+func (n *NixVisitor) visitNode(node *parser.Node) (string, error) {
+	switch node.Type {
+	case parser.SetNode:
+		tree, err := n.errors.BuildAttrTree(node, n.options.DuplicateKeys.Policy)
+		if err != nil {
+			return "", err
+		}
+		return n.visitSet(tree)
+	case parser.ListNode:
+		e, err := n.visitElements(nix.NewAttrNode(node, n.options.DuplicateKeys.Policy))
+		if err != nil {
+			return "", err
+		}
+		return n.join("[", e, "]"), nil
+	case parser.StringNode, parser.IStringNode:
+		s, err := nix.VisitStringValue(n.p, node)
+		if err != nil {
+			return "", err
+		}
+		return Quote(s), nil
+	case parser.IDNode:
+		id, err := nix.VisitID(n.p, node)
+		if err != nil {
+			return "", err
+		}
+		switch id {
+		case "true", "false":
+			return id, nil
+		case "null":
+			return "nil", nil
+		default:
+			return "", fmt.Errorf("unsupported identifier: %s", id)
+		}
+	case parser.IntNode, parser.FloatNode, parser.ApplyNode:
+		return n.visitNumber(node, "")
+	case parser.OpNode + 57378: // The negative unary operator
+		return n.visitNumber(node.Nodes[0], "-")
+	case parser.ParensNode:
+		return n.visit(node.Nodes[0])
+	default:
+		return "", fmt.Errorf("unsupported node type: %s", node.Type.String())
+	}
+}
+
+func (n *NixVisitor) Visit() (string, error) {
+	v, err := n.visit(n.node)
+	return n.errors.Done(v, err)
+}
+
+func FromNix(data string, options *converter.ConverterOptions) (string, error) {
+	p, err := nix.Parse(data)
+	if err != nil {
+		return "", err
+	}
+
+	out, err := NewNixVisitor(p, p.Result, options).Visit()
+	if err != nil {
+		return "", err
+	}
+
+	return out, nil
+}
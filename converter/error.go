@@ -0,0 +1,231 @@
+package converter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Error is a conversion error located in the input
+type Error struct {
+	// Input file name, empty when unknown
+	File string
+	// One-based line and column of the input, zero when unknown
+	Line   int
+	Column int
+	// Path of the value in the document, like a.b[0].c
+	Path string
+	Err  error
+}
+
+// Errorf returns an error at a line and a column of the input, zero when
+// unknown, the %w verb wraps an error like with fmt.Errorf
+func Errorf(line int, column int, format string, a ...any) *Error {
+	return &Error{
+		Line:   line,
+		Column: column,
+		Err:    fmt.Errorf(format, a...),
+	}
+}
+
+// AsError returns err as an *Error, wrapping it when needed
+func AsError(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+
+	return &Error{Err: err}
+}
+
+// LineColumn returns the one-based line and column of a byte offset of data
+func LineColumn(data []byte, offset int) (int, int) {
+	offset = min(max(offset, 0), len(data))
+	line := 1 + bytes.Count(data[:offset], []byte("\n"))
+	column := offset - bytes.LastIndexByte(data[:offset], '\n')
+
+	return line, column
+}
+
+// Lines are the offsets of the starts of the lines of an input, to locate
+// many offsets of a large input
+type Lines []int
+
+func NewLines(data []byte) Lines {
+	lines := Lines{0}
+	for i, b := range data {
+		if b == '\n' {
+			lines = append(lines, i+1)
+		}
+	}
+
+	return lines
+}
+
+// LineColumn returns the one-based line and column of a byte offset, like
+// the LineColumn function
+func (l Lines) LineColumn(offset int) (int, int) {
+	offset = max(offset, 0)
+	line := sort.Search(len(l), func(i int) bool { return l[i] > offset })
+
+	return line, offset - l[line-1] + 1
+}
+
+// Within locates err, or every error of Errors, in the whole input when it
+// is located in a part of it starting at a line and a column. An error
+// without a position is given the one of the part.
+func Within(err error, line int, column int) error {
+	if err == nil {
+		return nil
+	}
+
+	var errs Errors
+	if errors.As(err, &errs) {
+		for _, e := range errs {
+			Within(e, line, column)
+		}
+		return errs
+	}
+
+	e := AsError(err)
+	if e.Line == 0 {
+		e.Line, e.Column = line, column
+		return e
+	}
+
+	// The columns of the first line of the part are shifted
+	if e.Line == 1 && e.Column > 0 {
+		e.Column += column - 1
+	}
+	e.Line += line - 1
+
+	return e
+}
+
+// Returns the position in the file:line:col form, without the missing parts
+func (e *Error) location() string {
+	parts := []string{}
+	if e.File != "" {
+		parts = append(parts, e.File)
+	}
+
+	if e.Line > 0 {
+		parts = append(parts, strconv.Itoa(e.Line))
+		if e.Column > 0 {
+			parts = append(parts, strconv.Itoa(e.Column))
+		}
+	}
+
+	return strings.Join(parts, ":")
+}
+
+func (e *Error) Error() string {
+	message := e.Err.Error()
+	if e.Path != "" {
+		message += " (at " + e.Path + ")"
+	}
+
+	// Without a file name, the position is written in words
+	if e.File == "" && e.Line > 0 {
+		if e.Column > 0 {
+			return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, message)
+		}
+		return fmt.Sprintf("line %d: %s", e.Line, message)
+	}
+
+	if location := e.location(); location != "" {
+		return location + ": " + message
+	}
+
+	return message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Returns a key of a path, quoted when it would be ambiguous
+func pathKey(key string) string {
+	if key == "" || strings.ContainsAny(key, ".[]\" \n") {
+		return strconv.Quote(key)
+	}
+
+	return key
+}
+
+// AtKey prepends the key of a set or an object to the path of err, or of
+// every error of Errors, when err is not nil
+func AtKey(err error, key string) error {
+	if err == nil {
+		return nil
+	}
+
+	var errs Errors
+	if errors.As(err, &errs) {
+		for _, e := range errs {
+			AtKey(e, key)
+		}
+		return errs
+	}
+
+	e := AsError(err)
+	switch {
+	case e.Path == "":
+		e.Path = pathKey(key)
+	case strings.HasPrefix(e.Path, "["):
+		e.Path = pathKey(key) + e.Path
+	default:
+		e.Path = pathKey(key) + "." + e.Path
+	}
+
+	return e
+}
+
+// AtIndex prepends the index of a list to the path of err, or of every error
+// of Errors, when err is not nil
+func AtIndex(err error, index int) error {
+	if err == nil {
+		return nil
+	}
+
+	var errs Errors
+	if errors.As(err, &errs) {
+		for _, e := range errs {
+			AtIndex(e, index)
+		}
+		return errs
+	}
+
+	e := AsError(err)
+	if e.Path == "" || strings.HasPrefix(e.Path, "[") {
+		e.Path = "[" + strconv.Itoa(index) + "]" + e.Path
+	} else {
+		e.Path = "[" + strconv.Itoa(index) + "]." + e.Path
+	}
+
+	return e
+}
+
+// Errors are every error of a conversion, in the order of the input
+type Errors []*Error
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, "\n")
+}
+
+func (e Errors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+
+	return errs
+}
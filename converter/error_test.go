@@ -0,0 +1,78 @@
+package converter
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestErrorString(t *testing.T) {
+	tests := []struct {
+		err      *Error
+		expected string
+	}{
+		{&Error{Err: fmt.Errorf("oops")}, "oops"},
+		{&Error{Line: 3, Err: fmt.Errorf("oops")}, "line 3: oops"},
+		{&Error{Line: 3, Column: 7, Err: fmt.Errorf("oops")}, "line 3, column 7: oops"},
+		{&Error{File: "a.nix", Err: fmt.Errorf("oops")}, "a.nix: oops"},
+		{&Error{File: "a.nix", Line: 3, Err: fmt.Errorf("oops")}, "a.nix:3: oops"},
+		{&Error{File: "a.nix", Line: 3, Column: 7, Path: "a.b", Err: fmt.Errorf("oops")}, "a.nix:3:7: oops (at a.b)"},
+	}
+
+	for _, test := range tests {
+		if s := test.err.Error(); s != test.expected {
+			t.Errorf("expected %q, got %q", test.expected, s)
+		}
+	}
+}
+
+func TestErrorPath(t *testing.T) {
+	err := fmt.Errorf("oops")
+	err = AtKey(err, "c")
+	err = AtIndex(err, 0)
+	err = AtKey(err, "b")
+	err = AtKey(err, "a.x")
+	err = AtIndex(err, 2)
+
+	e := AsError(err)
+	if e.Path != `[2]."a.x".b[0].c` {
+		t.Errorf("unexpected path %q", e.Path)
+	}
+
+	if AtKey(nil, "a") != nil || AtIndex(nil, 0) != nil {
+		t.Errorf("a nil error must stay nil")
+	}
+}
+
+func TestErrorWithin(t *testing.T) {
+	tests := []struct {
+		err      *Error
+		expected string
+	}{
+		{&Error{Err: fmt.Errorf("oops")}, "line 3, column 5: oops"},
+		{&Error{Line: 1, Column: 2, Err: fmt.Errorf("oops")}, "line 3, column 6: oops"},
+		{&Error{Line: 2, Column: 2, Err: fmt.Errorf("oops")}, "line 4, column 2: oops"},
+		{&Error{Line: 1, Err: fmt.Errorf("oops")}, "line 3: oops"},
+	}
+
+	for _, test := range tests {
+		if s := Within(test.err, 3, 5).Error(); s != test.expected {
+			t.Errorf("expected %q, got %q", test.expected, s)
+		}
+	}
+
+	if line, column := LineColumn([]byte("ab\ncd"), 4); line != 2 || column != 2 {
+		t.Errorf("expected 2:2, got %d:%d", line, column)
+	}
+}
+
+func TestLines(t *testing.T) {
+	data := []byte("ab\n\ncd\ne")
+	lines := NewLines(data)
+
+	for offset := 0; offset <= len(data); offset++ {
+		line, column := LineColumn(data, offset)
+		if l, c := lines.LineColumn(offset); l != line || c != column {
+			t.Errorf("offset %d: expected %d:%d, got %d:%d", offset, line, column, l, c)
+		}
+	}
+}
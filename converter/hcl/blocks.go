@@ -0,0 +1,42 @@
+// Package hcl converts HCL bodies, like Terraform configurations and
+// variable files, to Nix and vice versa.
+//
+// Attributes are Nix attributes and blocks are nested sets, one level per
+// label, following the Terraform JSON syntax. A block of a known type (see
+// BlockLabels) is a set, or a list of sets when the same block is repeated,
+// such as resource.aws_instance.web. Any other block is always a list of sets,
+// so a nested block like lifecycle becomes lifecycle = [ { ... } ].
+//
+// From Nix, a set under a known block type is written as a block, and inside
+// a block, a list of sets under an unknown key is written as repeated blocks
+// without labels. Any other value is written as an attribute, so variable
+// files only hold attributes. Unknown block types with labels cannot be
+// written back as blocks.
+//
+// Strings are HCL templates, a reference like var.name is kept as the string
+// "${var.name}", which Terraform evaluates to the same value.
+package hcl
+
+// BlockLabels maps the known block types to their amount of labels
+var BlockLabels = map[string]int{
+	"resource":           2,
+	"data":               2,
+	"ephemeral":          2,
+	"module":             1,
+	"variable":           1,
+	"output":             1,
+	"provider":           1,
+	"check":              1,
+	"backend":            1,
+	"provisioner":        1,
+	"dynamic":            1,
+	"terraform":          0,
+	"locals":             0,
+	"moved":              0,
+	"import":             0,
+	"removed":            0,
+	"required_providers": 0,
+	"cloud":              0,
+	"lifecycle":          0,
+	"connection":         0,
+}
@@ -0,0 +1,27 @@
+package hcl
+
+import "github.com/theobori/nix-converter/converter"
+
+type HCLConverter struct {
+	data    string
+	options *converter.ConverterOptions
+}
+
+func NewHCLConverter(data string, options *converter.ConverterOptions) *HCLConverter {
+	return &HCLConverter{
+		data,
+		options,
+	}
+}
+
+func (h *HCLConverter) FromNix() (string, error) {
+	return FromNix(h.data, h.options)
+}
+
+func (h *HCLConverter) ToNix() (string, error) {
+	return ToNix(h.data, h.options)
+}
+
+func (h *HCLConverter) Type() string {
+	return "hcl"
+}
@@ -0,0 +1,250 @@
+package hcl
+
+import (
+	"testing"
+
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/options"
+	"github.com/theobori/nix-converter/internal/common"
+)
+
+var hclStrings = []string{
+	`region        = "eu-west-3"
+instance_type = "t3.micro"
+replicas      = 3
+ratio         = -1.5
+enabled       = true
+zones = [
+  "a",
+  "b",
+]
+tags = {
+  Name        = "web"
+  Environment = "prod"
+}
+nothing = null`,
+	`terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+variable "region" {
+  default = "eu-west-3"
+}
+
+resource "aws_instance" "web" {
+  ami   = "ami-123"
+  count = 2
+  tags = {
+    Name = "web-${count.index}"
+  }
+  ebs_block_device {
+    device_name = "/dev/sda"
+  }
+  ebs_block_device {
+    device_name = "/dev/sdb"
+  }
+  lifecycle {
+    create_before_destroy = true
+  }
+  user_data = <<EOT
+#!/bin/sh
+echo "$${HOME}"
+EOT
+}
+
+resource "aws_instance" "db" {
+  ami = "ami-456"
+}`,
+}
+
+var nixStrings = []string{
+	`{
+  region = "eu-west-3";
+  replicas = 3;
+  ratio = -1.5;
+  tags = {
+    Name = "web";
+    "with space" = "a\"b";
+  };
+  zones = [
+    "a"
+    "b"
+  ];
+  nothing = null;
+}`,
+	`{
+  locals = {
+    name = "app";
+  };
+  resource = {
+    null_resource = {
+      a = {
+        triggers = {
+          name = "\${local.name}";
+        };
+        provisioner = {
+          local-exec = {
+            command = "echo hi";
+          };
+        };
+        dynamic_list = [
+          {
+            name = "a";
+          }
+        ];
+      };
+    };
+  };
+}`,
+}
+
+func TestHCLToNix(t *testing.T) {
+	t.Parallel()
+	common.TestHelperToNixStrings(t, hclStrings, FromNix, ToNix, converter.NewDefaultConverterOptions())
+}
+
+func TestHCLFromNix(t *testing.T) {
+	t.Parallel()
+	options := converter.ConverterOptions{
+		SortIterators: *options.NewDefaultSortIterators(),
+		UnsafeKeys:    true,
+	}
+
+	common.TestHelperFromNixStrings(t, nixStrings, FromNix, ToNix, &options)
+}
+
+func TestHCLMapping(t *testing.T) {
+	t.Parallel()
+	options := converter.NewDefaultConverterOptions()
+	options.UnsafeKeys = true
+
+	input := `module "vpc" {
+  source = "./vpc"
+  cidr   = var.cidr
+  name   = "${var.env}-vpc"
+}
+
+settings {
+  level = 1
+}`
+
+	want := `{
+  module = {
+    vpc = {
+      source = "./vpc";
+      cidr = "\${var.cidr}";
+      name = "\${var.env}-vpc";
+    };
+  };
+  settings = [
+    {
+      level = 1;
+    }
+  ];
+}`
+
+	output, err := ToNix(input, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if output != want {
+		t.Errorf("ToNix() = \n%v, want \n%v", output, want)
+	}
+}
+
+// The HCL identifiers that are not Nix identifiers, like the Nix keywords,
+// are quoted, and the smallest integer is computed as its literal overflows,
+// the output is read back
+func TestHCLIdentifiers(t *testing.T) {
+	t.Parallel()
+	options := converter.NewDefaultConverterOptions()
+	options.UnsafeKeys = true
+
+	input := `in = 1
+é = 2
+rec "with" {
+  min = -9223372036854775808
+  list = [-9223372036854775808]
+}
+o = { or = 3, __curPos = 4 }`
+
+	want := `{
+  "in" = 1;
+  "é" = 2;
+  "rec" = {
+    "with" = [
+      {
+        min = (-9223372036854775807 - 1);
+        list = [
+          (-9223372036854775807 - 1)
+        ];
+      }
+    ];
+  };
+  o = {
+    "or" = 3;
+    __curPos = 4;
+  };
+}`
+
+	output, err := ToNix(input, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if output != want {
+		t.Errorf("ToNix() = \n%v, want \n%v", output, want)
+	}
+
+	// The output is read back, with the smallest integer
+	input, err = FromNix(output, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	again, err := ToNix(input, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if again != want {
+		t.Errorf("ToNix(FromNix()) = \n%v, want \n%v", again, want)
+	}
+}
+
+func TestHCLErrors(t *testing.T) {
+	t.Parallel()
+	hclInputs := []string{
+		`a = `,
+		"a = 1\na = 2",
+		"a = 1\na {\n}",
+		"resource \"a\" \"b\" {\n}\nresource \"a\" {\n}",
+		`a = 99999999999999999999`,
+		`a = -9223372036854775809`,
+	}
+
+	for _, input := range hclInputs {
+		if _, err := ToNix(input, converter.NewDefaultConverterOptions()); err == nil {
+			t.Errorf("ToNix(%q) expected an error", input)
+		}
+	}
+
+	nixInputs := []string{
+		`[ 1 ]`,
+		`{ "a b" = 1; }`,
+		`{ resource = { a = 1; }; }`,
+		`{ a = "${b}"; }`,
+	}
+
+	for _, input := range nixInputs {
+		if _, err := FromNix(input, converter.NewDefaultConverterOptions()); err == nil {
+			t.Errorf("FromNix(%q) expected an error", input)
+		}
+	}
+}
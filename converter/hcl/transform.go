@@ -0,0 +1,49 @@
+package hcl
+
+import (
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+var stringReplacer = strings.NewReplacer(
+	"\\", "\\\\",
+	"\"", "\\\"",
+	"\n", "\\n",
+	"\r", "\\r",
+	"\t", "\\t",
+)
+
+// MakeString writes a string holding an HCL template, template sequences
+// are kept as they are. Strings ending with a newline are written as heredocs
+func MakeString(s string) string {
+	if !strings.HasSuffix(s, "\n") || strings.Contains(s, "\r") {
+		return "\"" + stringReplacer.Replace(s) + "\""
+	}
+
+	marker := "EOT"
+	lines := strings.Split(s, "\n")
+	for i := 0; containsLine(lines, marker); i++ {
+		marker = "EOT" + strings.Repeat("_", i+1)
+	}
+
+	return "<<" + marker + "\n" + s + marker
+}
+
+func containsLine(lines []string, line string) bool {
+	for _, l := range lines {
+		if strings.TrimSpace(l) == line {
+			return true
+		}
+	}
+
+	return false
+}
+
+func MakeKey(s string) string {
+	if hclsyntax.ValidIdentifier(s) {
+		return s
+	}
+
+	return MakeString(s)
+}
@@ -0,0 +1,439 @@
+package hcl
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/nix"
+	"github.com/theobori/nix-converter/internal/common"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Entry is an attribute, the blocks sharing the same type and labels or a
+// set of entries created by the block labels
+type Entry struct {
+	key       string
+	attribute *hclsyntax.Attribute
+	bodies    []*hclsyntax.Body
+	isList    bool
+	children  []*Entry
+	index     map[string]*Entry
+}
+
+func newEntry(key string) *Entry {
+	return &Entry{key: key, index: map[string]*Entry{}}
+}
+
+func (e *Entry) child(key string) *Entry {
+	c, exists := e.index[key]
+	if !exists {
+		c = newEntry(key)
+		e.index[key] = c
+		e.children = append(e.children, c)
+	}
+
+	return c
+}
+
+type HCLVisitor struct {
+	i       common.Indentation
+	src     []byte
+	body    *hclsyntax.Body
+	limiter *converter.Limiter
+	options *converter.ConverterOptions
+}
+
+func NewHCLVisitor(src []byte, body *hclsyntax.Body, options *converter.ConverterOptions) *HCLVisitor {
+	return &HCLVisitor{
+		i:       *common.NewIndentationUnit(options.NixIndentUnit()),
+		src:     src,
+		body:    body,
+		limiter: converter.NewLimiter(options),
+		options: options,
+	}
+}
+
+func (h *HCLVisitor) source(r hcl.Range) string {
+	return string(h.src[r.Start.Byte:r.End.Byte])
+}
+
+func (h *HCLVisitor) visitString(s string) string {
+	if strings.Contains(s, "\n") {
+		return common.MakeIndentedString(s, h.i.IndentValue(), h.i.Unit())
+	}
+
+	return common.MakeStringSafe(s)
+}
+
+// Escape the template sequences of a literal string
+func escapeTemplate(s string) string {
+	s = strings.ReplaceAll(s, "${", "$${")
+	return strings.ReplaceAll(s, "%{", "%%{")
+}
+
+func (h *HCLVisitor) visitTemplate(expr hclsyntax.Expression) string {
+	switch e := expr.(type) {
+	case *hclsyntax.TemplateWrapExpr:
+		return "${" + h.source(e.Wrapped.Range()) + "}"
+	case *hclsyntax.TemplateExpr:
+		var b strings.Builder
+		for _, part := range e.Parts {
+			literal, ok := part.(*hclsyntax.LiteralValueExpr)
+			if ok && literal.Val.Type() == cty.String {
+				b.WriteString(escapeTemplate(literal.Val.AsString()))
+			} else {
+				b.WriteString("${" + h.source(part.Range()) + "}")
+			}
+		}
+		return b.String()
+	default:
+		return "${" + h.source(expr.Range()) + "}"
+	}
+}
+
+func (h *HCLVisitor) visitNumber(v cty.Value) (string, error) {
+	bf := v.AsBigFloat()
+
+	if bf.IsInt() {
+		bi, _ := bf.Int(nil)
+		if !bi.IsInt64() {
+			return "", fmt.Errorf("number out of range")
+		}
+		return nix.FormatInt(bi.Int64()), nil
+	}
+
+	if bf.Cmp(big.NewFloat(math.MaxInt64)) > 0 || bf.Cmp(big.NewFloat(math.MinInt64)) < 0 {
+		return "", fmt.Errorf("number out of range")
+	}
+
+	return bf.Text('f', -1), nil
+}
+
+func (h *HCLVisitor) visitValue(v cty.Value) (string, error) {
+	if v.IsNull() {
+		return "null", nil
+	}
+
+	switch v.Type() {
+	case cty.String:
+		return h.visitString(escapeTemplate(v.AsString())), nil
+	case cty.Number:
+		return h.visitNumber(v)
+	case cty.Bool:
+		if v.True() {
+			return "true", nil
+		}
+		return "false", nil
+	default:
+		return "", fmt.Errorf("unsupported value type: %s", v.Type().FriendlyName())
+	}
+}
+
+func (h *HCLVisitor) visitTuple(expr *hclsyntax.TupleConsExpr) (string, error) {
+	if len(expr.Exprs) == 0 {
+		return "[]", nil
+	}
+
+	e := []string{}
+	for _, item := range expr.Exprs {
+		h.i.Indent()
+		s, err := h.visitExpression(item)
+		if err != nil {
+			return "", err
+		}
+
+		e = append(e, h.i.IndentValue()+nix.MakeElementSafe(s))
+		h.i.UnIndent()
+	}
+
+	if h.options.SortIterators.SortList {
+		slices.Sort(e)
+	}
+
+	return "[\n" + strings.Join(e, "\n") + "\n" + h.i.IndentValue() + "]", nil
+}
+
+// rangeError locates an error at the start of a source range
+func rangeError(r hcl.Range, format string, a ...any) error {
+	return converter.Errorf(r.Start.Line, r.Start.Column, format, a...)
+}
+
+// diagnosticsError locates the first error of the diagnostics
+func diagnosticsError(diags hcl.Diagnostics) error {
+	for _, diag := range diags {
+		if diag.Severity != hcl.DiagError || diag.Subject == nil {
+			continue
+		}
+		if diag.Detail == "" {
+			return rangeError(*diag.Subject, "%s", diag.Summary)
+		}
+		return rangeError(*diag.Subject, "%s; %s", diag.Summary, diag.Detail)
+	}
+
+	return diags
+}
+
+func objectKey(item hclsyntax.ObjectConsItem) (string, error) {
+	key := hcl.ExprAsKeyword(item.KeyExpr)
+	if key != "" {
+		return key, nil
+	}
+
+	v, diags := item.KeyExpr.Value(nil)
+	if diags.HasErrors() || v.Type() != cty.String || v.IsNull() {
+		return "", rangeError(item.KeyExpr.Range(), "unsupported object key")
+	}
+
+	return v.AsString(), nil
+}
+
+// Returns the single item of an object expression
+func (h *HCLVisitor) singleItem(expr hclsyntax.Expression) (string, hclsyntax.Expression, bool) {
+	o, isObject := expr.(*hclsyntax.ObjectConsExpr)
+	if !isObject || len(o.Items) != 1 {
+		return "", nil, false
+	}
+
+	key, err := objectKey(o.Items[0])
+	if err != nil {
+		return "", nil, false
+	}
+
+	return key, o.Items[0].ValueExpr, true
+}
+
+func (h *HCLVisitor) visitObject(expr *hclsyntax.ObjectConsExpr) (string, error) {
+	if len(expr.Items) == 0 {
+		return "{}", nil
+	}
+
+	e := []string{}
+	for _, item := range expr.Items {
+		key, err := objectKey(item)
+		if err != nil {
+			return "", err
+		}
+
+		path, value := nix.CollapsePath(key, item.ValueExpr, h.singleItem, &h.options.NixOutput)
+
+		h.i.Indent()
+		s, err := h.visitExpression(value)
+		if err != nil {
+			return "", err
+		}
+
+		left := nix.MakeAttrPath(path, h.options.UnsafeKeys)
+		e = append(e, h.i.IndentValue()+left+" = "+s+";")
+		h.i.UnIndent()
+	}
+
+	if h.options.SortIterators.SortHashmap {
+		slices.Sort(e)
+	}
+
+	return "{\n" + strings.Join(e, "\n") + "\n" + h.i.IndentValue() + "}", nil
+}
+
+func (h *HCLVisitor) visitExpression(expr hclsyntax.Expression) (string, error) {
+	if err := h.limiter.Enter(); err != nil {
+		return "", err
+	}
+	defer h.limiter.Leave()
+
+	switch e := expr.(type) {
+	case *hclsyntax.TupleConsExpr:
+		return h.visitTuple(e)
+	case *hclsyntax.ObjectConsExpr:
+		return h.visitObject(e)
+	case *hclsyntax.TemplateExpr, *hclsyntax.TemplateWrapExpr:
+		return h.visitString(h.visitTemplate(e)), nil
+	}
+
+	// Expressions needing variables or functions are kept as templates
+	v, diags := expr.Value(nil)
+	if diags.HasErrors() || !v.IsWhollyKnown() || !v.Type().IsPrimitiveType() && !v.IsNull() {
+		return h.visitString(h.visitTemplate(expr)), nil
+	}
+
+	s, err := h.visitValue(v)
+	if err != nil {
+		return "", rangeError(expr.Range(), "%s", err)
+	}
+
+	return s, nil
+}
+
+func (h *HCLVisitor) buildEntries(body *hclsyntax.Body) (*Entry, error) {
+	root := newEntry("")
+
+	type item struct {
+		attribute *hclsyntax.Attribute
+		block     *hclsyntax.Block
+		offset    int
+	}
+
+	// Attributes are stored in a map, the source order is restored
+	items := []item{}
+	for _, attribute := range body.Attributes {
+		items = append(items, item{attribute: attribute, offset: attribute.SrcRange.Start.Byte})
+	}
+	for _, block := range body.Blocks {
+		items = append(items, item{block: block, offset: block.TypeRange.Start.Byte})
+	}
+	slices.SortFunc(items, func(a, b item) int {
+		return a.offset - b.offset
+	})
+
+	for _, it := range items {
+		if it.attribute != nil {
+			e := root.child(it.attribute.Name)
+			if len(e.bodies) > 0 || len(e.children) > 0 {
+				return nil, rangeError(it.attribute.SrcRange, "the attribute '%s' conflicts with a block", it.attribute.Name)
+			}
+			e.attribute = it.attribute
+			continue
+		}
+
+		block := it.block
+		_, known := BlockLabels[block.Type]
+
+		e := root
+		for _, key := range append([]string{block.Type}, block.Labels...) {
+			if e.attribute != nil || len(e.bodies) > 0 {
+				return nil, rangeError(block.TypeRange, "the block '%s' conflicts with another attribute or block", block.Type)
+			}
+			e = e.child(key)
+		}
+
+		if e.attribute != nil || len(e.children) > 0 {
+			return nil, rangeError(block.TypeRange, "the block '%s' conflicts with another attribute or block", block.Type)
+		}
+
+		e.bodies = append(e.bodies, block.Body)
+		e.isList = !known
+	}
+
+	return root, nil
+}
+
+func (h *HCLVisitor) visitEntry(entry *Entry) (string, error) {
+	if err := h.limiter.Enter(); err != nil {
+		return "", err
+	}
+	defer h.limiter.Leave()
+
+	if entry.attribute != nil {
+		return h.visitExpression(entry.attribute.Expr)
+	}
+
+	if len(entry.bodies) == 0 {
+		return h.visitEntries(entry)
+	}
+
+	if len(entry.bodies) == 1 && !entry.isList {
+		return h.visitBody(entry.bodies[0])
+	}
+
+	e := []string{}
+	for _, body := range entry.bodies {
+		h.i.Indent()
+		s, err := h.visitBody(body)
+		if err != nil {
+			return "", err
+		}
+
+		e = append(e, h.i.IndentValue()+s)
+		h.i.UnIndent()
+	}
+
+	return "[\n" + strings.Join(e, "\n") + "\n" + h.i.IndentValue() + "]", nil
+}
+
+// Returns the single child of an entry written as a set, the labels of a
+// block or the content of a single block
+func (h *HCLVisitor) singleEntry(entry *Entry) (string, *Entry, bool) {
+	if entry.attribute != nil || len(entry.bodies) > 1 || entry.isList {
+		return "", nil, false
+	}
+
+	if len(entry.bodies) == 1 {
+		root, err := h.buildEntries(entry.bodies[0])
+		if err != nil {
+			return "", nil, false
+		}
+		entry = root
+	}
+
+	if len(entry.children) != 1 {
+		return "", nil, false
+	}
+
+	return entry.children[0].key, entry.children[0], true
+}
+
+func (h *HCLVisitor) visitEntries(entry *Entry) (string, error) {
+	if len(entry.children) == 0 {
+		return "{}", nil
+	}
+
+	e := []string{}
+	for _, child := range entry.children {
+		path, last := nix.CollapsePath(child.key, child, h.singleEntry, &h.options.NixOutput)
+
+		h.i.Indent()
+		s, err := h.visitEntry(last)
+		if err != nil {
+			return "", err
+		}
+
+		left := nix.MakeAttrPath(path, h.options.UnsafeKeys)
+		e = append(e, h.i.IndentValue()+left+" = "+s+";")
+		h.i.UnIndent()
+	}
+
+	if h.options.SortIterators.SortHashmap {
+		slices.Sort(e)
+	}
+
+	return "{\n" + strings.Join(e, "\n") + "\n" + h.i.IndentValue() + "}", nil
+}
+
+func (h *HCLVisitor) visitBody(body *hclsyntax.Body) (string, error) {
+	root, err := h.buildEntries(body)
+	if err != nil {
+		return "", err
+	}
+
+	return h.visitEntries(root)
+}
+
+func (h *HCLVisitor) Visit() (string, error) {
+	out, err := h.visitBody(h.body)
+	if err != nil {
+		return "", err
+	}
+
+	return h.limiter.Done(out)
+}
+
+func ToNix(data string, options *converter.ConverterOptions) (string, error) {
+	src := []byte(data)
+
+	file, diags := hclsyntax.ParseConfig(src, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return "", diagnosticsError(diags)
+	}
+
+	out, err := NewHCLVisitor(src, file.Body.(*hclsyntax.Body), options).Visit()
+	if err != nil {
+		return "", err
+	}
+
+	return nix.Layout(out, &options.NixOutput), nil
+}
@@ -0,0 +1,313 @@
+package hcl
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/orivej/go-nix/nix/parser"
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/nix"
+	"github.com/theobori/nix-converter/internal/common"
+)
+
+type NixVisitor struct {
+	i       common.Indentation
+	p       *parser.Parser
+	errors  *nix.Collector
+	node    *parser.Node
+	options *converter.ConverterOptions
+}
+
+func NewNixVisitor(p *parser.Parser, node *parser.Node, options *converter.ConverterOptions) *NixVisitor {
+	return &NixVisitor{
+		i:       *common.NewIndentationUnit(options.Indentation.Unit()),
+		node:    node,
+		p:       p,
+		errors:  nix.NewCollector(p, node, options),
+		options: options,
+	}
+}
+
+func unwrapParens(node *parser.Node) *parser.Node {
+	for node.Type == parser.ParensNode {
+		node = node.Nodes[0]
+	}
+
+	return node
+}
+
+func (n *NixVisitor) keys(tree *nix.AttrNode) []string {
+	if !n.options.SortIterators.SortHashmap {
+		return tree.Order
+	}
+
+	keys := slices.Clone(tree.Order)
+	slices.Sort(keys)
+
+	return keys
+}
+
+func (n *NixVisitor) visitObject(tree *nix.AttrNode) (string, error) {
+	if len(tree.Order) == 0 {
+		return "{}", nil
+	}
+
+	e := []string{}
+	n.i.Indent()
+	for _, k := range n.keys(tree) {
+		s, err := n.visitAttrValue(tree.Children[k])
+		if err != nil {
+			return "", converter.AtKey(err, k)
+		}
+
+		e = append(e, n.i.IndentValue()+MakeKey(k)+" = "+s)
+	}
+	n.i.UnIndent()
+
+	return "{\n" + strings.Join(e, "\n") + "\n" + n.i.IndentValue() + "}", nil
+}
+
+func (n *NixVisitor) visitList(node *parser.Node) (string, error) {
+	if len(node.Nodes) == 0 {
+		return "[]", nil
+	}
+
+	e := []string{}
+	n.i.Indent()
+	for index, child := range node.Nodes {
+		s, err := n.visit(child)
+		if err != nil {
+			return "", converter.AtIndex(err, index)
+		}
+
+		e = append(e, n.i.IndentValue()+s+",")
+	}
+	n.i.UnIndent()
+
+	if n.options.SortIterators.SortList {
+		slices.Sort(e)
+	}
+
+	return "[\n" + strings.Join(e, "\n") + "\n" + n.i.IndentValue() + "]", nil
+}
+
+func (n *NixVisitor) visitAttrValue(attr *nix.AttrNode) (string, error) {
+	tree, isSet, err := attr.Set(n.p)
+	if err != nil {
+		return "", err
+	}
+
+	if isSet {
+		return n.visitObject(tree)
+	}
+
+	return n.visit(attr.Value)
+}
+
+func (n *NixVisitor) visit(node *parser.Node) (string, error) {
+	return nix.Visit(n.errors, node, n.visitNode, "null")
+}
+
+func (n *NixVisitor) visitNode(node *parser.Node) (string, error) {
+	switch node.Type {
+	case parser.SetNode:
+		tree, err := n.errors.BuildAttrTree(node, n.options.DuplicateKeys.Policy)
+		if err != nil {
+			return "", err
+		}
+		return n.visitObject(tree)
+	case parser.ListNode:
+		return n.visitList(node)
+	case parser.StringNode, parser.IStringNode:
+		s, err := nix.VisitStringValue(n.p, node)
+		if err != nil {
+			return "", err
+		}
+		return MakeString(s), nil
+	case parser.IDNode:
+		return nix.VisitID(n.p, node)
+	case parser.IntNode:
+		return nix.VisitInt(n.p, node)
+	case parser.FloatNode:
+		return nix.VisitFloat(n.p, node)
+	case parser.OpNode + 57378: // The negative unary operator
+		s, err := n.visit(node.Nodes[0])
+		if err != nil {
+			return "", err
+		}
+		return "-" + s, nil
+	case parser.ApplyNode:
+		return nix.VisitApply(n.p, node)
+	case parser.ParensNode:
+		return n.visit(node.Nodes[0])
+	default:
+		return "", fmt.Errorf("unsupported node type: %s", node.Type.String())
+	}
+}
+
+// A list of sets inside a block is written as repeated blocks
+func isBlockList(node *parser.Node) bool {
+	node = unwrapParens(node)
+	if node.Type != parser.ListNode || len(node.Nodes) == 0 {
+		return false
+	}
+
+	for _, child := range node.Nodes {
+		if unwrapParens(child).Type != parser.SetNode {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (n *NixVisitor) visitBlocks(header string, attr *nix.AttrNode, labels int) ([]string, error) {
+	tree, isSet, err := attr.Set(n.p)
+	if err != nil {
+		return nil, err
+	}
+
+	if labels > 0 {
+		if !isSet {
+			return nil, fmt.Errorf("the block '%s' needs %d more label(s)", header, labels)
+		}
+
+		e := []string{}
+		for _, k := range n.keys(tree) {
+			blocks, err := n.visitBlocks(header+" "+MakeString(k), tree.Children[k], labels-1)
+			if err != nil {
+				return nil, converter.AtKey(err, k)
+			}
+			e = append(e, blocks...)
+		}
+
+		return e, nil
+	}
+
+	if isSet {
+		block, err := n.visitBlock(header, tree)
+		if err != nil {
+			return nil, err
+		}
+		return []string{block}, nil
+	}
+
+	if !isBlockList(attr.Value) {
+		return nil, fmt.Errorf("the block '%s' must be a set or a list of sets", header)
+	}
+
+	e := []string{}
+	for index, child := range unwrapParens(attr.Value).Nodes {
+		tree, err := n.errors.BuildAttrTree(unwrapParens(child), n.options.DuplicateKeys.Policy)
+		if err != nil {
+			return nil, converter.AtIndex(err, index)
+		}
+
+		block, err := n.visitBlock(header, tree)
+		if err != nil {
+			return nil, converter.AtIndex(err, index)
+		}
+		e = append(e, block)
+	}
+
+	return e, nil
+}
+
+func (n *NixVisitor) visitBlock(header string, tree *nix.AttrNode) (string, error) {
+	n.i.Indent()
+	body, err := n.visitBody(tree, true)
+	n.i.UnIndent()
+	if err != nil {
+		return "", err
+	}
+
+	if len(body) == 0 {
+		return n.i.IndentValue() + header + " {}", nil
+	}
+
+	return n.i.IndentValue() + header + " {\n" + strings.Join(body, "\n") + "\n" + n.i.IndentValue() + "}", nil
+}
+
+func (n *NixVisitor) visitBody(tree *nix.AttrNode, inBlock bool) ([]string, error) {
+	e := []string{}
+
+	for _, k := range n.keys(tree) {
+		attr := tree.Children[k]
+
+		if !hclsyntax.ValidIdentifier(k) {
+			return nil, fmt.Errorf("'%s' is not a valid attribute or block name", k)
+		}
+
+		labels, known := BlockLabels[k]
+		_, isSet, err := attr.Set(n.p)
+		if err != nil {
+			return nil, converter.AtKey(err, k)
+		}
+
+		if known && (isSet || isBlockList(attr.Value)) || !known && inBlock && !isSet && isBlockList(attr.Value) {
+			blocks, err := n.visitBlocks(k, attr, labels)
+			if err != nil {
+				return nil, converter.AtKey(err, k)
+			}
+
+			// Top level blocks are separated by a blank line
+			for _, block := range blocks {
+				if !inBlock && len(e) > 0 {
+					block = "\n" + block
+				}
+				e = append(e, block)
+			}
+			continue
+		}
+
+		s, err := n.visitAttrValue(attr)
+		if err != nil {
+			return nil, converter.AtKey(err, k)
+		}
+		e = append(e, n.i.IndentValue()+k+" = "+s)
+	}
+
+	return e, nil
+}
+
+func (n *NixVisitor) visitRoot() (string, error) {
+	node := unwrapParens(n.node)
+	if node.Type != parser.SetNode {
+		return "", fmt.Errorf("the Nix expression must be a set")
+	}
+
+	tree, err := n.errors.BuildAttrTree(node, n.options.DuplicateKeys.Policy)
+	if err != nil {
+		return "", err
+	}
+
+	body, err := n.visitBody(tree, false)
+	if err != nil {
+		return "", err
+	}
+
+	// Align the attributes like terraform fmt
+	return strings.TrimSuffix(string(hclwrite.Format([]byte(strings.Join(body, "\n")+"\n"))), "\n"), nil
+}
+
+func (n *NixVisitor) Visit() (string, error) {
+	v, err := n.visitRoot()
+	return n.errors.Done(v, err)
+}
+
+func FromNix(data string, options *converter.ConverterOptions) (string, error) {
+	p, err := nix.Parse(data)
+	if err != nil {
+		return "", err
+	}
+
+	out, err := NewNixVisitor(p, p.Result, options).Visit()
+	if err != nil {
+		return "", err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,27 @@
+package ini
+
+import "github.com/theobori/nix-converter/converter"
+
+type INIConverter struct {
+	data    string
+	options *converter.ConverterOptions
+}
+
+func NewINIConverter(data string, options *converter.ConverterOptions) *INIConverter {
+	return &INIConverter{
+		data,
+		options,
+	}
+}
+
+func (i *INIConverter) FromNix() (string, error) {
+	return FromNix(i.data, i.options)
+}
+
+func (i *INIConverter) ToNix() (string, error) {
+	return ToNix(i.data, i.options)
+}
+
+func (i *INIConverter) Type() string {
+	return "ini"
+}
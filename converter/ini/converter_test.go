@@ -0,0 +1,191 @@
+package ini
+
+import (
+	"testing"
+
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/options"
+	"github.com/theobori/nix-converter/internal/common"
+)
+
+var iniStrings = []string{
+	`user = root
+port = 8080
+
+[core]
+bare = false
+filemode = 0755
+workers = "4"
+editor = vim -f
+ratio = -1.5
+
+[remote "origin"]
+url = git@github.com:theobori/nix-converter.git
+fetch = +refs/heads/*:refs/remotes/origin/*
+fetch = +refs/tags/*:refs/tags/*
+
+[remote "upstream"]
+url = "https://example.com/a\"b\\c.git"
+
+[Desktop Entry]
+Name[fr] = Bonjour
+Color = "#ff0000"`,
+}
+
+var nixStrings = []string{
+	`{
+  enable = true;
+  service = {
+    ExecStart = "/bin/app --flag";
+    Environment = [
+      "A=1"
+      "B=2"
+    ];
+    Restart = "true";
+    Nice = -5;
+    Description = " padded ";
+  };
+  branch = {
+    main = {
+      remote = "origin";
+      merge = "refs/heads/main";
+    };
+  };
+  empty = {};
+}`,
+}
+
+func TestINIToNix(t *testing.T) {
+	t.Parallel()
+	common.TestHelperToNixStrings(t, iniStrings, FromNix, ToNix, converter.NewDefaultConverterOptions())
+}
+
+func TestINIFromNix(t *testing.T) {
+	t.Parallel()
+	options := converter.ConverterOptions{
+		SortIterators: *options.NewDefaultSortIterators(),
+		UnsafeKeys:    true,
+	}
+
+	common.TestHelperFromNixStrings(t, nixStrings, FromNix, ToNix, &options)
+}
+
+func TestINIComments(t *testing.T) {
+	t.Parallel()
+	options := converter.NewDefaultConverterOptions()
+	options.UnsafeKeys = true
+	options.INI.InlineComments = true
+
+	input := `; php.ini
+[PHP]
+; Maximum execution time
+max_execution_time = 30 ; seconds
+short_open_tag = Off
+error_log = /var/log/php \
+  errors.log
+
+[user]
+	name = Jane
+	signingkey`
+
+	want := `{
+  # php.ini
+  PHP = {
+    # Maximum execution time
+    # seconds
+    max_execution_time = 30;
+    short_open_tag = "Off";
+    error_log = "/var/log/php errors.log";
+  };
+  user = {
+    name = "Jane";
+    signingkey = true;
+  };
+}`
+
+	output, err := ToNix(input, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if output != want {
+		t.Errorf("ToNix() = \n%v, want \n%v", output, want)
+	}
+}
+
+func TestINIInlineComments(t *testing.T) {
+	t.Parallel()
+	input := "[Unit]\nDescription=Foo ; not a comment?\nColor = #ff0000 # red\nBare ; comment"
+
+	tests := []struct {
+		inlineComments bool
+		want           string
+	}{
+		{false, `{
+  Unit = {
+    Description = "Foo ; not a comment?";
+    Color = "#ff0000 # red";
+    "Bare ; comment" = true;
+  };
+}`},
+		{true, `{
+  Unit = {
+    # not a comment?
+    Description = "Foo";
+    # red
+    Color = "#ff0000";
+    # comment
+    Bare = true;
+  };
+}`},
+	}
+
+	for _, test := range tests {
+		options := converter.NewDefaultConverterOptions()
+		options.UnsafeKeys = true
+		options.INI.InlineComments = test.inlineComments
+
+		output, err := ToNix(input, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if output != test.want {
+			t.Errorf("ToNix() = \n%v, want \n%v", output, test.want)
+		}
+	}
+}
+
+func TestINIErrors(t *testing.T) {
+	t.Parallel()
+	iniInputs := []string{
+		`[core`,
+		`[remote origin"]`,
+		`[remote "origin]`,
+		`= value`,
+		`key = "unterminated`,
+		"[a]\nb = 1\n[a \"b\"]",
+	}
+
+	for _, input := range iniInputs {
+		if _, err := ToNix(input, converter.NewDefaultConverterOptions()); err == nil {
+			t.Errorf("ToNix(%q) expected an error", input)
+		}
+	}
+
+	nixInputs := []string{
+		`[ "a" ]`,
+		`{ a = null; }`,
+		`{ a = [ ]; }`,
+		`{ a = [ { b = 1; } ]; }`,
+		`{ a.b.c.d = 1; }`,
+		`{ "a=b" = 1; }`,
+		`{ "[a]" = { b = 1; }; }`,
+	}
+
+	for _, input := range nixInputs {
+		if _, err := FromNix(input, converter.NewDefaultConverterOptions()); err == nil {
+			t.Errorf("FromNix(%q) expected an error", input)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package ini
+
+import (
+	"fmt"
+	"strings"
+)
+
+var quoteReplacer = strings.NewReplacer(
+	"\\", "\\\\",
+	"\"", "\\\"",
+	"\n", "\\n",
+	"\t", "\\t",
+	"\b", "\\b",
+)
+
+// Quote a value or a subsection name like git-config does
+func Quote(s string) string {
+	return "\"" + quoteReplacer.Replace(s) + "\""
+}
+
+// Unquote reads a double quoted value at the start of s and returns it
+// with the remaining text after the closing quote
+func Unquote(s string) (string, string, error) {
+	var b strings.Builder
+
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			return b.String(), s[i+1:], nil
+		case '\\':
+			i++
+			if i == len(s) {
+				return "", "", fmt.Errorf("unterminated escape sequence")
+			}
+
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'b':
+				b.WriteByte('\b')
+			case '"', '\\':
+				b.WriteByte(s[i])
+			default:
+				return "", "", fmt.Errorf("unknown escape sequence '\\%c'", s[i])
+			}
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+
+	return "", "", fmt.Errorf("missing closing double quote")
+}
+
+// Split an unquoted value from its inline comment, a comment must be
+// preceded by a whitespace so values like colors are kept intact
+func splitInlineComment(s string) (string, string) {
+	for i := 1; i < len(s); i++ {
+		if (s[i] == ';' || s[i] == '#') && (s[i-1] == ' ' || s[i-1] == '\t') {
+			return strings.TrimSpace(s[:i]), s[i:]
+		}
+	}
+
+	return s, ""
+}
+
+// Return the text of a comment without its marker
+func commentText(s string) string {
+	s = strings.TrimLeft(s, ";#")
+	return strings.TrimPrefix(s, " ")
+}
@@ -0,0 +1,52 @@
+package ini
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/theobori/nix-converter/internal/common"
+)
+
+func IsBool(s string) bool {
+	return s == "true" || s == "false"
+}
+
+// Integers with leading zeros, like file modes, are not considered as numbers
+func IsInteger(s string) bool {
+	digits := strings.TrimPrefix(s, "-")
+	if len(digits) > 1 && digits[0] == '0' {
+		return false
+	}
+
+	_, err := strconv.ParseInt(s, 10, 64)
+	return err == nil
+}
+
+func IsFloat(s string) bool {
+	if !common.IsNumber(s) || !strings.Contains(s, ".") {
+		return false
+	}
+
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+// A string must be quoted when reading it back would give another value
+func IsValueUnsafe(s string) bool {
+	return IsBool(s) || IsInteger(s) || IsFloat(s) ||
+		strings.TrimSpace(s) != s ||
+		strings.ContainsAny(s, "\"\\\n\t\b;#")
+}
+
+func IsKeyValid(s string) bool {
+	return s != "" &&
+		strings.TrimSpace(s) == s &&
+		!strings.ContainsAny(s, "=\n\r\"") &&
+		!strings.ContainsAny(s[:1], "[;#")
+}
+
+func IsSectionValid(s string) bool {
+	return s != "" &&
+		strings.TrimSpace(s) == s &&
+		!strings.ContainsAny(s, "[]\"\n\r")
+}
@@ -0,0 +1,324 @@
+package ini
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/nix"
+	"github.com/theobori/nix-converter/internal/common"
+)
+
+type Value struct {
+	raw    string
+	quoted bool
+}
+
+// Entry is either a key with its values or a (sub)section
+type Entry struct {
+	key      string
+	comments []string
+	values   []Value
+	section  *Section
+}
+
+type Section struct {
+	entries []*Entry
+	index   map[string]*Entry
+}
+
+func newSection() *Section {
+	return &Section{index: map[string]*Entry{}}
+}
+
+func (s *Section) entry(key string, comments []string) *Entry {
+	e, exists := s.index[key]
+	if !exists {
+		e = &Entry{key: key}
+		s.index[key] = e
+		s.entries = append(s.entries, e)
+	}
+
+	e.comments = append(e.comments, comments...)
+
+	return e
+}
+
+type INIVisitor struct {
+	i       common.Indentation
+	root    *Section
+	limiter *converter.Limiter
+	options *converter.ConverterOptions
+}
+
+func NewINIVisitor(root *Section, options *converter.ConverterOptions) *INIVisitor {
+	return &INIVisitor{
+		i:       *common.NewIndentationUnit(options.NixIndentUnit()),
+		root:    root,
+		limiter: converter.NewLimiter(options),
+		options: options,
+	}
+}
+
+// visitValue stops at the first exceeded limit, the error is returned by Err
+func (v *INIVisitor) visitValue(value Value) string {
+	if v.limiter.Enter() != nil {
+		return ""
+	}
+	defer v.limiter.Leave()
+
+	s := value.raw
+
+	if !value.quoted && (IsBool(s) || IsInteger(s) || IsFloat(s)) {
+		return s
+	}
+
+	if strings.Contains(s, "\n") {
+		return common.MakeIndentedString(s, v.i.IndentValue(), v.i.Unit())
+	}
+
+	return common.MakeStringSafe(s)
+}
+
+func (v *INIVisitor) visitValues(values []Value) string {
+	if len(values) == 1 {
+		return v.visitValue(values[0])
+	}
+
+	e := []string{}
+	for _, value := range values {
+		v.i.Indent()
+		e = append(e, v.i.IndentValue()+nix.MakeElementSafe(v.visitValue(value)))
+		v.i.UnIndent()
+	}
+
+	if v.options.SortIterators.SortList {
+		slices.Sort(e)
+	}
+
+	return "[\n" + strings.Join(e, "\n") + "\n" + v.i.IndentValue() + "]"
+}
+
+// Returns the single entry of a section, the comments of an entry are kept
+// above its own key
+func (v *INIVisitor) single(entry *Entry) (string, *Entry, bool) {
+	if entry.section == nil || len(entry.section.entries) != 1 || len(entry.section.entries[0].comments) > 0 {
+		return "", nil, false
+	}
+
+	child := entry.section.entries[0]
+
+	return child.key, child, true
+}
+
+func (v *INIVisitor) visitSection(section *Section) string {
+	if v.limiter.Enter() != nil {
+		return ""
+	}
+	defer v.limiter.Leave()
+
+	if len(section.entries) == 0 {
+		return "{}"
+	}
+
+	entries := section.entries
+	if v.options.SortIterators.SortHashmap {
+		entries = slices.Clone(entries)
+		slices.SortStableFunc(entries, func(a, b *Entry) int {
+			return strings.Compare(a.key, b.key)
+		})
+	}
+
+	e := []string{}
+	v.i.Indent()
+	for _, entry := range entries {
+		for _, comment := range entry.comments {
+			e = append(e, v.i.IndentValue()+strings.TrimRight("# "+comment, " "))
+		}
+
+		path, last := nix.CollapsePath(entry.key, entry, v.single, &v.options.NixOutput)
+
+		var value string
+		if last.section != nil {
+			value = v.visitSection(last.section)
+		} else {
+			value = v.visitValues(last.values)
+		}
+
+		key := nix.MakeAttrPath(path, v.options.UnsafeKeys)
+		e = append(e, v.i.IndentValue()+key+" = "+value+";")
+	}
+	v.i.UnIndent()
+
+	return "{\n" + strings.Join(e, "\n") + "\n" + v.i.IndentValue() + "}"
+}
+
+func (v *INIVisitor) Visit() string {
+	return v.visitSection(v.root)
+}
+
+// Err returns the error of the first limit exceeded by Visit
+func (v *INIVisitor) Err() error {
+	return v.limiter.Err()
+}
+
+func parseSectionHeader(line string) ([]string, string, error) {
+	end := strings.LastIndex(line, "]")
+	if end == -1 {
+		return nil, "", fmt.Errorf("missing closing bracket")
+	}
+
+	_, comment := splitInlineComment(" " + line[end+1:])
+	if strings.TrimSpace(line[end+1:]) != "" && comment == "" {
+		return nil, "", fmt.Errorf("unexpected text after the section header")
+	}
+
+	header := strings.TrimSpace(line[1:end])
+
+	// Subsection like [remote "origin"], section names may contain spaces
+	name, rest, found := strings.Cut(header, " \"")
+	if !found {
+		if header == "" {
+			return nil, "", fmt.Errorf("empty section name")
+		}
+		if strings.Contains(header, "\"") {
+			return nil, "", fmt.Errorf("unexpected double quote in the section name")
+		}
+		return []string{header}, comment, nil
+	}
+
+	name = strings.TrimSpace(name)
+	rest = "\"" + rest
+
+	subsection, rest, err := Unquote(rest)
+	if err != nil {
+		return nil, "", err
+	}
+	if strings.TrimSpace(rest) != "" {
+		return nil, "", fmt.Errorf("unexpected text after the subsection name")
+	}
+
+	return []string{name, subsection}, comment, nil
+}
+
+// Returns the key, the value and the comment of a line, an unquoted value
+// only ends with a comment when inlineComments is set
+func parseKeyValue(line string, inlineComments bool) (string, Value, string, error) {
+	key, value, found := strings.Cut(line, "=")
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", Value{}, "", fmt.Errorf("empty key")
+	}
+
+	// A key without value is a boolean, like in git-config
+	if !found {
+		comment := ""
+		if inlineComments {
+			key, comment = splitInlineComment(key)
+		}
+		return key, Value{raw: "true"}, comment, nil
+	}
+
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, "\"") {
+		comment := ""
+		if inlineComments {
+			value, comment = splitInlineComment(value)
+		}
+		return key, Value{raw: value}, comment, nil
+	}
+
+	s, rest, err := Unquote(value)
+	if err != nil {
+		return "", Value{}, "", err
+	}
+
+	rest = strings.TrimSpace(rest)
+	if rest != "" && rest[0] != ';' && rest[0] != '#' {
+		return "", Value{}, "", fmt.Errorf("unexpected text after the quoted value")
+	}
+
+	return key, Value{raw: s, quoted: true}, rest, nil
+}
+
+// Parse reads an INI document, keys before the first section are global.
+// The unquoted values end with an inline comment when inlineComments is set.
+func Parse(data string, inlineComments bool) (*Section, error) {
+	root := newSection()
+	current := root
+	comments := []string{}
+
+	lines := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+	for n := 0; n < len(lines); n++ {
+		lineNumber := n + 1
+		line := strings.TrimSpace(lines[n])
+
+		// Line continuations
+		for strings.HasSuffix(line, "\\") && n+1 < len(lines) {
+			n++
+			line = strings.TrimSuffix(line, "\\") + strings.TrimSpace(lines[n])
+		}
+
+		switch {
+		case line == "":
+			continue
+		case line[0] == ';' || line[0] == '#':
+			comments = append(comments, commentText(line))
+		case line[0] == '[':
+			path, comment, err := parseSectionHeader(line)
+			if err != nil {
+				return nil, converter.Errorf(lineNumber, 0, "%w", err)
+			}
+			if comment != "" {
+				comments = append(comments, commentText(comment))
+			}
+
+			current = root
+			for i, name := range path {
+				var entryComments []string
+				if i == len(path)-1 {
+					entryComments = comments
+				}
+
+				e := current.entry(name, entryComments)
+				if e.section == nil {
+					if e.values != nil {
+						return nil, converter.Errorf(lineNumber, 0, "the section '%s' conflicts with a key", name)
+					}
+					e.section = newSection()
+				}
+				current = e.section
+			}
+			comments = []string{}
+		default:
+			key, value, comment, err := parseKeyValue(line, inlineComments)
+			if err != nil {
+				return nil, converter.Errorf(lineNumber, 0, "%w", err)
+			}
+			if comment != "" {
+				comments = append(comments, commentText(comment))
+			}
+
+			e := current.entry(key, comments)
+			if e.section != nil {
+				return nil, converter.Errorf(lineNumber, 0, "the key '%s' conflicts with a subsection", key)
+			}
+			e.values = append(e.values, value)
+			comments = []string{}
+		}
+	}
+
+	return root, nil
+}
+
+func ToNix(data string, options *converter.ConverterOptions) (string, error) {
+	root, err := Parse(data, options.INI.InlineComments)
+	if err != nil {
+		return "", err
+	}
+
+	visitor := NewINIVisitor(root, options)
+
+	return visitor.limiter.Done(nix.Layout(visitor.Visit(), &options.NixOutput))
+}
@@ -0,0 +1,253 @@
+package ini
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/orivej/go-nix/nix/parser"
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/nix"
+)
+
+type NixVisitor struct {
+	p       *parser.Parser
+	errors  *nix.Collector
+	node    *parser.Node
+	options *converter.ConverterOptions
+}
+
+func NewNixVisitor(p *parser.Parser, node *parser.Node, options *converter.ConverterOptions) *NixVisitor {
+	return &NixVisitor{
+		node:    node,
+		p:       p,
+		errors:  nix.NewCollector(p, node, options),
+		options: options,
+	}
+}
+
+func (n *NixVisitor) visitValue(node *parser.Node) (string, error) {
+	return nix.Visit(n.errors, node, n.visitValueNode, "")
+}
+
+func (n *NixVisitor) visitValueNode(node *parser.Node) (string, error) {
+	switch node.Type {
+	case parser.StringNode, parser.IStringNode:
+		s, err := nix.VisitStringValue(n.p, node)
+		if err != nil {
+			return "", err
+		}
+		if IsValueUnsafe(s) {
+			return Quote(s), nil
+		}
+		return s, nil
+	case parser.IntNode:
+		return nix.VisitInt(n.p, node)
+	case parser.FloatNode:
+		return nix.VisitFloat(n.p, node)
+	case parser.IDNode:
+		s, err := nix.VisitID(n.p, node)
+		if err != nil {
+			return "", err
+		}
+		if !IsBool(s) {
+			return "", fmt.Errorf("unsupported identifier '%s'", s)
+		}
+		return s, nil
+	case parser.OpNode + 57378: // The negative unary operator
+		s, err := n.visitValue(node.Nodes[0])
+		if err != nil {
+			return "", err
+		}
+		return "-" + s, nil
+	case parser.ApplyNode:
+		return nix.VisitApply(n.p, node)
+	case parser.ParensNode:
+		return n.visitValue(node.Nodes[0])
+	default:
+		return "", fmt.Errorf("unsupported node type: %s", node.Type.String())
+	}
+}
+
+// Write the keys of a section, lists are written as repeated keys
+func (n *NixVisitor) visitKeys(tree *nix.AttrNode, keys []string) ([]string, error) {
+	e := []string{}
+
+	for _, k := range keys {
+		if !IsKeyValid(k) {
+			return nil, fmt.Errorf("invalid key '%s'", k)
+		}
+
+		valueNode := tree.Children[k].Value
+		for valueNode.Type == parser.ParensNode {
+			valueNode = valueNode.Nodes[0]
+		}
+
+		items := []*parser.Node{valueNode}
+		if valueNode.Type == parser.ListNode {
+			if len(valueNode.Nodes) == 0 {
+				return nil, fmt.Errorf("key '%s': an empty list cannot be written as repeated keys", k)
+			}
+			items = valueNode.Nodes
+		}
+
+		values := []string{}
+		for _, item := range items {
+			value, err := n.visitValue(item)
+			if err != nil {
+				return nil, converter.AtKey(err, k)
+			}
+			values = append(values, k+" = "+value)
+		}
+
+		if n.options.SortIterators.SortList {
+			slices.Sort(values)
+		}
+
+		e = append(e, values...)
+	}
+
+	return e, nil
+}
+
+// Split the keys of a set between the values and the sets
+func (n *NixVisitor) splitKeys(tree *nix.AttrNode) ([]string, []string, error) {
+	keys := tree.Order
+	if n.options.SortIterators.SortHashmap {
+		keys = slices.Clone(tree.Order)
+		slices.Sort(keys)
+	}
+
+	values := []string{}
+	sets := []string{}
+	for _, k := range keys {
+		_, isSet, err := tree.Children[k].Set(n.p)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if isSet {
+			sets = append(sets, k)
+		} else {
+			values = append(values, k)
+		}
+	}
+
+	return values, sets, nil
+}
+
+func (n *NixVisitor) visitSection(header string, tree *nix.AttrNode) ([]string, error) {
+	sections := [][]string{}
+
+	valueKeys, setKeys, err := n.splitKeys(tree)
+	if err != nil {
+		return nil, err
+	}
+
+	lines, err := n.visitKeys(tree, valueKeys)
+	if err != nil {
+		return nil, err
+	}
+	// A section only holding subsections does not need its own header
+	if len(lines) > 0 || len(setKeys) == 0 {
+		sections = append(sections, append([]string{"[" + header + "]"}, lines...))
+	}
+
+	for _, k := range setKeys {
+		subtree, _, err := tree.Children[k].Set(n.p)
+		if err != nil {
+			return nil, err
+		}
+
+		subValueKeys, subSetKeys, err := n.splitKeys(subtree)
+		if err != nil {
+			return nil, err
+		}
+		if len(subSetKeys) > 0 {
+			return nil, fmt.Errorf("section '%s': the subsection '%s' cannot contain sets", header, k)
+		}
+
+		lines, err := n.visitKeys(subtree, subValueKeys)
+		if err != nil {
+			return nil, err
+		}
+		sections = append(sections, append([]string{"[" + header + " " + Quote(k) + "]"}, lines...))
+	}
+
+	e := []string{}
+	for _, section := range sections {
+		e = append(e, strings.Join(section, "\n"))
+	}
+
+	return e, nil
+}
+
+func (n *NixVisitor) visitRoot() (string, error) {
+	node := n.node
+	for node.Type == parser.ParensNode {
+		node = node.Nodes[0]
+	}
+
+	if node.Type != parser.SetNode {
+		return "", fmt.Errorf("the Nix expression must be a set")
+	}
+
+	tree, err := n.errors.BuildAttrTree(node, n.options.DuplicateKeys.Policy)
+	if err != nil {
+		return "", err
+	}
+
+	valueKeys, setKeys, err := n.splitKeys(tree)
+	if err != nil {
+		return "", err
+	}
+
+	// Global keys must come before the first section
+	globals, err := n.visitKeys(tree, valueKeys)
+	if err != nil {
+		return "", err
+	}
+
+	e := []string{}
+	if len(globals) > 0 {
+		e = append(e, strings.Join(globals, "\n"))
+	}
+
+	for _, k := range setKeys {
+		if !IsSectionValid(k) {
+			return "", fmt.Errorf("invalid section name '%s'", k)
+		}
+
+		subtree, _, err := tree.Children[k].Set(n.p)
+		if err != nil {
+			return "", err
+		}
+
+		sections, err := n.visitSection(k, subtree)
+		if err != nil {
+			return "", err
+		}
+		e = append(e, sections...)
+	}
+
+	return strings.Join(e, "\n\n"), nil
+}
+
+func (n *NixVisitor) Visit() (string, error) {
+	v, err := n.visitRoot()
+	return n.errors.Done(v, err)
+}
+
+func FromNix(data string, options *converter.ConverterOptions) (string, error) {
+	p, err := nix.Parse(data)
+	if err != nil {
+		return "", err
+	}
+
+	out, err := NewNixVisitor(p, p.Result, options).Visit()
+	if err != nil {
+		return "", err
+	}
+
+	return out, nil
+}
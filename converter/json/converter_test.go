@@ -1,6 +1,10 @@
 package json
 
 import (
+	"context"
+	"errors"
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/theobori/nix-converter/converter"
@@ -190,6 +194,10 @@ var jsonStrings = []string{
     }
   }
 }`,
+	`[
+  -9223372036854775808,
+  9223372036854775807
+]`,
 }
 
 var nixStrings = []string{
@@ -266,3 +274,133 @@ func TestJSONFromNix(t *testing.T) {
 
 	common.TestHelperFromNixStrings(t, nixStrings, FromNix, ToNix, &options)
 }
+
+func TestJSONCollapsePaths(t *testing.T) {
+	t.Parallel()
+	input := `{"services":{"nginx":{"enable":true,"virtualHosts":{"a":{"root":"/srv"}}}},"b":{"c":{}}}`
+
+	tests := []struct {
+		name  string
+		depth int
+		want  string
+	}{
+		{
+			name: "no limit",
+			want: `{
+  services.nginx = {
+    enable = true;
+    virtualHosts.a.root = "/srv";
+  };
+  b.c = {};
+}`,
+		},
+		{
+			name:  "depth limit",
+			depth: 2,
+			want: `{
+  services.nginx = {
+    enable = true;
+    virtualHosts.a = {
+      root = "/srv";
+    };
+  };
+  b.c = {};
+}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			options := converter.NewDefaultConverterOptions()
+			options.UnsafeKeys = true
+			options.NixOutput.CollapsePaths = true
+			options.NixOutput.CollapseDepth = tt.depth
+
+			output, err := ToNix(input, options)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if output != tt.want {
+				t.Errorf("ToNix() = \n%v, want \n%v", output, tt.want)
+			}
+
+			common.TestHelperFromNix(t, tt.want, FromNix, ToNix, options)
+		})
+	}
+}
+
+func TestJSONLineWidth(t *testing.T) {
+	t.Parallel()
+	options := converter.NewDefaultConverterOptions()
+	options.UnsafeKeys = true
+	options.NixOutput.LineWidth = 40
+
+	input := `{"a":[1,-2,3],"b":{"c":"x"},"d":["a long string","another long string"]}`
+
+	want := `{
+  a = [ 1 (-2) 3 ];
+  b = { c = "x"; };
+  d = [
+    "a long string"
+    "another long string"
+  ];
+}`
+
+	output, err := ToNix(input, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if output != want {
+		t.Errorf("ToNix() = \n%v, want \n%v", output, want)
+	}
+
+	common.TestHelperFromNix(t, want, FromNix, ToNix, options)
+}
+
+func TestJSONToNixStream(t *testing.T) {
+	t.Parallel()
+	options := converter.NewDefaultConverterOptions()
+	options.UnsafeKeys = true
+
+	var b strings.Builder
+	err := ToNixStream(context.Background(), strings.NewReader(`[{"a": {"b": 1}}, "x\ny", []]`), &b, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `[
+  {
+    a = {
+      b = 1;
+    };
+  }
+  ''
+    x
+    y''
+  []
+]`
+	if b.String() != want {
+		t.Errorf("ToNixStream() = \n%v, want \n%v", b.String(), want)
+	}
+
+	for _, input := range []string{`[1, 2] 3`, `{"a": 1, "a": 2}`, `[1,`} {
+		if err := ToNixStream(context.Background(), strings.NewReader(input), io.Discard, options); err == nil {
+			t.Errorf("ToNixStream(%q) expected an error", input)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = ToNixStream(ctx, strings.NewReader(`[1, 2, 3]`), io.Discard, options)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("ToNixStream() expected the context error, got %v", err)
+	}
+}
+
+func TestJSONToNixfmt(t *testing.T) {
+	t.Parallel()
+	common.TestHelperNixfmt(t, ".json", ToNix)
+}
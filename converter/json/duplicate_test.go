@@ -0,0 +1,88 @@
+package json
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/options"
+)
+
+func TestDuplicateKeysFromNix(t *testing.T) {
+	input := `{ a = { b = 1; c = 2; }; a.b = 3; a.d = 4; e = 1; e = 2; }`
+
+	tests := []struct {
+		policy   string
+		expected string
+	}{
+		{options.DuplicateKeysError, ""},
+		{options.DuplicateKeysFirstWins, `{ "a": { "b": 1, "c": 2, "d": 4 }, "e": 1 }`},
+		{options.DuplicateKeysLastWins, `{ "a": { "b": 3, "c": 2, "d": 4 }, "e": 2 }`},
+		{options.DuplicateKeysDeepMerge, `{ "a": { "b": 3, "c": 2, "d": 4 }, "e": 2 }`},
+	}
+
+	for _, test := range tests {
+		o := converter.NewDefaultConverterOptions()
+		o.DuplicateKeys.Policy = test.policy
+
+		output, err := FromNix(input, o)
+		if test.expected == "" {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %s", test.policy, output)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", test.policy, err)
+		}
+
+		if compact(output) != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.policy, test.expected, compact(output))
+		}
+	}
+}
+
+func TestDuplicateKeysToNix(t *testing.T) {
+	input := `{"a": {"b": 1, "c": 2}, "e": 1, "a": {"b": 3}, "e": 2}`
+
+	tests := []struct {
+		policy   string
+		expected string
+	}{
+		{options.DuplicateKeysError, ""},
+		{options.DuplicateKeysFirstWins, `{ "a": { "b": 1, "c": 2 }, "e": 1 }`},
+		{options.DuplicateKeysLastWins, `{ "a": { "b": 3 }, "e": 2 }`},
+		{options.DuplicateKeysDeepMerge, `{ "a": { "b": 3, "c": 2 }, "e": 2 }`},
+	}
+
+	for _, test := range tests {
+		o := converter.NewDefaultConverterOptions()
+		o.DuplicateKeys.Policy = test.policy
+
+		output, err := ToNix(input, o)
+		if test.expected == "" {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %s", test.policy, output)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", test.policy, err)
+		}
+
+		// The Nix output is compared as JSON
+		roundTrip, err := FromNix(output, converter.NewDefaultConverterOptions())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if compact(roundTrip) != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.policy, test.expected, compact(roundTrip))
+		}
+	}
+}
+
+// Returns the output on a single line
+func compact(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
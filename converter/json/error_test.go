@@ -0,0 +1,125 @@
+package json
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/theobori/nix-converter/converter"
+)
+
+func TestErrorPosition(t *testing.T) {
+	tests := []struct {
+		nix    string
+		line   int
+		column int
+		path   string
+	}{
+		{"{ a.b = [ 1 (x: x) ]; }", 1, 14, "a.b[1]"},
+		{"{\n  a = {\n    b = with c; d;\n  };\n}", 3, 9, "a.b"},
+		{"[ 1 2", 1, 1, ""},
+	}
+
+	for _, test := range tests {
+		_, err := FromNix(test.nix, converter.NewDefaultConverterOptions())
+		if err == nil {
+			t.Fatalf("expected an error for %q", test.nix)
+		}
+
+		e := converter.AsError(err)
+		if e.Line != test.line || e.Column != test.column || e.Path != test.path {
+			t.Errorf("%q: expected %d:%d at %q, got %d:%d at %q", test.nix, test.line, test.column, test.path, e.Line, e.Column, e.Path)
+		}
+	}
+}
+
+func TestAllErrors(t *testing.T) {
+	o := converter.NewDefaultConverterOptions()
+	o.AllErrors = true
+
+	_, err := FromNix("{\n  a = [ 1 (x: x) 3 ];\n  b.c = with d; e;\n  f = 99999999999999999999;\n}", o)
+
+	var errs converter.Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected several errors, got %v", err)
+	}
+
+	expected := []string{
+		"line 2, column 12: unsupported node type: function (at a[1])",
+		"line 3, column 9: unsupported node type: with (at b.c)",
+		"line 4, column 7: the integer 99999999999999999999 is out of the 64-bit range (at f)",
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %d errors, got %d: %v", len(expected), len(errs), errs)
+	}
+
+	for i, e := range errs {
+		if e.Error() != expected[i] {
+			t.Errorf("expected %q, got %q", expected[i], e.Error())
+		}
+	}
+
+	// Without errors, the output is the same as without collecting them
+	out, err := FromNix("{ a = [ 1 2 ]; }", o)
+	if err != nil || out != "{\n  \"a\": [\n    1,\n    2\n  ]\n}" {
+		t.Errorf("unexpected output %q, %v", out, err)
+	}
+}
+
+func TestAllErrorsKeys(t *testing.T) {
+	o := converter.NewDefaultConverterOptions()
+	o.AllErrors = true
+
+	_, err := FromNix("{\n  a = 1;\n  ${b} = 2;\n  c = {\n    a = 1;\n    ${d} = 3;\n    a = 2;\n  };\n  a = 4;\n  inherit e;\n}", o)
+
+	var errs converter.Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected several errors, got %v", err)
+	}
+
+	expected := []string{
+		"line 3, column 3: unsupported key node type: interp",
+		"line 6, column 5: unsupported key node type: interp (at c)",
+		"line 7, column 5: the attribute 'a' is already defined (at c)",
+		"line 9, column 3: the attribute 'a' is already defined",
+		"line 10, column 3: unsupported binding: inherit",
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %d errors, got %d: %v", len(expected), len(errs), errs)
+	}
+
+	for i, e := range errs {
+		if e.Error() != expected[i] {
+			t.Errorf("expected %q, got %q", expected[i], e.Error())
+		}
+	}
+}
+
+func TestToNixErrorPosition(t *testing.T) {
+	tests := []struct {
+		json     string
+		expected string
+	}{
+		{"{\n  \"a\": 1,\n  \"a\": 2\n}", "line 3, column 3: the key 'a' is defined twice"},
+		{"\n\n  {\n  \"a\": {\"b\": 1,\n    \"b\": 2}\n}", "line 5, column 5: the key 'b' is defined twice (at a)"},
+		{"[\n  1,\n  {\"x\": 1, \"x\": 2}\n]", "line 3, column 12: the key 'x' is defined twice (at [1])"},
+		{"[\n  1,\n  x\n]", "line 3, column 3: invalid character 'x' looking for beginning of value"},
+		{"{\n  \"a\": 1\n  \"b\": 2\n}", "line 3, column 3: invalid character '\"' after object key:value pair"},
+		// The bytes of the converted elements are forgotten while streaming
+		{"[\n" + strings.Repeat("  \"abcdefgh\",\n", 1000) + "  {\"x\": 1, \"x\": 2}\n]", "line 1002, column 12: the key 'x' is defined twice (at [1000])"},
+	}
+
+	for _, test := range tests {
+		// The sorted values are not streamed
+		for _, sorted := range []bool{false, true} {
+			o := converter.NewDefaultConverterOptions()
+			o.SortIterators.SortHashmap = sorted
+			o.SortIterators.SortList = sorted
+
+			_, err := ToNix(test.json, o)
+			if err == nil || err.Error() != test.expected {
+				t.Errorf("%q (sorted: %v): expected the error %q, got %v", test.json, sorted, test.expected, err)
+			}
+		}
+	}
+}
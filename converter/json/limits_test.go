@@ -0,0 +1,79 @@
+package json
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/options"
+)
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		limits options.Limits
+		json   string
+		nix    string
+		limit  string
+	}{
+		{options.Limits{MaxDepth: 3}, `[[[[1]]]]`, `[ [ [ [ 1 ] ] ] ]`, converter.LimitDepth},
+		{options.Limits{MaxNodes: 3}, `{"a": 1, "b": 2, "c": 3}`, `{ a = 1; b = 2; c = 3; }`, converter.LimitNodes},
+		{options.Limits{MaxOutputBytes: 10}, `{"key": "a long value"}`, `{ key = "a long value"; }`, converter.LimitOutputBytes},
+	}
+
+	for _, test := range tests {
+		o := converter.NewDefaultConverterOptions()
+		o.Limits = test.limits
+
+		_, toNixErr := ToNix(test.json, o)
+		_, fromNixErr := FromNix(test.nix, o)
+
+		for _, err := range []error{toNixErr, fromNixErr} {
+			var limitErr *converter.LimitError
+			if !errors.As(err, &limitErr) || limitErr.Limit != test.limit {
+				t.Errorf("expected the %s limit error, got %v", test.limit, err)
+			}
+		}
+	}
+
+	// The depth is checked before the parsers reach their own limits
+	deep := strings.Repeat("[", 1000) + strings.Repeat("]", 1000)
+	for _, sorted := range []bool{false, true} {
+		o := converter.NewDefaultConverterOptions()
+		o.Limits = options.Limits{MaxDepth: 3}
+		o.SortIterators.SortList = sorted
+
+		_, err := ToNix(deep, o)
+		var limitErr *converter.LimitError
+		if !errors.As(err, &limitErr) || limitErr.Limit != converter.LimitDepth {
+			t.Errorf("expected the depth limit error (sorted: %v), got %v", sorted, err)
+		}
+	}
+
+	// The limits are not exceeded by the values at the limit
+	o := converter.NewDefaultConverterOptions()
+	o.Limits = options.Limits{MaxDepth: 5, MaxNodes: 5}
+	if _, err := ToNix(`[[[[1]]]]`, o); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if _, err := FromNix(`[ [ [ [ 1 ] ] ] ]`, o); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestContextCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	o := converter.NewDefaultConverterOptions().WithContext(ctx)
+	o.AllErrors = true
+
+	if _, err := ToNix(`{"a": [1, 2]}`, o); !errors.Is(err, context.Canceled) {
+		t.Errorf("ToNix() expected the context error, got %v", err)
+	}
+
+	if _, err := FromNix(`{ a = [ 1 2 ]; }`, o); !errors.Is(err, context.Canceled) {
+		t.Errorf("FromNix() expected the context error, got %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+package json
+
+import (
+	"testing"
+
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/options"
+)
+
+// TestNixToJSONOutputStyles tests the compact, minified and custom indentation JSON outputs
+func TestNixToJSONOutputStyles(t *testing.T) {
+	t.Parallel()
+	input := `{ b = [ 1 (-2) { } ]; a.d = "x"; a.c = [ ]; }`
+
+	tests := []struct {
+		name   string
+		output options.JSONOutput
+		sort   bool
+		want   string
+	}{
+		{
+			name:   "compact",
+			output: options.JSONOutput{Style: options.JSONStyleCompact},
+			want:   `{"b": [1, -2, {}], "a": {"d": "x", "c": []}}`,
+		},
+		{
+			name:   "minified",
+			output: options.JSONOutput{Style: options.JSONStyleMinified},
+			want:   `{"b":[1,-2,{}],"a":{"d":"x","c":[]}}`,
+		},
+		{
+			name:   "custom indentation",
+			output: options.JSONOutput{Style: options.JSONStylePretty, IndentSize: 4},
+			want: `{
+    "b": [
+        1,
+        -2,
+        {}
+    ],
+    "a": {
+        "d": "x",
+        "c": []
+    }
+}`,
+		},
+		{
+			name:   "sorted keys",
+			output: options.JSONOutput{Style: options.JSONStyleMinified},
+			sort:   true,
+			want:   `{"a":{"c":[],"d":"x"},"b":[1,-2,{}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			options := converter.NewDefaultConverterOptions()
+			options.JSONOutput = tt.output
+			options.SortIterators.SortHashmap = tt.sort
+
+			result, err := FromNix(input, options)
+			if err != nil {
+				t.Fatalf("FromNix() error = %v", err)
+			}
+			if result != tt.want {
+				t.Errorf("FromNix() = \n%q\nwant \n%q", result, tt.want)
+			}
+		})
+	}
+}
+
+// TestJSONSortedKeysOrder tests that keys are sorted by code point like jq -S
+func TestJSONSortedKeysOrder(t *testing.T) {
+	t.Parallel()
+	options := converter.NewDefaultConverterOptions()
+	options.JSONOutput.Style = "minified"
+	options.SortIterators.SortHashmap = true
+
+	result, err := FromNix(`{ "b" = 1; "a-b" = 2; "a" = 3; "Z" = 4; "é" = 5; "_" = 6; }`, options)
+	if err != nil {
+		t.Fatalf("FromNix() error = %v", err)
+	}
+
+	want := `{"Z":4,"_":6,"a":3,"a-b":2,"b":1,"é":5}`
+	if result != want {
+		t.Errorf("FromNix() = %q, want %q", result, want)
+	}
+}
+
+// TestJSONIndentation tests the shared indentation options in both directions
+func TestJSONIndentation(t *testing.T) {
+	t.Parallel()
+	options := converter.NewDefaultConverterOptions()
+	options.Indentation.UseTabs = true
+
+	result, err := FromNix(`{ a = [ 1 ]; }`, options)
+	if err != nil {
+		t.Fatalf("FromNix() error = %v", err)
+	}
+
+	want := "{\n\t\"a\": [\n\t\t1\n\t]\n}"
+	if result != want {
+		t.Errorf("FromNix() = %q, want %q", result, want)
+	}
+
+	options.Indentation.UseTabs = false
+	options.Indentation.Size = 4
+
+	result, err = ToNix(`{"a": {"b": "x\ny"}}`, options)
+	if err != nil {
+		t.Fatalf("ToNix() error = %v", err)
+	}
+
+	want = `{
+    "a" = {
+        "b" = ''
+            x
+            y'';
+    };
+}`
+	if result != want {
+		t.Errorf("ToNix() = \n%v, want \n%v", result, want)
+	}
+}
+
+// The nixfmt style always indents the Nix output with two spaces
+func TestJSONNixfmtIndentation(t *testing.T) {
+	t.Parallel()
+	o := converter.NewDefaultConverterOptions()
+	o.NixOutput.Style = options.NixStyleNixfmt
+
+	for _, indentation := range []options.Indentation{{Size: 4}, {Size: 2, UseTabs: true}} {
+		o.Indentation = indentation
+
+		result, err := ToNix(`{"a": {"b": [1, 2]}}`, o)
+		if err != nil {
+			t.Fatalf("ToNix() error = %v", err)
+		}
+
+		want := `{
+  "a" = {
+    "b" = [
+      1
+      2
+    ];
+  };
+}`
+		if result != want {
+			t.Errorf("ToNix() = \n%v, want \n%v", result, want)
+		}
+	}
+}
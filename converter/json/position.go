@@ -0,0 +1,143 @@
+package json
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"errors"
+	"io"
+
+	"github.com/theobori/nix-converter/converter"
+)
+
+// Returns the offset of the first byte at or after offset that is not a JSON
+// whitespace nor a separator
+func skipSeparators(data []byte, offset int) int {
+	for offset < len(data) && bytes.IndexByte([]byte(" \t\r\n,:"), data[offset]) >= 0 {
+		offset++
+	}
+
+	return offset
+}
+
+// Returns the offsets of the keys of every object of a JSON text, the objects
+// are in the order of their opening braces. fastjson does not keep the
+// offsets, they are read again with encoding/json.
+func keyOffsets(data []byte) [][]int {
+	d := stdjson.NewDecoder(bytes.NewReader(data))
+
+	objects := [][]int{}
+	// The index of the objects being read, -1 for the arrays
+	stack := []int{}
+	// Whether the next token of the innermost object is a key
+	isKey := false
+
+	for {
+		offset := int(d.InputOffset())
+		key := isKey && len(stack) > 0 && stack[len(stack)-1] >= 0
+
+		t, err := d.Token()
+		if err != nil {
+			return objects
+		}
+
+		switch t {
+		case stdjson.Delim('{'):
+			stack = append(stack, len(objects))
+			objects = append(objects, []int{})
+			isKey = true
+			continue
+		case stdjson.Delim('['):
+			stack = append(stack, -1)
+			continue
+		case stdjson.Delim('}'), stdjson.Delim(']'):
+			stack = stack[:len(stack)-1]
+		default:
+			if key {
+				object := stack[len(stack)-1]
+				objects[object] = append(objects[object], skipSeparators(data, offset))
+				isKey = false
+				continue
+			}
+		}
+
+		// A value of an object is followed by a key
+		isKey = len(stack) > 0 && stack[len(stack)-1] >= 0
+	}
+}
+
+// Locates a parse error of fastjson in data. Its messages have no position,
+// the syntax error of encoding/json gives the offset.
+func parseError(data []byte, err error) error {
+	var (
+		raw       stdjson.RawMessage
+		syntaxErr *stdjson.SyntaxError
+	)
+	if !errors.As(stdjson.Unmarshal(data, &raw), &syntaxErr) {
+		return err
+	}
+
+	// The offset is the one after the byte that failed
+	line, column := converter.LineColumn(data, int(syntaxErr.Offset)-1)
+	return converter.Errorf(line, column, "%w", syntaxErr)
+}
+
+// positionReader keeps the bytes read from r since the last commit, with the
+// line and the column of the commit, to locate the offsets of a stream
+type positionReader struct {
+	r       io.Reader
+	data    []byte
+	offset  int64
+	line    int
+	column  int
+	stopped bool
+}
+
+func newPositionReader(r io.Reader) *positionReader {
+	return &positionReader{r: r, line: 1, column: 1}
+}
+
+func (p *positionReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if !p.stopped {
+		p.data = append(p.data, b[:n]...)
+	}
+
+	return n, err
+}
+
+// Returns the bytes read from an offset at or after the last commit
+func (p *positionReader) from(offset int64) []byte {
+	return p.data[min(max(offset-p.offset, 0), int64(len(p.data))):]
+}
+
+// Returns the offset of the first byte at or after offset that is not a
+// JSON whitespace nor a separator
+func (p *positionReader) skipSeparators(offset int64) int64 {
+	return offset + int64(skipSeparators(p.from(offset), 0))
+}
+
+// Returns the line and the column of an offset at or after the last commit
+func (p *positionReader) position(offset int64) (int, int) {
+	data := p.data[:len(p.data)-len(p.from(offset))]
+
+	line, column := converter.LineColumn(data, len(data))
+	if line == 1 {
+		return p.line, p.column + column - 1
+	}
+
+	return p.line + line - 1, column
+}
+
+// commit forgets the bytes before an offset
+func (p *positionReader) commit(offset int64) {
+	p.line, p.column = p.position(offset)
+	p.data = append([]byte(nil), p.from(offset)...)
+	p.offset = offset
+}
+
+// stop returns the bytes read since the last commit, the next ones are not
+// kept anymore
+func (p *positionReader) stop() []byte {
+	p.stopped = true
+	return p.data
+}
@@ -0,0 +1,22 @@
+{
+  "single" = [ 1 ];
+  "negative" = [
+    (-1)
+    (-2.5)
+    3
+  ];
+  "nested" = [
+    [
+      1
+      2
+    ]
+    [ "x" ]
+  ];
+  "sets" = [
+    { "a" = 1; }
+    {
+      "a" = 1;
+      "b" = 2;
+    }
+  ];
+}
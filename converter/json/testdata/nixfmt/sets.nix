@@ -0,0 +1,11 @@
+{
+  "a" = {
+    "b" = {
+      "c" = -1;
+    };
+  };
+  "d" = {
+    "e" = true;
+    "f" = null;
+  };
+}
@@ -0,0 +1,16 @@
+{
+  "script" = ''
+    set -e
+
+    echo ''${HOME}
+  '';
+  "text" = ''
+    first
+    second'';
+  "list" = [
+    ''
+      a
+      b
+    ''
+  ];
+}
@@ -0,0 +1,7 @@
+[
+  {
+    "name" = "x";
+    "value" = [ 1 ];
+  }
+  "y"
+]
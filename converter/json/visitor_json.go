@@ -1,11 +1,19 @@
 package json
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	stdjson "encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"slices"
 	"strings"
 
 	"github.com/theobori/nix-converter/converter"
 	"github.com/theobori/nix-converter/converter/nix"
+	"github.com/theobori/nix-converter/converter/options"
 	"github.com/theobori/nix-converter/internal/common"
 	"github.com/valyala/fastjson"
 )
@@ -13,17 +21,75 @@ import (
 type JSONVisitor struct {
 	i       common.Indentation
 	value   *fastjson.Value
+	limiter *converter.Limiter
 	options *converter.ConverterOptions
 }
 
 func NewJSONVisitor(value *fastjson.Value, options *converter.ConverterOptions) *JSONVisitor {
+	return NewJSONVisitorWithIndentation(value, common.NewIndentationUnit(options.NixIndentUnit()), options)
+}
+
+// NewJSONVisitorWithIndentation creates a visitor whose output starts at the
+// given indentation level, useful when the value is nested in another Nix expression
+func NewJSONVisitorWithIndentation(value *fastjson.Value, i *common.Indentation, options *converter.ConverterOptions) *JSONVisitor {
+	return NewJSONVisitorWithLimiter(value, i, converter.NewLimiter(options), options)
+}
+
+// NewJSONVisitorWithLimiter creates a visitor sharing the limits of several
+// values, such as the elements of a stream
+func NewJSONVisitorWithLimiter(value *fastjson.Value, i *common.Indentation, limiter *converter.Limiter, options *converter.ConverterOptions) *JSONVisitor {
 	return &JSONVisitor{
-		i:       *common.NewDefaultIndentation(),
+		i:       *i,
 		value:   value,
+		limiter: limiter,
 		options: options,
 	}
 }
 
+// CheckDepth checks the nesting of a JSON text against the depth limit before
+// it is parsed, the parsers having their own lower limits
+func CheckDepth(data []byte, limiter *converter.Limiter) error {
+	depth, inString, escaped := 0, false, false
+	for _, b := range data {
+		switch {
+		case escaped:
+			escaped = false
+		case inString:
+			escaped = b == '\\'
+			inString = b != '"'
+		case b == '"':
+			inString = true
+		case b == '[' || b == '{':
+			depth++
+			if err := limiter.Nest(depth); err != nil {
+				return err
+			}
+		case b == ']' || b == '}':
+			depth--
+		}
+	}
+
+	return nil
+}
+
+// Returns the single key of an object and its value
+func (j *JSONVisitor) single(value *fastjson.Value) (string, *fastjson.Value, bool) {
+	o, err := value.Object()
+	if err != nil || o.Len() != 1 {
+		return "", nil, false
+	}
+
+	var (
+		key string
+		v   *fastjson.Value
+	)
+	o.Visit(func(k []byte, child *fastjson.Value) {
+		key, v = string(k), child
+	})
+
+	return key, v, true
+}
+
 func (j *JSONVisitor) visitObject(value *fastjson.Value) string {
 	o, _ := value.Object()
 
@@ -33,8 +99,10 @@ func (j *JSONVisitor) visitObject(value *fastjson.Value) string {
 
 	e := []string{}
 	o.Visit(func(key []byte, v *fastjson.Value) {
+		path, v := nix.CollapsePath(string(key), v, j.single, &j.options.NixOutput)
+
 		j.i.Indent()
-		left := nix.MakeNameSafe(string(key), j.options.UnsafeKeys)
+		left := nix.MakeAttrPath(path, j.options.UnsafeKeys)
 		right := j.visit(v)
 
 		e = append(e, j.i.IndentValue()+left+" = "+right+";")
@@ -75,14 +143,14 @@ func (j *JSONVisitor) visitString(value *fastjson.Value) string {
 	s := string(value.GetStringBytes())
 
 	if strings.Contains(s, "\n") {
-		return common.MakeIndentedString(s, j.i.IndentValue())
+		return common.MakeIndentedString(s, j.i.IndentValue(), j.i.Unit())
 	}
 
 	return value.String()
 }
 
 func (j *JSONVisitor) visitNumber(value *fastjson.Value) string {
-	return value.String()
+	return nix.FormatIntLiteral(value.String())
 }
 
 func (j *JSONVisitor) visitFalse(_ *fastjson.Value) string {
@@ -97,7 +165,13 @@ func (j *JSONVisitor) visitNull(_ *fastjson.Value) string {
 	return "null"
 }
 
+// visit stops at the first exceeded limit, the error is returned by Err
 func (j *JSONVisitor) visit(value *fastjson.Value) string {
+	if j.limiter.Enter() != nil {
+		return ""
+	}
+	defer j.limiter.Leave()
+
 	switch value.Type() {
 	case fastjson.TypeObject:
 		return j.visitObject(value)
@@ -122,13 +196,344 @@ func (j *JSONVisitor) Visit() string {
 	return j.visit(j.value)
 }
 
-func ToNix(data string, options *converter.ConverterOptions) (string, error) {
+// Err returns the error of the first limit exceeded by Visit
+func (j *JSONVisitor) Err() error {
+	return j.limiter.Err()
+}
+
+// MergeDuplicateKeys applies a duplicate keys policy of options.DuplicateKeys
+// to the objects of a value, fastjson keeps every key of an object. data is
+// the JSON text of the value, locating the keys defined twice, it may be nil.
+func MergeDuplicateKeys(v *fastjson.Value, data []byte, policy string) error {
+	m := duplicateKeys{policy: policy, data: data}
+	return m.merge(v)
+}
+
+type duplicateKeys struct {
+	a      fastjson.Arena
+	policy string
+	data   []byte
+	// The key offsets of the objects of data and its lines, only read on
+	// errors
+	offsets [][]int
+	lines   converter.Lines
+	// The number of objects visited, in the order of their opening braces
+	objects int
+}
+
+// Returns the entries of an object
+func objectEntries(o *fastjson.Object) []nix.Entry[*fastjson.Value] {
+	entries := []nix.Entry[*fastjson.Value]{}
+	o.Visit(func(key []byte, v *fastjson.Value) {
+		entries = append(entries, nix.Entry[*fastjson.Value]{Key: string(key), Value: v})
+	})
+
+	return entries
+}
+
+// Sets the positions of the entries of an object, returning false when they
+// are unknown
+func (m *duplicateKeys) locate(object int, entries []nix.Entry[*fastjson.Value]) bool {
+	if m.offsets == nil {
+		m.offsets = keyOffsets(m.data)
+		m.lines = converter.NewLines(m.data)
+	}
+
+	if object >= len(m.offsets) || len(m.offsets[object]) != len(entries) {
+		return false
+	}
+
+	for i, offset := range m.offsets[object] {
+		entries[i].Line, entries[i].Column = m.lines.LineColumn(offset)
+	}
+
+	return true
+}
+
+func (m *duplicateKeys) merge(v *fastjson.Value) error {
+	switch v.Type() {
+	case fastjson.TypeArray:
+		for index, item := range v.GetArray() {
+			if err := m.merge(item); err != nil {
+				return converter.AtIndex(err, index)
+			}
+		}
+	case fastjson.TypeObject:
+		object := m.objects
+		m.objects++
+
+		o := v.GetObject()
+		entries := objectEntries(o)
+		for _, e := range entries {
+			if err := m.merge(e.Value); err != nil {
+				return converter.AtKey(err, e.Key)
+			}
+		}
+
+		var merge func(x *fastjson.Value, y *fastjson.Value) (*fastjson.Value, bool, error)
+		merge = func(x *fastjson.Value, y *fastjson.Value) (*fastjson.Value, bool, error) {
+			if x.Type() != fastjson.TypeObject || y.Type() != fastjson.TypeObject {
+				return nil, false, nil
+			}
+
+			merged, err := nix.MergeEntries(append(objectEntries(x.GetObject()), objectEntries(y.GetObject())...), m.policy, merge)
+			if err != nil {
+				return nil, false, err
+			}
+
+			o := m.a.NewObject()
+			for _, e := range merged {
+				o.Set(e.Key, e.Value)
+			}
+
+			return o, true, nil
+		}
+
+		merged, err := nix.MergeEntries(entries, m.policy, merge)
+		if err != nil && m.locate(object, entries) {
+			// The keys are only located when one of them fails
+			merged, err = nix.MergeEntries(entries, m.policy, merge)
+		}
+		if err != nil || len(merged) == len(entries) {
+			return err
+		}
+
+		// Del removes the first entry of a key, so every entry is removed
+		for _, e := range entries {
+			o.Del(e.Key)
+		}
+		for _, e := range merged {
+			o.Set(e.Key, e.Value)
+		}
+	}
+
+	return nil
+}
+
+// Returns true when the values of a top-level array or object can be written
+// one at a time, sorting, merging keys and laying out the output need all of them
+func isStreamable(first byte, o *converter.ConverterOptions) bool {
+	if o.NixOutput.Style == options.NixStyleNixfmt || o.NixOutput.LineWidth > 0 {
+		return false
+	}
+
+	switch first {
+	case '[':
+		return !o.SortIterators.SortList
+	case '{':
+		policy := o.DuplicateKeys.Policy
+		return !o.SortIterators.SortHashmap && policy != options.DuplicateKeysLastWins && policy != options.DuplicateKeysDeepMerge
+	default:
+		return false
+	}
+}
+
+// ToNixStream converts a JSON value read from r and writes it to w. The
+// elements of a top-level array and the members of a top-level object are
+// converted one at a time, so only the largest of them has to fit in memory.
+// When one of them fails, the ones before it may already be written to w.
+func ToNixStream(ctx context.Context, r io.Reader, w io.Writer, options *converter.ConverterOptions) error {
+	positions := newPositionReader(r)
+	reader := bufio.NewReader(positions)
+	writer := bufio.NewWriter(converter.NewLimiter(options).Writer(w))
+
+	first, skipped, err := peekNonSpace(reader)
+	if err != nil {
+		return err
+	}
+
+	if isStreamable(first, options) {
+		positions.commit(skipped)
+		err = streamToNix(ctx, stdjson.NewDecoder(reader), positions, writer, options)
+	} else {
+		// The whole input is read, the skipped whitespaces locate its errors
+		prefix := bytes.NewReader(positions.stop()[:skipped])
+		err = converter.Buffered(bufferedToNix)(ctx, io.MultiReader(prefix, reader), writer, options)
+	}
+	if err != nil {
+		return err
+	}
+
+	return writer.Flush()
+}
+
+// Returns the first byte of a reader that is not a JSON whitespace, without
+// reading it, and the number of whitespaces before it
+func peekNonSpace(r *bufio.Reader) (byte, int64, error) {
+	for skipped := int64(0); ; skipped++ {
+		b, err := r.ReadByte()
+		if errors.Is(err, io.EOF) {
+			return 0, skipped, nil
+		}
+		if err != nil {
+			return 0, skipped, err
+		}
+
+		if b != ' ' && b != '\t' && b != '\n' && b != '\r' {
+			return b, skipped, r.UnreadByte()
+		}
+	}
+}
+
+// Writes the values of a top-level array or object as they are decoded. The
+// offsets of d start at the last commit of positions, which locates the errors.
+func streamToNix(ctx context.Context, d *stdjson.Decoder, positions *positionReader, w *bufio.Writer, o *converter.ConverterOptions) error {
+	var p fastjson.Parser
+
+	base := positions.offset
+	// Locates an error at the value or the key starting after an offset
+	locate := func(offset int64, err error) error {
+		var syntaxErr *stdjson.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			// The offset is the one after the byte that failed
+			offset = base + syntaxErr.Offset - 1
+		} else {
+			offset = positions.skipSeparators(offset)
+		}
+
+		line, column := positions.position(offset)
+		return converter.Within(err, line, column)
+	}
+
+	t, err := d.Token()
+	if err != nil {
+		return locate(base, err)
+	}
+	isObject := t == stdjson.Delim('{')
+
+	i := common.NewIndentationUnit(o.NixIndentUnit())
+	i.Indent()
+
+	// The elements share the limits of the top-level value
+	limiter := converter.NewLimiter(o.WithContext(ctx))
+	if err := limiter.Enter(); err != nil {
+		return err
+	}
+
+	seen := map[string]bool{}
+	count := 0
+	for d.More() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// The bytes of the elements already written are not needed anymore
+		positions.commit(base + d.InputOffset())
+
+		var key string
+		keyOffset := base + d.InputOffset()
+		if isObject {
+			t, err := d.Token()
+			if err != nil {
+				return locate(keyOffset, err)
+			}
+			key = t.(string)
+		}
+
+		valueOffset := base + d.InputOffset()
+		var raw stdjson.RawMessage
+		if err := d.Decode(&raw); err != nil {
+			return locate(valueOffset, err)
+		}
+
+		if isObject && seen[key] {
+			// Only the error and first-wins policies are streamed
+			if o.DuplicateKeys.Policy == options.DuplicateKeysFirstWins {
+				continue
+			}
+			return locate(keyOffset, nix.DuplicateKeyError(0, 0, key))
+		}
+		seen[key] = true
+
+		if err := CheckDepth(raw, limiter); err != nil {
+			return err
+		}
+
+		v, err := p.ParseBytes(raw)
+		if err == nil {
+			err = MergeDuplicateKeys(v, raw, o.DuplicateKeys.Policy)
+		}
+		if err != nil {
+			err = locate(valueOffset, err)
+			if isObject {
+				return converter.AtKey(err, key)
+			}
+			return converter.AtIndex(err, count)
+		}
+
+		j := NewJSONVisitorWithLimiter(v, i, limiter, o)
+		var element string
+		if isObject {
+			path, v := nix.CollapsePath(key, v, j.single, &o.NixOutput)
+			element = i.IndentValue() + nix.MakeAttrPath(path, o.UnsafeKeys) + " = " + j.visit(v) + ";"
+		} else {
+			element = i.IndentValue() + nix.MakeElementSafe(j.visit(v))
+		}
+		if err := limiter.Err(); err != nil {
+			return err
+		}
+
+		open := "[\n"
+		if isObject {
+			open = "{\n"
+		}
+		if count > 0 {
+			open = ""
+		}
+
+		if _, err := w.WriteString(open + element + "\n"); err != nil {
+			return err
+		}
+		count++
+	}
+
+	// The closing delimiter
+	end := base + d.InputOffset()
+	if _, err := d.Token(); err != nil {
+		return locate(end, err)
+	}
+	end = base + d.InputOffset()
+	if _, err := d.Token(); !errors.Is(err, io.EOF) {
+		return locate(end, fmt.Errorf("unexpected data after the top-level value"))
+	}
+
+	var closing string
+	switch {
+	case count == 0 && isObject:
+		closing = "{}"
+	case count == 0:
+		closing = "[]"
+	case isObject:
+		closing = "}"
+	default:
+		closing = "]"
+	}
+
+	_, err = w.WriteString(closing)
+	return err
+}
+
+func bufferedToNix(data string, options *converter.ConverterOptions) (string, error) {
+	limiter := converter.NewLimiter(options)
+	if err := CheckDepth([]byte(data), limiter); err != nil {
+		return "", err
+	}
+
 	v, err := fastjson.Parse(data)
 	if err != nil {
+		return "", parseError([]byte(data), err)
+	}
+
+	if err := MergeDuplicateKeys(v, []byte(data), options.DuplicateKeys.Policy); err != nil {
 		return "", err
 	}
 
-	out := NewJSONVisitor(v, options).Visit()
+	visitor := NewJSONVisitorWithLimiter(v, common.NewIndentationUnit(options.NixIndentUnit()), limiter, options)
+	out := visitor.Visit()
 
-	return out, nil
+	return limiter.Done(nix.Layout(out, &options.NixOutput))
+}
+
+func ToNix(data string, options *converter.ConverterOptions) (string, error) {
+	return converter.ConvertString(ToNixStream, data, options)
 }
@@ -9,73 +9,97 @@ import (
 	"github.com/orivej/go-nix/nix/parser"
 	"github.com/theobori/nix-converter/converter"
 	"github.com/theobori/nix-converter/converter/nix"
+	"github.com/theobori/nix-converter/converter/options"
 	"github.com/theobori/nix-converter/internal/common"
 )
 
-const IndentSize = 2
+// Deprecated: IndentSize is the default indentation, use
+// options.DefaultIndentSize instead.
+const IndentSize = options.DefaultIndentSize
 
 type NixVisitor struct {
 	i       common.Indentation
 	p       *parser.Parser
+	errors  *nix.Collector
 	node    *parser.Node
 	options *converter.ConverterOptions
 }
 
-type jsonNode struct {
-	valueNode *parser.Node
-	children  map[string]*jsonNode
-	order     []string
-}
-
 func NewNixVisitor(p *parser.Parser, node *parser.Node, options *converter.ConverterOptions) *NixVisitor {
 	return &NixVisitor{
-		i:       *common.NewDefaultIndentation(),
+		i:       *newJSONIndentation(options),
 		node:    node,
 		p:       p,
+		errors:  nix.NewCollector(p, node, options),
 		options: options,
 	}
 }
 
-func (n *NixVisitor) visitKey(node *parser.Node) (string, error) {
-	switch node.Type {
-	case parser.IDNode:
-		return nix.VisitID(n.p, node)
-	case parser.StringNode:
-		if len(node.Nodes) == 0 {
-			return "", nil
-		}
-		return n.p.TokenString(node.Nodes[0].Tokens[0]), nil
+func newJSONIndentation(o *converter.ConverterOptions) *common.Indentation {
+	// Only the pretty style spreads values over several lines
+	if !o.JSONOutput.IsPretty() {
+		return common.NewIndentation(0)
+	}
+
+	// The JSON indentation size takes precedence over the shared one
+	if o.JSONOutput.IndentSize == 0 {
+		return common.NewIndentationUnit(o.Indentation.Unit())
+	}
+
+	return common.NewIndentation(o.JSONOutput.IndentSize)
+}
+
+// Join the elements of an object or an array depending on the output style
+func (n *NixVisitor) join(open string, elements []string, close string) string {
+	switch n.options.JSONOutput.Style {
+	case options.JSONStyleCompact:
+		return open + strings.Join(elements, ", ") + close
+	case options.JSONStyleMinified:
+		return open + strings.Join(elements, ",") + close
 	default:
-		return "", fmt.Errorf("unsupported key node type: %s", node.Type.String())
+		return open + "\n" + strings.Join(elements, ",\n") + "\n" + n.i.IndentValue() + close
+	}
+}
+
+func (n *NixVisitor) keySeparator() string {
+	if n.options.JSONOutput.Style == options.JSONStyleMinified {
+		return ":"
 	}
+
+	return ": "
 }
 
-func (n *NixVisitor) buildJSON(node *jsonNode) (string, error) {
+func (n *NixVisitor) buildJSON(tree *nix.AttrNode) (string, error) {
 	var parts []string
 
-	keys := node.order
+	keys := tree.Order
+	// Sorting the keys bytewise gives the same order as jq -S
+	if n.options.SortIterators.SortHashmap {
+		keys = slices.Clone(tree.Order)
+		slices.Sort(keys)
+	}
 
 	n.i.Indent()
 	for _, k := range keys {
-		child := node.children[k]
-		keyStr := n.i.IndentValue() + makeJSONString(k) + ": "
-		if child.valueNode != nil {
-			valStr, err := n.visit(child.valueNode)
+		child := tree.Children[k]
+		keyStr := n.i.IndentValue() + makeJSONString(k) + n.keySeparator()
+		if child.Value != nil {
+			valStr, err := n.visit(child.Value)
 			if err != nil {
-				return "", err
+				return "", converter.AtKey(err, k)
 			}
 			parts = append(parts, keyStr+valStr)
 		} else {
 			childStr, err := n.buildJSON(child)
 			if err != nil {
-				return "", err
+				return "", converter.AtKey(err, k)
 			}
 			parts = append(parts, keyStr+childStr)
 		}
 	}
 	n.i.UnIndent()
 
-	return "{\n" + strings.Join(parts, ",\n") + "\n" + n.i.IndentValue() + "}", nil
+	return n.join("{", parts, "}"), nil
 }
 
 func (n *NixVisitor) visitSet(node *parser.Node) (string, error) {
@@ -83,37 +107,12 @@ func (n *NixVisitor) visitSet(node *parser.Node) (string, error) {
 		return "{}", nil
 	}
 
-	root := &jsonNode{children: make(map[string]*jsonNode)}
-
-	for _, child := range node.Nodes {
-
-		attrPathNode := child.Nodes[0]
-		valueNode := child.Nodes[1]
-
-		var keys []string
-		for _, kNode := range attrPathNode.Nodes {
-			k, err := n.visitKey(kNode)
-			if err != nil {
-				return "", err
-			}
-			keys = append(keys, k)
-		}
-
-		curr := root
-		for i, k := range keys {
-			if curr.children[k] == nil {
-				curr.children[k] = &jsonNode{children: make(map[string]*jsonNode)}
-				curr.order = append(curr.order, k)
-			}
-			if i == len(keys)-1 {
-				curr.children[k].valueNode = valueNode
-			} else {
-				curr = curr.children[k]
-			}
-		}
+	tree, err := n.errors.BuildAttrTree(node, n.options.DuplicateKeys.Policy)
+	if err != nil {
+		return "", err
 	}
 
-	return n.buildJSON(root)
+	return n.buildJSON(tree)
 }
 
 func (n *NixVisitor) visitList(node *parser.Node) (string, error) {
@@ -122,11 +121,11 @@ func (n *NixVisitor) visitList(node *parser.Node) (string, error) {
 	}
 
 	e := []string{}
-	for _, child := range node.Nodes {
+	for index, child := range node.Nodes {
 		n.i.Indent()
 		s, err := n.visit(child)
 		if err != nil {
-			return "", err
+			return "", converter.AtIndex(err, index)
 		}
 
 		e = append(e, n.i.IndentValue()+s)
@@ -137,7 +136,7 @@ func (n *NixVisitor) visitList(node *parser.Node) (string, error) {
 		slices.Sort(e)
 	}
 
-	return "[\n" + strings.Join(e, ",\n") + "\n" + n.i.IndentValue() + "]", nil
+	return n.join("[", e, "]"), nil
 }
 
 func (n *NixVisitor) visitUnaryNegative(node *parser.Node) (string, error) {
@@ -164,6 +163,10 @@ func (n *NixVisitor) visitIndentedString(node *parser.Node) (string, error) {
 }
 
 func (n *NixVisitor) visit(node *parser.Node) (string, error) {
+	return nix.Visit(n.errors, node, n.visitNode, "null")
+}
+
+func (n *NixVisitor) visitNode(node *parser.Node) (string, error) {
 	switch node.Type {
 	case parser.SetNode:
 		return n.visitSet(node)
@@ -195,11 +198,12 @@ func (n *NixVisitor) visit(node *parser.Node) (string, error) {
 }
 
 func (n *NixVisitor) Visit() (string, error) {
-	return n.visit(n.node)
+	v, err := n.visit(n.node)
+	return n.errors.Done(v, err)
 }
 
 func FromNix(data string, options *converter.ConverterOptions) (string, error) {
-	p, err := parser.ParseString(data)
+	p, err := nix.Parse(data)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,27 @@
+package jsonl
+
+import "github.com/theobori/nix-converter/converter"
+
+type JSONLConverter struct {
+	data    string
+	options *converter.ConverterOptions
+}
+
+func NewJSONLConverter(data string, options *converter.ConverterOptions) *JSONLConverter {
+	return &JSONLConverter{
+		data,
+		options,
+	}
+}
+
+func (j *JSONLConverter) FromNix() (string, error) {
+	return FromNix(j.data, j.options)
+}
+
+func (j *JSONLConverter) ToNix() (string, error) {
+	return ToNix(j.data, j.options)
+}
+
+func (j *JSONLConverter) Type() string {
+	return "jsonl"
+}
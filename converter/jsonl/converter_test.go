@@ -0,0 +1,153 @@
+package jsonl
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/options"
+	"github.com/theobori/nix-converter/internal/common"
+)
+
+var jsonlStrings = []string{
+	`{"name":"web-1","tags":["linux","nginx"],"cpu":4}
+{"name":"db-1","tags":[],"cpu":-8,"meta":{"rack":"A\nB"}}
+"plain"
+-1.5
+null
+[1,[2,[3]]]`,
+	`{"level":"info","msg":"started"}`,
+}
+
+var nixStrings = []string{
+	`[
+  {
+    name = "web-1";
+    tags = [
+      "linux"
+      "nginx"
+    ];
+    cpu = 4;
+  }
+  {
+    name = "db-1";
+    tags = [];
+    cpu = -8;
+  }
+  "plain"
+  (-1.5)
+  null
+]`,
+	`[]`,
+}
+
+func TestJSONLToNix(t *testing.T) {
+	t.Parallel()
+	common.TestHelperToNixStrings(t, jsonlStrings, FromNix, ToNix, converter.NewDefaultConverterOptions())
+}
+
+func TestJSONLFromNix(t *testing.T) {
+	t.Parallel()
+	options := converter.ConverterOptions{
+		SortIterators: *options.NewDefaultSortIterators(),
+		UnsafeKeys:    true,
+	}
+
+	common.TestHelperFromNixStrings(t, nixStrings, FromNix, ToNix, &options)
+}
+
+func TestJSONLToNixStream(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "blank lines and CRLF",
+			input: "{\"a\": 1}\r\n\r\n  [true]  \n",
+			want: `[
+  {
+    "a" = 1;
+  }
+  [
+    true
+  ]
+]`,
+		},
+		{
+			name:  "empty input",
+			input: "",
+			want:  "[]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var b strings.Builder
+			err := ToNixStream(context.Background(), strings.NewReader(tt.input), &b, converter.NewDefaultConverterOptions())
+			if err != nil {
+				t.Fatalf("ToNixStream() error = %v", err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("ToNixStream() = \n%q\nwant \n%q", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONLErrors(t *testing.T) {
+	t.Parallel()
+	_, err := ToNix("{\"a\": 1}\n{\"a\": \n", converter.NewDefaultConverterOptions())
+	if err == nil || !strings.HasPrefix(err.Error(), "line 2:") {
+		t.Errorf("ToNix() error = %v, want a line 2 error", err)
+	}
+
+	_, err = ToNix("{\"a\": 1}\n  {\"b\": {\"c\": 1, \"c\": 2}}\n", converter.NewDefaultConverterOptions())
+	if want := "line 2, column 18: the key 'c' is defined twice (at b)"; err == nil || err.Error() != want {
+		t.Errorf("ToNix() error = %v, want %q", err, want)
+	}
+
+	// The error of a line after converted ones gives its line
+	o := converter.NewDefaultConverterOptions()
+	o.Limits.MaxDepth = 2
+	var b strings.Builder
+	err = ToNixStream(context.Background(), strings.NewReader("[1]\n[2]\n[[3]]\n"), &b, o)
+	if err == nil || !strings.HasPrefix(err.Error(), "line 3: ") {
+		t.Errorf("ToNixStream() error = %v, want a line 3 error", err)
+	}
+
+	// The nodes limit is shared by the lines
+	o = converter.NewDefaultConverterOptions()
+	o.Limits.MaxNodes = 3
+	_, err = ToNix("1\n2\n3\n4\n", o)
+	var limitErr *converter.LimitError
+	if !errors.As(err, &limitErr) || !strings.HasPrefix(err.Error(), "line 4: ") {
+		t.Errorf("ToNix() error = %v, want a line 4 limit error", err)
+	}
+
+	_, err = FromNix(`{ a = 1; }`, converter.NewDefaultConverterOptions())
+	if err == nil {
+		t.Error("FromNix() expected an error for a non-list expression")
+	}
+}
+
+func TestJSONLAllErrors(t *testing.T) {
+	t.Parallel()
+	o := converter.NewDefaultConverterOptions()
+	o.AllErrors = true
+
+	_, err := FromNix("[\n  { a = x: x; }\n  { b = [ (y: y) ]; }\n]", o)
+	if err == nil {
+		t.Fatal("FromNix() expected errors")
+	}
+
+	expected := "line 2, column 9: unsupported node type: function (at [0].a)\n" +
+		"line 3, column 12: unsupported node type: function (at [1].b[0])"
+	if err.Error() != expected {
+		t.Errorf("FromNix() error = %q, want %q", err.Error(), expected)
+	}
+}
@@ -0,0 +1,122 @@
+package jsonl
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/json"
+	"github.com/theobori/nix-converter/converter/nix"
+	"github.com/theobori/nix-converter/internal/common"
+	"github.com/valyala/fastjson"
+)
+
+// ToNixStream reads newline-delimited JSON values from r and writes a Nix
+// list to w, one element per line. Lines are converted one at a time so the
+// whole input never has to be held in memory, except when the list must be
+// sorted. The depth limit applies to every line and the nodes limit to all of
+// them. As the elements are written once converted, when a line fails the
+// elements of the lines before it may already be written to w, in a list that
+// is not closed. The error gives the line that failed.
+func ToNixStream(ctx context.Context, r io.Reader, w io.Writer, options *converter.ConverterOptions) error {
+	var (
+		p        fastjson.Parser
+		elements []string
+		count    int
+	)
+
+	reader := bufio.NewReader(r)
+	writer := bufio.NewWriter(converter.NewLimiter(options).Writer(w))
+
+	i := common.NewIndentationUnit(options.NixIndentUnit())
+	i.Indent()
+
+	// The lines share the limits of the list
+	limiter := converter.NewLimiter(options.WithContext(ctx))
+
+	for lineNumber := 1; ; lineNumber++ {
+		if err := ctx.Err(); err != nil {
+			return converter.Within(err, lineNumber, 0)
+		}
+
+		line, err := reader.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return converter.Within(err, lineNumber, 0)
+		}
+
+		eof := err != nil
+		column := 1 + len(line) - len(strings.TrimLeftFunc(line, unicode.IsSpace))
+		line = strings.TrimSpace(line)
+
+		if line != "" {
+			if err := json.CheckDepth([]byte(line), limiter); err != nil {
+				return converter.Within(err, lineNumber, 0)
+			}
+
+			v, err := p.Parse(line)
+			if err != nil {
+				return converter.Errorf(lineNumber, 0, "%w", err)
+			}
+
+			if err := json.MergeDuplicateKeys(v, []byte(line), options.DuplicateKeys.Policy); err != nil {
+				return converter.Within(err, lineNumber, column)
+			}
+
+			visitor := json.NewJSONVisitorWithLimiter(v, i, limiter, options)
+			element := i.IndentValue() + nix.MakeElementSafe(visitor.Visit())
+			if err := visitor.Err(); err != nil {
+				return converter.Within(err, lineNumber, 0)
+			}
+			element = nix.Layout(element, &options.NixOutput)
+
+			if options.SortIterators.SortList {
+				elements = append(elements, element)
+			} else {
+				if count == 0 {
+					_, err = writer.WriteString("[\n")
+				}
+				if err == nil {
+					_, err = writer.WriteString(element + "\n")
+				}
+				if err != nil {
+					return converter.Within(err, lineNumber, 0)
+				}
+			}
+
+			count++
+		}
+
+		if eof {
+			break
+		}
+	}
+
+	if options.SortIterators.SortList && count > 0 {
+		slices.Sort(elements)
+		_, err := writer.WriteString("[\n" + strings.Join(elements, "\n") + "\n")
+		if err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if count == 0 {
+		_, err = writer.WriteString("[]")
+	} else {
+		_, err = writer.WriteString("]")
+	}
+	if err != nil {
+		return err
+	}
+
+	return writer.Flush()
+}
+
+func ToNix(data string, options *converter.ConverterOptions) (string, error) {
+	return converter.ConvertString(ToNixStream, data, options)
+}
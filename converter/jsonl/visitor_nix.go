@@ -0,0 +1,83 @@
+package jsonl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/orivej/go-nix/nix/parser"
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/json"
+	"github.com/theobori/nix-converter/converter/nix"
+	"github.com/theobori/nix-converter/converter/options"
+)
+
+type NixVisitor struct {
+	p       *parser.Parser
+	errors  *nix.Collector
+	node    *parser.Node
+	options *converter.ConverterOptions
+}
+
+func NewNixVisitor(p *parser.Parser, node *parser.Node, converterOptions *converter.ConverterOptions) *NixVisitor {
+	// Every element must fit on a single line
+	elementOptions := *converterOptions
+	elementOptions.JSONOutput.Style = options.JSONStyleMinified
+
+	return &NixVisitor{
+		node:    node,
+		p:       p,
+		errors:  nix.NewCollector(p, node, converterOptions),
+		options: &elementOptions,
+	}
+}
+
+// visitElement collects the errors of the element, when every error is
+// wanted, before going on with the next one
+func (n *NixVisitor) visitElement(node *parser.Node) (string, error) {
+	v, err := json.NewNixVisitor(n.p, node, n.options).Visit()
+	return nix.Collect(n.errors, node, v, err, "null")
+}
+
+func (n *NixVisitor) visit(node *parser.Node) (string, error) {
+	return nix.Visit(n.errors, node, n.visitNode, "")
+}
+
+func (n *NixVisitor) visitNode(node *parser.Node) (string, error) {
+	switch node.Type {
+	case parser.ListNode:
+		e := []string{}
+		for index, child := range node.Nodes {
+			s, err := n.visitElement(child)
+			if err != nil {
+				return "", converter.AtIndex(err, index)
+			}
+
+			e = append(e, s)
+		}
+
+		return strings.Join(e, "\n"), nil
+	case parser.ParensNode:
+		return n.visit(node.Nodes[0])
+	default:
+		return "", fmt.Errorf("the Nix expression must be a list, got %s", node.Type.String())
+	}
+}
+
+func (n *NixVisitor) Visit() (string, error) {
+	v, err := n.visit(n.node)
+	return n.errors.Done(v, err)
+}
+
+func FromNix(data string, options *converter.ConverterOptions) (string, error) {
+	p, err := nix.Parse(data)
+	if err != nil {
+		return "", err
+	}
+
+	out, err := NewNixVisitor(p, p.Result, options).Visit()
+	if err != nil {
+		return "", err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,27 @@
+package kdl
+
+import "github.com/theobori/nix-converter/converter"
+
+type KDLConverter struct {
+	data    string
+	options *converter.ConverterOptions
+}
+
+func NewKDLConverter(data string, options *converter.ConverterOptions) *KDLConverter {
+	return &KDLConverter{
+		data,
+		options,
+	}
+}
+
+func (k *KDLConverter) FromNix() (string, error) {
+	return FromNix(k.data, k.options)
+}
+
+func (k *KDLConverter) ToNix() (string, error) {
+	return ToNix(k.data, k.options)
+}
+
+func (k *KDLConverter) Type() string {
+	return "kdl"
+}
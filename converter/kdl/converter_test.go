@@ -0,0 +1,185 @@
+package kdl
+
+import (
+	"testing"
+
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/options"
+	"github.com/theobori/nix-converter/internal/common"
+)
+
+var kdlStrings = []string{
+	`layout {
+  pane split_direction="vertical" {
+    pane command="htop" size="70%"
+    pane
+  }
+}
+keybinds clear-defaults=true {
+  normal {
+    bind "Ctrl a" {
+      SwitchToMode "locked"
+    }
+  }
+}
+theme "dracula"
+(author)person "Kat" 1.5 -3 null false age=(u8)31 "first name"="Kat"
+empty {}`,
+	`package {
+  name "nix-converter"
+  description "a \"data\" converter\n"
+  authors "theobori" "\\x"
+}`,
+}
+
+var nixStrings = []string{
+	`[
+  {
+    name = "node";
+    args = [
+      1
+      (-2)
+      1.0
+      "a"
+      null
+      true
+      {
+        type = "date";
+        value = "2024-01-01";
+      }
+    ];
+    props = {
+      key = "value";
+      "with space" = -0.5;
+    };
+    children = [
+      {
+        type = "mytype";
+        name = "child node";
+      }
+      {
+        name = "empty";
+        children = [];
+      }
+    ];
+  }
+]`,
+	`[]`,
+}
+
+func TestKDLToNix(t *testing.T) {
+	t.Parallel()
+	common.TestHelperToNixStrings(t, kdlStrings, FromNix, ToNix, converter.NewDefaultConverterOptions())
+}
+
+func TestKDLFromNix(t *testing.T) {
+	t.Parallel()
+	options := converter.ConverterOptions{
+		SortIterators: *options.NewDefaultSortIterators(),
+		UnsafeKeys:    true,
+	}
+
+	common.TestHelperFromNixStrings(t, nixStrings, FromNix, ToNix, &options)
+}
+
+func TestKDLSyntax(t *testing.T) {
+	t.Parallel()
+	// Like the KDL specification, the last value of a repeated property wins
+	duplicateKeys := options.DuplicateKeys{Policy: options.DuplicateKeysLastWins}
+	options := converter.NewDefaultConverterOptions()
+	options.UnsafeKeys = true
+	options.DuplicateKeys = duplicateKeys
+
+	input := `/* block /* nested */ comment */
+// line comment
+node 0xff 0o17 0b101 1_000 1e3 r"raw\n" r#"a "quoted" raw"# #"v2 raw"# \
+  #true #null bare key=1 key=2 /-discarded /-skipped=1 /-{
+    ignored
+  }
+/-ignored-node
+"quoted name"; second "\u{1F600}\s"`
+
+	want := `[
+  {
+    name = "node";
+    args = [
+      255
+      15
+      5
+      1000
+      1000.0
+      "raw\\n"
+      "a \"quoted\" raw"
+      "v2 raw"
+      true
+      null
+      "bare"
+    ];
+    props = {
+      key = 2;
+    };
+  }
+  {
+    name = "quoted name";
+  }
+  {
+    name = "second";
+    args = [
+      "😀 "
+    ];
+  }
+]`
+
+	output, err := ToNix(input, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if output != want {
+		t.Errorf("ToNix() = \n%v, want \n%v", output, want)
+	}
+}
+
+func TestKDLErrors(t *testing.T) {
+	t.Parallel()
+	kdlInputs := []string{
+		`node {`,
+		`}`,
+		`node "unterminated`,
+		`node /* unterminated`,
+		`123 arg`,
+		`true arg`,
+		`node 0xzz`,
+		`node 99999999999999999999`,
+		`node #inf`,
+		`node "\q"`,
+		`node { child; } arg`,
+		`node (type arg`,
+		"node \\ arg\n",
+	}
+
+	for _, input := range kdlInputs {
+		if _, err := ToNix(input, converter.NewDefaultConverterOptions()); err == nil {
+			t.Errorf("ToNix(%q) expected an error", input)
+		}
+	}
+
+	nixInputs := []string{
+		`{ name = "a"; }`,
+		`[ "a" ]`,
+		`[ { args = [ 1 ]; } ]`,
+		`[ { name = 1; } ]`,
+		`[ { name = "a"; extra = 1; } ]`,
+		`[ { name = "a"; args = 1; } ]`,
+		`[ { name = "a"; args = [ [ 1 ] ]; } ]`,
+		`[ { name = "a"; args = [ { value = 1; } ]; } ]`,
+		`[ { name = "a"; props = [ 1 ]; } ]`,
+		`[ { name = "a"; children = { }; } ]`,
+	}
+
+	for _, input := range nixInputs {
+		if _, err := FromNix(input, converter.NewDefaultConverterOptions()); err == nil {
+			t.Errorf("FromNix(%q) expected an error", input)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// Package kdl converts KDL documents to Nix and vice versa.
+//
+// A document is a list of nodes and a node is a set with the following keys,
+// the empty ones being omitted.
+//
+//   - name is the node name.
+//   - type is the type annotation of the node, like "author" in (author)name.
+//   - args is the list of the positional arguments.
+//   - props is a set of the properties, the value of a repeated property is
+//     chosen by the duplicate keys policy.
+//   - children is the list of the child nodes, an empty children block is an
+//     empty list.
+//
+// Arguments and property values are Nix strings, numbers, booleans and null. A
+// value with a type annotation, like (u8)10, is the set { type = "u8"; value = 10; }.
+// Numbers written with another radix, like 0xff, are converted to decimal.
+//
+// Both KDL 1.0 and KDL 2.0 keywords and raw strings are read, KDL 1.0 is
+// written. Comments and slashdash comments are dropped.
+package kdl
+
+import "github.com/theobori/nix-converter/converter/options"
+
+const (
+	NameKey     = "name"
+	TypeKey     = "type"
+	ArgsKey     = "args"
+	PropsKey    = "props"
+	ChildrenKey = "children"
+	ValueKey    = "value"
+)
+
+// Value is an argument or a property value with its optional type
+// annotation, the value is nil, a bool, an int64, a float64 or a string
+type Value struct {
+	Type  string
+	Value any
+}
+
+type Property struct {
+	Name  string
+	Value Value
+}
+
+type Node struct {
+	Type     string
+	Name     string
+	Args     []Value
+	Props    []Property
+	Children []*Node
+}
+
+// Sets a property, a repeated property is kept at its first position with the
+// value chosen by the policy, it returns false when the policy is an error
+func (n *Node) setProperty(name string, value Value, policy string) bool {
+	for i := range n.Props {
+		if n.Props[i].Name != name {
+			continue
+		}
+
+		switch policy {
+		case options.DuplicateKeysError:
+			return false
+		case options.DuplicateKeysFirstWins:
+		default:
+			n.Props[i].Value = value
+		}
+		return true
+	}
+
+	n.Props = append(n.Props, Property{name, value})
+	return true
+}
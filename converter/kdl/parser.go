@@ -0,0 +1,554 @@
+package kdl
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/theobori/nix-converter/converter"
+)
+
+type kdlParser struct {
+	r      []rune
+	pos    int
+	policy string
+}
+
+func (p *kdlParser) errorf(format string, a ...any) error {
+	before := string(p.r[:min(p.pos, len(p.r))])
+	line := 1 + strings.Count(before, "\n")
+	column := len(before) - strings.LastIndexByte(before, '\n')
+	return converter.Errorf(line, column, format, a...)
+}
+
+func (p *kdlParser) peekAt(offset int) rune {
+	if p.pos+offset >= len(p.r) {
+		return -1
+	}
+
+	return p.r[p.pos+offset]
+}
+
+func (p *kdlParser) peek() rune {
+	return p.peekAt(0)
+}
+
+func (p *kdlParser) startsWith(s string) bool {
+	for i, r := range []rune(s) {
+		if p.peekAt(i) != r {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isNewline(r rune) bool {
+	switch r {
+	case '\n', '\r', '\u0085', '\u000C', '\u2028', '\u2029':
+		return true
+	default:
+		return false
+	}
+}
+
+func isWhitespace(r rune) bool {
+	return r == 0xFEFF || unicode.IsSpace(r) && !isNewline(r)
+}
+
+func (p *kdlParser) skipBlockComment() error {
+	p.pos += 2
+	depth := 1
+
+	for depth > 0 {
+		switch {
+		case p.peek() == -1:
+			return p.errorf("unterminated block comment")
+		case p.startsWith("/*"):
+			depth++
+			p.pos += 2
+		case p.startsWith("*/"):
+			depth--
+			p.pos += 2
+		default:
+			p.pos++
+		}
+	}
+
+	return nil
+}
+
+func (p *kdlParser) skipLineComment() {
+	for p.peek() != -1 && !isNewline(p.peek()) {
+		p.pos++
+	}
+}
+
+// Skips the whitespace, block comments and line continuations inside a node
+func (p *kdlParser) skipNodeSpace() error {
+	for {
+		switch {
+		case isWhitespace(p.peek()):
+			p.pos++
+		case p.startsWith("/*"):
+			if err := p.skipBlockComment(); err != nil {
+				return err
+			}
+		case p.peek() == '\\':
+			p.pos++
+			for isWhitespace(p.peek()) {
+				p.pos++
+			}
+			if p.startsWith("//") {
+				p.skipLineComment()
+			}
+			if p.peek() != -1 && !isNewline(p.peek()) {
+				return p.errorf("expected a newline after a line continuation")
+			}
+			if p.startsWith("\r\n") {
+				p.pos++
+			}
+			p.pos++
+		default:
+			return nil
+		}
+	}
+}
+
+// Skips the space between nodes, newlines and comments included
+func (p *kdlParser) skipLineSpace() error {
+	for {
+		if err := p.skipNodeSpace(); err != nil {
+			return err
+		}
+
+		switch {
+		case isNewline(p.peek()):
+			p.pos++
+		case p.startsWith("//"):
+			p.skipLineComment()
+		default:
+			return nil
+		}
+	}
+}
+
+func (p *kdlParser) bareToken() string {
+	start := p.pos
+	for p.peek() != -1 && IsIdentifierChar(p.peek()) {
+		p.pos++
+	}
+
+	return string(p.r[start:p.pos])
+}
+
+// Reports if the next token is a string, raw strings included
+func (p *kdlParser) isStringStart() bool {
+	offset := 0
+	if p.peek() == 'r' {
+		offset++
+	}
+	for p.peekAt(offset) == '#' {
+		offset++
+	}
+
+	return p.peekAt(offset) == '"' && (offset == 0 || p.peekAt(offset-1) == '#' || p.peek() == 'r')
+}
+
+func (p *kdlParser) rawString(hashes int) (string, error) {
+	end := "\"" + strings.Repeat("#", hashes)
+	start := p.pos
+
+	for !p.startsWith(end) {
+		if p.peek() == -1 {
+			return "", p.errorf("unterminated raw string")
+		}
+		p.pos++
+	}
+
+	s := string(p.r[start:p.pos])
+	p.pos += len(end)
+
+	return s, nil
+}
+
+func (p *kdlParser) escape(b *strings.Builder) error {
+	r := p.peek()
+	p.pos++
+
+	switch r {
+	case 'n':
+		b.WriteRune('\n')
+	case 'r':
+		b.WriteRune('\r')
+	case 't':
+		b.WriteRune('\t')
+	case '\\', '/', '"':
+		b.WriteRune(r)
+	case 'b':
+		b.WriteRune('\b')
+	case 'f':
+		b.WriteRune('\f')
+	case 's':
+		b.WriteRune(' ')
+	case 'u':
+		if p.peek() != '{' {
+			return p.errorf("invalid unicode escape")
+		}
+		end := p.pos + 1
+		for end < len(p.r) && p.r[end] != '}' && end-p.pos <= 7 {
+			end++
+		}
+		if end >= len(p.r) || p.r[end] != '}' {
+			return p.errorf("invalid unicode escape")
+		}
+		code, err := strconv.ParseUint(string(p.r[p.pos+1:end]), 16, 32)
+		if err != nil || code > unicode.MaxRune || code >= 0xD800 && code <= 0xDFFF {
+			return p.errorf("invalid unicode escape")
+		}
+		b.WriteRune(rune(code))
+		p.pos = end + 1
+	default:
+		// KDL 2.0 whitespace escape
+		if isWhitespace(r) || isNewline(r) {
+			for isWhitespace(p.peek()) || isNewline(p.peek()) {
+				p.pos++
+			}
+			return nil
+		}
+		return p.errorf("invalid escape '\\%c'", r)
+	}
+
+	return nil
+}
+
+func (p *kdlParser) string() (string, error) {
+	isRaw := p.peek() == 'r'
+	if isRaw {
+		p.pos++
+	}
+
+	hashes := 0
+	for p.peek() == '#' {
+		hashes++
+		p.pos++
+	}
+	p.pos++
+
+	if isRaw || hashes > 0 {
+		return p.rawString(hashes)
+	}
+
+	var b strings.Builder
+	for {
+		r := p.peek()
+		p.pos++
+
+		switch r {
+		case -1:
+			return "", p.errorf("unterminated string")
+		case '"':
+			return b.String(), nil
+		case '\\':
+			if err := p.escape(&b); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+}
+
+func (p *kdlParser) number(token string) (any, error) {
+	s := strings.ReplaceAll(token, "_", "")
+	sign := ""
+	if s[0] == '+' || s[0] == '-' {
+		sign, s = s[:1], s[1:]
+	}
+
+	base := 0
+	switch {
+	case strings.HasPrefix(s, "0x"):
+		base = 16
+	case strings.HasPrefix(s, "0o"):
+		base = 8
+	case strings.HasPrefix(s, "0b"):
+		base = 2
+	}
+
+	if base != 0 {
+		i, err := strconv.ParseInt(sign+s[2:], base, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number '%s'", token)
+		}
+		return i, nil
+	}
+
+	if strings.ContainsAny(s, ".eE") {
+		f, err := strconv.ParseFloat(sign+s, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number '%s'", token)
+		}
+		return f, nil
+	}
+
+	i, err := strconv.ParseInt(sign+s, 10, 64)
+	if err != nil {
+		return nil, p.errorf("invalid number '%s'", token)
+	}
+
+	return i, nil
+}
+
+func (p *kdlParser) typeAnnotation() (string, error) {
+	if p.peek() != '(' {
+		return "", nil
+	}
+	p.pos++
+
+	if err := p.skipNodeSpace(); err != nil {
+		return "", err
+	}
+
+	name, err := p.identifier()
+	if err != nil {
+		return "", err
+	}
+
+	if err := p.skipNodeSpace(); err != nil {
+		return "", err
+	}
+
+	if p.peek() != ')' {
+		return "", p.errorf("expected ')' after a type annotation")
+	}
+	p.pos++
+
+	return name, p.skipNodeSpace()
+}
+
+// Reads an identifier or a string
+func (p *kdlParser) identifier() (string, error) {
+	if p.isStringStart() {
+		return p.string()
+	}
+
+	token := p.bareToken()
+	if token == "" {
+		return "", p.errorf("expected an identifier")
+	}
+
+	if strings.HasPrefix(token, "#") || looksLikeNumber([]rune(token)) {
+		return "", p.errorf("invalid identifier '%s'", token)
+	}
+
+	switch token {
+	case "true", "false", "null":
+		return "", p.errorf("invalid identifier '%s'", token)
+	}
+
+	return token, nil
+}
+
+func (p *kdlParser) value() (Value, error) {
+	t, err := p.typeAnnotation()
+	if err != nil {
+		return Value{}, err
+	}
+
+	if p.isStringStart() {
+		s, err := p.string()
+		return Value{t, s}, err
+	}
+
+	token := p.bareToken()
+
+	switch token {
+	case "":
+		if p.peek() == -1 {
+			return Value{}, p.errorf("unexpected end of input")
+		}
+		return Value{}, p.errorf("unexpected character '%c'", p.peek())
+	case "true", "#true":
+		return Value{t, true}, nil
+	case "false", "#false":
+		return Value{t, false}, nil
+	case "null", "#null":
+		return Value{t, nil}, nil
+	case "#inf", "#-inf", "#nan":
+		return Value{}, p.errorf("'%s' has no Nix representation", token)
+	}
+
+	if looksLikeNumber([]rune(token)) {
+		n, err := p.number(token)
+		return Value{t, n}, err
+	}
+
+	if strings.HasPrefix(token, "#") {
+		return Value{}, p.errorf("invalid keyword '%s'", token)
+	}
+
+	// KDL 2.0 identifier strings
+	return Value{t, token}, nil
+}
+
+// Reads a property name, if the next entry is a property
+func (p *kdlParser) propertyName() (string, bool, error) {
+	start := p.pos
+
+	var (
+		name string
+		err  error
+	)
+	if p.isStringStart() {
+		name, err = p.string()
+		if err != nil {
+			return "", false, err
+		}
+	} else {
+		name = p.bareToken()
+		if name == "" || looksLikeNumber([]rune(name)) || strings.HasPrefix(name, "#") {
+			p.pos = start
+			return "", false, nil
+		}
+	}
+
+	if p.peek() != '=' {
+		p.pos = start
+		return "", false, nil
+	}
+	p.pos++
+
+	return name, true, nil
+}
+
+func (p *kdlParser) node() (*Node, error) {
+	t, err := p.typeAnnotation()
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := p.identifier()
+	if err != nil {
+		return nil, err
+	}
+
+	node := &Node{Type: t, Name: name}
+
+	for {
+		if err := p.skipNodeSpace(); err != nil {
+			return nil, err
+		}
+
+		switch r := p.peek(); {
+		case r == -1 || r == '}':
+			return node, nil
+		case isNewline(r) || r == ';':
+			p.pos++
+			return node, nil
+		case p.startsWith("//"):
+			p.skipLineComment()
+			continue
+		}
+
+		isDiscarded := p.startsWith("/-")
+		if isDiscarded {
+			p.pos += 2
+			if err := p.skipNodeSpace(); err != nil {
+				return nil, err
+			}
+		}
+
+		if p.peek() == '{' {
+			p.pos++
+			children, err := p.nodes(true)
+			if err != nil {
+				return nil, err
+			}
+			if !isDiscarded {
+				if node.Children != nil {
+					return nil, p.errorf("node '%s' has several children blocks", name)
+				}
+				node.Children = children
+			}
+			continue
+		}
+
+		if node.Children != nil {
+			return nil, p.errorf("node '%s' has entries after its children block", name)
+		}
+
+		propertyName, isProperty, err := p.propertyName()
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+
+		if isDiscarded {
+			continue
+		}
+
+		if isProperty {
+			if !node.setProperty(propertyName, v, p.policy) {
+				return nil, p.errorf("the property '%s' is defined twice", propertyName)
+			}
+		} else {
+			node.Args = append(node.Args, v)
+		}
+	}
+}
+
+func (p *kdlParser) nodes(isBlock bool) ([]*Node, error) {
+	nodes := []*Node{}
+
+	for {
+		if err := p.skipLineSpace(); err != nil {
+			return nil, err
+		}
+
+		switch p.peek() {
+		case ';':
+			p.pos++
+			continue
+		case -1:
+			if isBlock {
+				return nil, p.errorf("unterminated children block")
+			}
+			return nodes, nil
+		case '}':
+			if !isBlock {
+				return nil, p.errorf("unexpected '}'")
+			}
+			p.pos++
+			return nodes, nil
+		}
+
+		isDiscarded := p.startsWith("/-")
+		if isDiscarded {
+			p.pos += 2
+			if err := p.skipLineSpace(); err != nil {
+				return nil, err
+			}
+		}
+
+		node, err := p.node()
+		if err != nil {
+			return nil, err
+		}
+
+		if !isDiscarded {
+			nodes = append(nodes, node)
+		}
+	}
+}
+
+// Parse reads a KDL document, the value of a repeated property is chosen by
+// the policy
+func Parse(data string, policy string) ([]*Node, error) {
+	p := kdlParser{r: []rune(data), policy: policy}
+	return p.nodes(false)
+}
@@ -0,0 +1,41 @@
+package kdl
+
+import (
+	"fmt"
+	"strings"
+)
+
+var stringReplacer = strings.NewReplacer(
+	"\\", "\\\\",
+	"\"", "\\\"",
+	"\n", "\\n",
+	"\r", "\\r",
+	"\t", "\\t",
+	"\b", "\\b",
+	"\f", "\\f",
+)
+
+// Quote writes a KDL string, other control characters are written as
+// unicode escapes
+func Quote(s string) string {
+	var b strings.Builder
+
+	for _, r := range stringReplacer.Replace(s) {
+		if r < 0x20 || r == 0x7F {
+			fmt.Fprintf(&b, "\\u{%x}", r)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+
+	return "\"" + b.String() + "\""
+}
+
+// MakeIdentifier writes a node or property name, quoted when needed
+func MakeIdentifier(s string) string {
+	if IsIdentifierValid(s) {
+		return s
+	}
+
+	return Quote(s)
+}
@@ -0,0 +1,46 @@
+package kdl
+
+import (
+	"strings"
+	"unicode"
+)
+
+const nonIdentifierChars = "\\/(){}<>;[]=,\""
+
+func IsIdentifierChar(r rune) bool {
+	return r > 0x20 && r != 0x7F && r != 0xFEFF && !unicode.IsSpace(r) &&
+		!strings.ContainsRune(nonIdentifierChars, r)
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+// Reports if a bare token starts like a number
+func looksLikeNumber(r []rune) bool {
+	if len(r) > 0 && (r[0] == '+' || r[0] == '-') {
+		r = r[1:]
+	}
+	if len(r) > 0 && r[0] == '.' {
+		r = r[1:]
+	}
+
+	return len(r) > 0 && isDigit(r[0])
+}
+
+// IsIdentifierValid reports if a string can be written as a bare identifier,
+// in both KDL 1.0 and KDL 2.0
+func IsIdentifierValid(s string) bool {
+	switch s {
+	case "", "true", "false", "null", "inf", "-inf", "nan":
+		return false
+	}
+
+	for _, r := range s {
+		if !IsIdentifierChar(r) || r == '#' {
+			return false
+		}
+	}
+
+	return !looksLikeNumber([]rune(s))
+}
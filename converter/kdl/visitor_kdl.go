@@ -0,0 +1,247 @@
+package kdl
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/nix"
+	"github.com/theobori/nix-converter/internal/common"
+)
+
+type KDLVisitor struct {
+	i       common.Indentation
+	nodes   []*Node
+	limiter *converter.Limiter
+	options *converter.ConverterOptions
+}
+
+func NewKDLVisitor(nodes []*Node, options *converter.ConverterOptions) *KDLVisitor {
+	return &KDLVisitor{
+		i:       *common.NewIndentationUnit(options.NixIndentUnit()),
+		nodes:   nodes,
+		limiter: converter.NewLimiter(options),
+		options: options,
+	}
+}
+
+func (k *KDLVisitor) visitString(s string) string {
+	if strings.Contains(s, "\n") {
+		return common.MakeIndentedString(s, k.i.IndentValue(), k.i.Unit())
+	}
+
+	return common.MakeStringSafe(s)
+}
+
+func (k *KDLVisitor) visitScalar(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "null", nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int64:
+		return nix.FormatInt(v), nil
+	case float64:
+		if math.IsInf(v, 0) {
+			return "", fmt.Errorf("the number %v is out of range", v)
+		}
+		return nix.FormatFloat(v), nil
+	default:
+		return k.visitString(v.(string)), nil
+	}
+}
+
+// Builds a set from its attribute paths and visited values, one indentation
+// level deeper
+func (k *KDLVisitor) buildSet(paths [][]string, values []string) string {
+	e := []string{}
+	k.i.Indent()
+	for i, path := range paths {
+		left := nix.MakeAttrPath(path, k.options.UnsafeKeys)
+		e = append(e, k.i.IndentValue()+left+" = "+values[i]+";")
+	}
+	k.i.UnIndent()
+
+	if k.options.SortIterators.SortHashmap {
+		slices.Sort(e)
+	}
+
+	return "{\n" + strings.Join(e, "\n") + "\n" + k.i.IndentValue() + "}"
+}
+
+func (k *KDLVisitor) visitValue(v Value) (string, error) {
+	if err := k.limiter.Enter(); err != nil {
+		return "", err
+	}
+	defer k.limiter.Leave()
+
+	if v.Type == "" {
+		return k.visitScalar(v.Value)
+	}
+
+	k.i.Indent()
+	s, err := k.visitScalar(v.Value)
+	k.i.UnIndent()
+	if err != nil {
+		return "", err
+	}
+
+	return k.buildSet([][]string{{TypeKey}, {ValueKey}}, []string{k.visitString(v.Type), s}), nil
+}
+
+func (k *KDLVisitor) visitArgs(args []Value) (string, error) {
+	e := []string{}
+	for _, arg := range args {
+		k.i.Indent()
+		s, err := k.visitValue(arg)
+		if err != nil {
+			return "", err
+		}
+
+		e = append(e, k.i.IndentValue()+nix.MakeElementSafe(s))
+		k.i.UnIndent()
+	}
+
+	if k.options.SortIterators.SortList {
+		slices.Sort(e)
+	}
+
+	return "[\n" + strings.Join(e, "\n") + "\n" + k.i.IndentValue() + "]", nil
+}
+
+func (k *KDLVisitor) visitProps(props []Property) (string, error) {
+	paths := [][]string{}
+	values := []string{}
+	for _, prop := range props {
+		k.i.Indent()
+		s, err := k.visitValue(prop.Value)
+		k.i.UnIndent()
+		if err != nil {
+			return "", err
+		}
+
+		paths = append(paths, []string{prop.Name})
+		values = append(values, s)
+	}
+
+	return k.buildSet(paths, values), nil
+}
+
+// Returns the single property of the properties
+func (k *KDLVisitor) single(v any) (string, any, bool) {
+	props, isProps := v.([]Property)
+	if !isProps || len(props) != 1 {
+		return "", nil, false
+	}
+
+	return props[0].Name, props[0].Value, true
+}
+
+func (k *KDLVisitor) visitNode(node *Node) (string, error) {
+	if err := k.limiter.Enter(); err != nil {
+		return "", err
+	}
+	defer k.limiter.Leave()
+
+	paths := [][]string{}
+	values := []string{}
+
+	if node.Type != "" {
+		paths = append(paths, []string{TypeKey})
+		values = append(values, k.visitString(node.Type))
+	}
+
+	paths = append(paths, []string{NameKey})
+	values = append(values, k.visitString(node.Name))
+
+	k.i.Indent()
+	if len(node.Args) > 0 {
+		s, err := k.visitArgs(node.Args)
+		if err != nil {
+			return "", err
+		}
+		paths = append(paths, []string{ArgsKey})
+		values = append(values, s)
+	}
+
+	if len(node.Props) > 0 {
+		path, value := nix.CollapsePath(PropsKey, any(node.Props), k.single, &k.options.NixOutput)
+
+		var (
+			s   string
+			err error
+		)
+		if props, isProps := value.([]Property); isProps {
+			s, err = k.visitProps(props)
+		} else {
+			s, err = k.visitValue(value.(Value))
+		}
+		if err != nil {
+			return "", err
+		}
+		paths = append(paths, path)
+		values = append(values, s)
+	}
+
+	if node.Children != nil {
+		s, err := k.visitNodes(node.Children)
+		if err != nil {
+			return "", err
+		}
+		paths = append(paths, []string{ChildrenKey})
+		values = append(values, s)
+	}
+	k.i.UnIndent()
+
+	return k.buildSet(paths, values), nil
+}
+
+func (k *KDLVisitor) visitNodes(nodes []*Node) (string, error) {
+	if len(nodes) == 0 {
+		return "[]", nil
+	}
+
+	e := []string{}
+	for _, node := range nodes {
+		k.i.Indent()
+		s, err := k.visitNode(node)
+		if err != nil {
+			return "", err
+		}
+
+		e = append(e, k.i.IndentValue()+s)
+		k.i.UnIndent()
+	}
+
+	if k.options.SortIterators.SortList {
+		slices.Sort(e)
+	}
+
+	return "[\n" + strings.Join(e, "\n") + "\n" + k.i.IndentValue() + "]", nil
+}
+
+func (k *KDLVisitor) Visit() (string, error) {
+	out, err := k.visitNodes(k.nodes)
+	if err != nil {
+		return "", err
+	}
+
+	return k.limiter.Done(out)
+}
+
+func ToNix(data string, options *converter.ConverterOptions) (string, error) {
+	nodes, err := Parse(data, options.DuplicateKeys.Policy)
+	if err != nil {
+		return "", err
+	}
+
+	out, err := NewKDLVisitor(nodes, options).Visit()
+	if err != nil {
+		return "", err
+	}
+
+	return nix.Layout(out, &options.NixOutput), nil
+}
@@ -0,0 +1,336 @@
+package kdl
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/orivej/go-nix/nix/parser"
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/nix"
+	"github.com/theobori/nix-converter/internal/common"
+)
+
+type NixVisitor struct {
+	i       common.Indentation
+	p       *parser.Parser
+	errors  *nix.Collector
+	node    *parser.Node
+	options *converter.ConverterOptions
+}
+
+func NewNixVisitor(p *parser.Parser, node *parser.Node, options *converter.ConverterOptions) *NixVisitor {
+	return &NixVisitor{
+		i:       *common.NewIndentationUnit(options.Indentation.Unit()),
+		node:    node,
+		p:       p,
+		errors:  nix.NewCollector(p, node, options),
+		options: options,
+	}
+}
+
+func unwrapParens(node *parser.Node) *parser.Node {
+	for node.Type == parser.ParensNode {
+		node = node.Nodes[0]
+	}
+
+	return node
+}
+
+func (n *NixVisitor) keys(tree *nix.AttrNode) []string {
+	if !n.options.SortIterators.SortHashmap {
+		return tree.Order
+	}
+
+	keys := slices.Clone(tree.Order)
+	slices.Sort(keys)
+
+	return keys
+}
+
+func (n *NixVisitor) visitNumber(node *parser.Node, sign string) (string, error) {
+	switch node.Type {
+	case parser.IntNode:
+		s, err := nix.VisitInt(n.p, node)
+		if err != nil {
+			return "", err
+		}
+		return sign + s, nil
+	case parser.FloatNode, parser.ApplyNode:
+		var (
+			f   float64
+			err error
+		)
+		if node.Type == parser.FloatNode {
+			f, err = nix.VisitFloatRaw(n.p, node)
+		} else {
+			f, err = nix.VisitApplyRaw(n.p, node)
+		}
+		if err != nil {
+			return "", err
+		}
+		return sign + nix.FormatFloat(f), nil
+	case parser.ParensNode:
+		return n.visitNumber(node.Nodes[0], sign)
+	default:
+		return "", fmt.Errorf("expected a number, got %s", node.Type.String())
+	}
+}
+
+func (n *NixVisitor) visitScalar(node *parser.Node) (string, error) {
+	return nix.Visit(n.errors, node, n.visitScalarNode, "null")
+}
+
+func (n *NixVisitor) visitScalarNode(node *parser.Node) (string, error) {
+	switch node.Type {
+	case parser.StringNode, parser.IStringNode:
+		s, err := nix.VisitStringValue(n.p, node)
+		if err != nil {
+			return "", err
+		}
+		return Quote(s), nil
+	case parser.IDNode:
+		id, err := nix.VisitID(n.p, node)
+		if err != nil {
+			return "", err
+		}
+		switch id {
+		case "true", "false", "null":
+			return id, nil
+		default:
+			return "", fmt.Errorf("unsupported identifier: %s", id)
+		}
+	case parser.IntNode, parser.FloatNode, parser.ApplyNode:
+		return n.visitNumber(node, "")
+	case parser.OpNode + 57378: // The negative unary operator
+		return n.visitNumber(node.Nodes[0], "-")
+	case parser.ParensNode:
+		return n.visitScalar(node.Nodes[0])
+	default:
+		return "", fmt.Errorf("expected a KDL value, got %s", node.Type.String())
+	}
+}
+
+func (n *NixVisitor) visitString(attr *nix.AttrNode, key string) (string, error) {
+	if attr.Value == nil {
+		return "", fmt.Errorf("'%s' must be a string", key)
+	}
+
+	s, err := nix.VisitStringValue(n.p, attr.Value)
+	if err != nil {
+		return "", converter.AtKey(err, key)
+	}
+
+	return s, nil
+}
+
+func (n *NixVisitor) visitValue(attr *nix.AttrNode) (string, error) {
+	tree, isSet, err := attr.Set(n.p)
+	if err != nil {
+		return "", err
+	}
+
+	if !isSet {
+		return n.visitScalar(attr.Value)
+	}
+
+	_, hasType := tree.Children[TypeKey]
+	value, hasValue := tree.Children[ValueKey]
+	if !hasType || !hasValue || len(tree.Order) != 2 || value.Value == nil {
+		return "", fmt.Errorf("a value with a type annotation must be a set with the keys '%s' and '%s'", TypeKey, ValueKey)
+	}
+
+	t, err := n.visitString(tree.Children[TypeKey], TypeKey)
+	if err != nil {
+		return "", err
+	}
+
+	s, err := n.visitScalar(value.Value)
+	if err != nil {
+		return "", err
+	}
+
+	return "(" + MakeIdentifier(t) + ")" + s, nil
+}
+
+func (n *NixVisitor) visitArgs(attr *nix.AttrNode) ([]string, error) {
+	if attr.Value == nil || unwrapParens(attr.Value).Type != parser.ListNode {
+		return nil, fmt.Errorf("'%s' must be a list", ArgsKey)
+	}
+
+	args := []string{}
+	for index, child := range unwrapParens(attr.Value).Nodes {
+		s, err := n.visitValue(nix.NewAttrNode(child, n.options.DuplicateKeys.Policy))
+		if err != nil {
+			return nil, converter.AtIndex(err, index)
+		}
+
+		args = append(args, s)
+	}
+
+	if n.options.SortIterators.SortList {
+		slices.Sort(args)
+	}
+
+	return args, nil
+}
+
+func (n *NixVisitor) visitProps(attr *nix.AttrNode) ([]string, error) {
+	tree, isSet, err := attr.Set(n.p)
+	if err != nil {
+		return nil, err
+	}
+	if !isSet {
+		return nil, fmt.Errorf("'%s' must be a set", PropsKey)
+	}
+
+	props := []string{}
+	for _, k := range n.keys(tree) {
+		s, err := n.visitValue(tree.Children[k])
+		if err != nil {
+			return nil, converter.AtKey(err, k)
+		}
+
+		props = append(props, MakeIdentifier(k)+"="+s)
+	}
+
+	return props, nil
+}
+
+func (n *NixVisitor) visitNode(node *parser.Node) (string, error) {
+	if err := n.errors.Enter(node); err != nil {
+		return "", err
+	}
+	defer n.errors.Leave()
+
+	node = unwrapParens(node)
+	if node.Type != parser.SetNode {
+		return "", fmt.Errorf("a node must be a set, got %s", node.Type.String())
+	}
+
+	tree, err := n.errors.BuildAttrTree(node, n.options.DuplicateKeys.Policy)
+	if err != nil {
+		return "", err
+	}
+
+	for _, k := range tree.Order {
+		switch k {
+		case NameKey, TypeKey, ArgsKey, PropsKey, ChildrenKey:
+		default:
+			return "", fmt.Errorf("unknown node key '%s'", k)
+		}
+	}
+
+	attr, exists := tree.Children[NameKey]
+	if !exists {
+		return "", fmt.Errorf("a node needs a '%s'", NameKey)
+	}
+
+	name, err := n.visitString(attr, NameKey)
+	if err != nil {
+		return "", err
+	}
+
+	line := MakeIdentifier(name)
+
+	if attr, exists := tree.Children[TypeKey]; exists {
+		t, err := n.visitString(attr, TypeKey)
+		if err != nil {
+			return "", err
+		}
+		line = "(" + MakeIdentifier(t) + ")" + line
+	}
+
+	entries := []string{}
+	if attr, exists := tree.Children[ArgsKey]; exists {
+		args, err := n.visitArgs(attr)
+		if err != nil {
+			return "", converter.AtKey(err, ArgsKey)
+		}
+		entries = append(entries, args...)
+	}
+
+	if attr, exists := tree.Children[PropsKey]; exists {
+		props, err := n.visitProps(attr)
+		if err != nil {
+			return "", converter.AtKey(err, PropsKey)
+		}
+		entries = append(entries, props...)
+	}
+
+	if len(entries) > 0 {
+		line += " " + strings.Join(entries, " ")
+	}
+
+	if attr, exists := tree.Children[ChildrenKey]; exists {
+		if attr.Value == nil || unwrapParens(attr.Value).Type != parser.ListNode {
+			return "", fmt.Errorf("node '%s': '%s' must be a list", name, ChildrenKey)
+		}
+
+		n.i.Indent()
+		children, err := n.visitNodes(unwrapParens(attr.Value))
+		n.i.UnIndent()
+		if err != nil {
+			return "", err
+		}
+
+		if len(children) == 0 {
+			line += " {}"
+		} else {
+			line += " {\n" + strings.Join(children, "\n") + "\n" + n.i.IndentValue() + "}"
+		}
+	}
+
+	return n.i.IndentValue() + line, nil
+}
+
+func (n *NixVisitor) visitNodes(node *parser.Node) ([]string, error) {
+	e := []string{}
+	for index, child := range node.Nodes {
+		s, err := n.visitNode(child)
+		if err != nil {
+			return nil, converter.AtIndex(err, index)
+		}
+
+		e = append(e, s)
+	}
+
+	if n.options.SortIterators.SortList {
+		slices.Sort(e)
+	}
+
+	return e, nil
+}
+
+func (n *NixVisitor) visitRoot() (string, error) {
+	node := unwrapParens(n.node)
+	if node.Type != parser.ListNode {
+		return "", fmt.Errorf("the Nix expression must be a list of nodes")
+	}
+
+	nodes, err := n.visitNodes(node)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join(nodes, "\n"), nil
+}
+
+func (n *NixVisitor) Visit() (string, error) {
+	v, err := n.visitRoot()
+	return n.errors.Done(v, err)
+}
+
+func FromNix(data string, options *converter.ConverterOptions) (string, error) {
+	p, err := nix.Parse(data)
+	if err != nil {
+		return "", err
+	}
+
+	out, err := NewNixVisitor(p, p.Result, options).Visit()
+	if err != nil {
+		return "", err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,148 @@
+package converter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/theobori/nix-converter/converter/options"
+)
+
+// Names of the limits of options.Limits
+const (
+	LimitDepth           = "depth"
+	LimitNodes           = "nodes"
+	LimitOutputBytes     = "output bytes"
+	LimitAliasExpansions = "alias expansions"
+)
+
+// LimitError is the error of a conversion exceeding one of its limits
+type LimitError struct {
+	// One of the Limit constants
+	Limit string
+	Max   int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("the %s limit of %d is exceeded", e.Limit, e.Max)
+}
+
+// Interrupted returns true when err stops a conversion because of a limit or
+// of its context, such an error is never collected with the other errors
+func Interrupted(err error) bool {
+	var limit *LimitError
+
+	return errors.As(err, &limit) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// Limiter enforces the limits and the context of a single conversion, its
+// first error stops the conversion
+type Limiter struct {
+	ctx     context.Context
+	limits  options.Limits
+	depth   int
+	nodes   int
+	output  int
+	aliases int
+	err     error
+}
+
+func NewLimiter(options *ConverterOptions) *Limiter {
+	return &Limiter{
+		ctx:    options.Context(),
+		limits: options.Limits,
+	}
+}
+
+// Returns the error of the limit when the value exceeds it
+func (l *Limiter) check(name string, value int, max int) error {
+	if l.err == nil && max > 0 && value > max {
+		l.err = &LimitError{name, max}
+	}
+
+	return l.err
+}
+
+// Enter counts a value and its nesting, a successful Enter is followed by
+// Leave once the value is visited
+func (l *Limiter) Enter() error {
+	if l.err != nil {
+		return l.err
+	}
+
+	if err := l.ctx.Err(); err != nil {
+		l.err = err
+		return err
+	}
+
+	l.nodes++
+	if err := l.check(LimitNodes, l.nodes, l.limits.MaxNodes); err != nil {
+		return err
+	}
+
+	if err := l.check(LimitDepth, l.depth+1, l.limits.MaxDepth); err != nil {
+		return err
+	}
+	l.depth++
+
+	return nil
+}
+
+// Nest checks the depth of a value nested n levels below the current one,
+// before it is visited
+func (l *Limiter) Nest(n int) error {
+	return l.check(LimitDepth, l.depth+n, l.limits.MaxDepth)
+}
+
+func (l *Limiter) Leave() {
+	l.depth--
+}
+
+// Alias counts an alias referencing an anchor
+func (l *Limiter) Alias() error {
+	l.aliases++
+	return l.check(LimitAliasExpansions, l.aliases, l.limits.MaxAliasExpansions)
+}
+
+// Output counts n bytes of output
+func (l *Limiter) Output(n int) error {
+	l.output += n
+	return l.check(LimitOutputBytes, l.output, l.limits.MaxOutputBytes)
+}
+
+// Err returns the first error of the conversion
+func (l *Limiter) Err() error {
+	return l.err
+}
+
+// Done returns the output of a conversion, or its first error
+func (l *Limiter) Done(out string) (string, error) {
+	if l.err != nil {
+		return "", l.err
+	}
+
+	if err := l.Output(len(out)); err != nil {
+		return "", err
+	}
+
+	return out, nil
+}
+
+type limitWriter struct {
+	l *Limiter
+	w io.Writer
+}
+
+func (w *limitWriter) Write(p []byte) (int, error) {
+	if err := w.l.Output(len(p)); err != nil {
+		return 0, err
+	}
+
+	return w.w.Write(p)
+}
+
+// Writer returns a writer counting its bytes as output
+func (l *Limiter) Writer(w io.Writer) io.Writer {
+	return &limitWriter{l, w}
+}
@@ -0,0 +1,31 @@
+package msgpack
+
+import "github.com/theobori/nix-converter/converter"
+
+type MsgpackConverter struct {
+	data    string
+	options *converter.ConverterOptions
+}
+
+func NewMsgpackConverter(data string, options *converter.ConverterOptions) *MsgpackConverter {
+	return &MsgpackConverter{
+		data,
+		options,
+	}
+}
+
+func (c *MsgpackConverter) FromNix() (string, error) {
+	return FromNix(c.data, c.options)
+}
+
+func (c *MsgpackConverter) FromNixBytes() ([]byte, error) {
+	return FromNixBytes(c.data, c.options)
+}
+
+func (c *MsgpackConverter) ToNix() (string, error) {
+	return ToNix(c.data, c.options)
+}
+
+func (c *MsgpackConverter) Type() string {
+	return "msgpack"
+}
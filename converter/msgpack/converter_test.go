@@ -0,0 +1,117 @@
+package msgpack
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/options"
+	"github.com/theobori/nix-converter/internal/common"
+)
+
+func mustDecodeHex(t *testing.T, s string) string {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(b)
+}
+
+var msgpackHexStrings = []string{
+	"7f",
+	"e0",
+	"d080",
+	"cd0100",
+	"d1ff38",
+	"cf7fffffffffffffff",
+	"cb3ff8000000000000",
+	"c3",
+	"c0",
+	"a568656c6c6f",
+	"c403010203",
+	"d6ff00000000",
+	"c703010a0b0c",
+	"93010293a0c2c3",
+	"82a161a162a1630c",
+	"8101a36f6e65",
+}
+
+var nixStrings = []string{
+	`{
+  name = "server";
+  port = 8080;
+  ratio = 0.1;
+  offset = -1.5;
+  enabled = true;
+  parent = null;
+  hosts = [
+    "a"
+    "b"
+  ];
+  certificate = {
+    _type = "bytes";
+    value = "AAECAw==";
+  };
+  timestamp = {
+    _type = "ext";
+    ext = -1;
+    value = "UUtKsA==";
+  };
+  empty = {};
+}`,
+}
+
+func TestMsgpackToNix(t *testing.T) {
+	t.Parallel()
+	msgpackStrings := []string{}
+	for _, s := range msgpackHexStrings {
+		msgpackStrings = append(msgpackStrings, mustDecodeHex(t, s))
+	}
+
+	common.TestHelperToNixStrings(t, msgpackStrings, FromNix, ToNix, converter.NewDefaultConverterOptions())
+}
+
+func TestMsgpackFromNix(t *testing.T) {
+	t.Parallel()
+	options := converter.ConverterOptions{
+		SortIterators: *options.NewDefaultSortIterators(),
+		UnsafeKeys:    true,
+	}
+
+	common.TestHelperFromNixStrings(t, nixStrings, FromNix, ToNix, &options)
+}
+
+func TestMsgpackErrors(t *testing.T) {
+	t.Parallel()
+	msgpackInputs := []string{
+		"",
+		"c1",
+		"cd01",
+		"cfffffffffffffffff",
+		"a2ff",
+		"a1c3a0",
+		"0101",
+		"cbfff0000000000000",
+		"dc0001",
+		"81a16101a16102",
+	}
+
+	for _, input := range msgpackInputs {
+		if _, err := ToNix(mustDecodeHex(t, input), converter.NewDefaultConverterOptions()); err == nil {
+			t.Errorf("ToNix(%q) expected an error", input)
+		}
+	}
+
+	nixInputs := []string{
+		`{ a = { _type = "tag"; tag = 1; value = 1; }; }`,
+		`{ a = { _type = "undefined"; }; }`,
+		`{ a = { _type = "ext"; ext = 128; value = ""; }; }`,
+	}
+
+	for _, input := range nixInputs {
+		if _, err := FromNix(input, converter.NewDefaultConverterOptions()); err == nil {
+			t.Errorf("FromNix(%q) expected an error", input)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package msgpack
+
+import (
+	"fmt"
+	"math"
+	"unicode/utf8"
+
+	"github.com/theobori/nix-converter/converter/value"
+)
+
+type decoder struct {
+	data   []byte
+	offset int
+}
+
+func (d *decoder) read(size uint64) ([]byte, error) {
+	if size > uint64(len(d.data)-d.offset) {
+		return nil, fmt.Errorf("unexpected end of data at offset %d", d.offset)
+	}
+
+	b := d.data[d.offset : d.offset+int(size)]
+	d.offset += int(size)
+
+	return b, nil
+}
+
+// Reads a big endian unsigned integer of 1, 2, 4 or 8 bytes
+func (d *decoder) uint(size uint64) (uint64, error) {
+	b, err := d.read(size)
+	if err != nil {
+		return 0, err
+	}
+
+	var u uint64
+	for _, c := range b {
+		u = u<<8 | uint64(c)
+	}
+
+	return u, nil
+}
+
+func (d *decoder) string(length uint64) (any, error) {
+	b, err := d.read(length)
+	if err != nil {
+		return nil, err
+	}
+	if !utf8.Valid(b) {
+		return nil, fmt.Errorf("invalid UTF-8 string at offset %d", d.offset)
+	}
+
+	return string(b), nil
+}
+
+func (d *decoder) array(length uint64) (any, error) {
+	array := []any{}
+	for i := uint64(0); i < length; i++ {
+		item, err := d.item()
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, item)
+	}
+
+	return array, nil
+}
+
+func (d *decoder) dict(length uint64) (any, error) {
+	m := value.Map{}
+	for i := uint64(0); i < length; i++ {
+		k, err := d.item()
+		if err != nil {
+			return nil, err
+		}
+		switch k.(type) {
+		case []any, value.Map:
+			return nil, fmt.Errorf("unsupported map key type %T at offset %d", k, d.offset)
+		}
+
+		v, err := d.item()
+		if err != nil {
+			return nil, err
+		}
+		m = append(m, value.Pair{Key: k, Value: v})
+	}
+
+	return m, nil
+}
+
+func (d *decoder) ext(length uint64) (any, error) {
+	t, err := d.read(1)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := d.read(length)
+	if err != nil {
+		return nil, err
+	}
+
+	return value.Ext{Type: int8(t[0]), Data: append([]byte{}, b...)}, nil
+}
+
+// Reads the length of a variable size item, stored on 1, 2 or 4 bytes
+func (d *decoder) length(code byte, first byte) (uint64, error) {
+	return d.uint(1 << (code - first))
+}
+
+func (d *decoder) item() (any, error) {
+	b, err := d.read(1)
+	if err != nil {
+		return nil, err
+	}
+	code := b[0]
+
+	switch {
+	case code <= 0x7F:
+		return int64(code), nil
+	case code <= 0x8F:
+		return d.dict(uint64(code & 0x0F))
+	case code <= 0x9F:
+		return d.array(uint64(code & 0x0F))
+	case code <= 0xBF:
+		return d.string(uint64(code & 0x1F))
+	case code >= 0xE0:
+		return int64(int8(code)), nil
+	}
+
+	switch code {
+	case 0xC0:
+		return nil, nil
+	case 0xC2, 0xC3:
+		return code == 0xC3, nil
+	case 0xC4, 0xC5, 0xC6:
+		length, err := d.length(code, 0xC4)
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.read(length)
+		if err != nil {
+			return nil, err
+		}
+		return value.Bytes(append([]byte{}, b...)), nil
+	case 0xC7, 0xC8, 0xC9:
+		length, err := d.length(code, 0xC7)
+		if err != nil {
+			return nil, err
+		}
+		return d.ext(length)
+	case 0xCA:
+		u, err := d.uint(4)
+		if err != nil {
+			return nil, err
+		}
+		return float64(math.Float32frombits(uint32(u))), nil
+	case 0xCB:
+		u, err := d.uint(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(u), nil
+	case 0xCC, 0xCD, 0xCE, 0xCF:
+		return d.uint(1 << (code - 0xCC))
+	case 0xD0, 0xD1, 0xD2, 0xD3:
+		size := uint64(1) << (code - 0xD0)
+		u, err := d.uint(size)
+		if err != nil {
+			return nil, err
+		}
+		// Sign extension of the integer
+		shift := 64 - 8*size
+		return int64(u<<shift) >> shift, nil
+	case 0xD4, 0xD5, 0xD6, 0xD7, 0xD8:
+		return d.ext(1 << (code - 0xD4))
+	case 0xD9, 0xDA, 0xDB:
+		length, err := d.length(code, 0xD9)
+		if err != nil {
+			return nil, err
+		}
+		return d.string(length)
+	case 0xDC, 0xDD:
+		length, err := d.uint(2 << (code - 0xDC))
+		if err != nil {
+			return nil, err
+		}
+		return d.array(length)
+	case 0xDE, 0xDF:
+		length, err := d.uint(2 << (code - 0xDE))
+		if err != nil {
+			return nil, err
+		}
+		return d.dict(length)
+	default:
+		return nil, fmt.Errorf("invalid type 0x%02x at offset %d", code, d.offset-1)
+	}
+}
+
+// Decode reads a single MessagePack object
+func Decode(data []byte) (any, error) {
+	d := decoder{data: data}
+
+	v, err := d.item()
+	if err != nil {
+		return nil, err
+	}
+
+	if d.offset != len(data) {
+		return nil, fmt.Errorf("unexpected data after the object at offset %d", d.offset)
+	}
+
+	return v, nil
+}
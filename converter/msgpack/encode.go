@@ -0,0 +1,147 @@
+package msgpack
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/theobori/nix-converter/converter/value"
+)
+
+type encoder struct {
+	b []byte
+}
+
+// Writes the smallest header for a length, using the fix code when possible
+func (e *encoder) length(length int, fix byte, fixMax int, code8 byte, code16 byte, code32 byte) error {
+	switch {
+	case length <= fixMax:
+		e.b = append(e.b, fix|byte(length))
+	case code8 != 0 && length <= math.MaxUint8:
+		e.b = append(e.b, code8, byte(length))
+	case length <= math.MaxUint16:
+		e.b = binary.BigEndian.AppendUint16(append(e.b, code16), uint16(length))
+	case uint64(length) <= math.MaxUint32:
+		e.b = binary.BigEndian.AppendUint32(append(e.b, code32), uint32(length))
+	default:
+		return fmt.Errorf("length %d is too large", length)
+	}
+
+	return nil
+}
+
+func (e *encoder) int(i int64) {
+	switch {
+	case i >= 0:
+		e.uint(uint64(i))
+	case i >= -32:
+		e.b = append(e.b, byte(int8(i)))
+	case i >= math.MinInt8:
+		e.b = append(e.b, 0xD0, byte(int8(i)))
+	case i >= math.MinInt16:
+		e.b = binary.BigEndian.AppendUint16(append(e.b, 0xD1), uint16(int16(i)))
+	case i >= math.MinInt32:
+		e.b = binary.BigEndian.AppendUint32(append(e.b, 0xD2), uint32(int32(i)))
+	default:
+		e.b = binary.BigEndian.AppendUint64(append(e.b, 0xD3), uint64(i))
+	}
+}
+
+func (e *encoder) uint(u uint64) {
+	switch {
+	case u <= 0x7F:
+		e.b = append(e.b, byte(u))
+	case u <= math.MaxUint8:
+		e.b = append(e.b, 0xCC, byte(u))
+	case u <= math.MaxUint16:
+		e.b = binary.BigEndian.AppendUint16(append(e.b, 0xCD), uint16(u))
+	case u <= math.MaxUint32:
+		e.b = binary.BigEndian.AppendUint32(append(e.b, 0xCE), uint32(u))
+	default:
+		e.b = binary.BigEndian.AppendUint64(append(e.b, 0xCF), u)
+	}
+}
+
+func (e *encoder) ext(ext value.Ext) error {
+	fixCodes := map[int]byte{1: 0xD4, 2: 0xD5, 4: 0xD6, 8: 0xD7, 16: 0xD8}
+
+	if code, isFix := fixCodes[len(ext.Data)]; isFix {
+		e.b = append(e.b, code)
+	} else if err := e.length(len(ext.Data), 0, -1, 0xC7, 0xC8, 0xC9); err != nil {
+		return err
+	}
+
+	e.b = append(e.b, byte(ext.Type))
+	e.b = append(e.b, ext.Data...)
+
+	return nil
+}
+
+func (e *encoder) item(v any) error {
+	switch v := v.(type) {
+	case nil:
+		e.b = append(e.b, 0xC0)
+	case bool:
+		if v {
+			e.b = append(e.b, 0xC3)
+		} else {
+			e.b = append(e.b, 0xC2)
+		}
+	case int64:
+		e.int(v)
+	case uint64:
+		e.uint(v)
+	case float64:
+		e.b = binary.BigEndian.AppendUint64(append(e.b, 0xCB), math.Float64bits(v))
+	case string:
+		if err := e.length(len(v), 0xA0, 31, 0xD9, 0xDA, 0xDB); err != nil {
+			return err
+		}
+		e.b = append(e.b, v...)
+	case value.Bytes:
+		if err := e.length(len(v), 0, -1, 0xC4, 0xC5, 0xC6); err != nil {
+			return err
+		}
+		e.b = append(e.b, v...)
+	case []any:
+		if err := e.length(len(v), 0x90, 15, 0, 0xDC, 0xDD); err != nil {
+			return err
+		}
+		for _, item := range v {
+			if err := e.item(item); err != nil {
+				return err
+			}
+		}
+	case value.Map:
+		if err := e.length(len(v), 0x80, 15, 0, 0xDE, 0xDF); err != nil {
+			return err
+		}
+		for _, pair := range v {
+			if err := e.item(pair.Key); err != nil {
+				return err
+			}
+			if err := e.item(pair.Value); err != nil {
+				return err
+			}
+		}
+	case value.Ext:
+		return e.ext(v)
+	case value.Tag, value.Simple, value.Undefined:
+		return fmt.Errorf("CBOR tags, simple values and undefined are not supported by MessagePack")
+	default:
+		return fmt.Errorf("unsupported value type: %T", v)
+	}
+
+	return nil
+}
+
+// Encode writes a value as a MessagePack object
+func Encode(v any) ([]byte, error) {
+	e := encoder{}
+
+	if err := e.item(v); err != nil {
+		return nil, err
+	}
+
+	return e.b, nil
+}
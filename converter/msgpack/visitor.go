@@ -0,0 +1,33 @@
+package msgpack
+
+import (
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/value"
+)
+
+func ToNix(data string, options *converter.ConverterOptions) (string, error) {
+	v, err := Decode([]byte(data))
+	if err != nil {
+		return "", err
+	}
+
+	return value.ToNix(v, options)
+}
+
+func FromNixBytes(data string, options *converter.ConverterOptions) ([]byte, error) {
+	v, err := value.FromNix(data, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return Encode(v)
+}
+
+func FromNix(data string, options *converter.ConverterOptions) (string, error) {
+	b, err := FromNixBytes(data, options)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
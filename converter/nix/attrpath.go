@@ -0,0 +1,51 @@
+package nix
+
+import (
+	"strings"
+
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/options"
+)
+
+// CollapsePath follows the chain of sets with a single key starting at the
+// value of key, when the options allow it. single returns the key and the
+// value of a set with a single key, ok being false for any other value. The
+// returned path holds at least key and the value is the last one of the chain.
+func CollapsePath[T any](key string, value T, single func(T) (string, T, bool), o *options.NixOutput) ([]string, T) {
+	path := []string{key}
+	if !o.CollapsePaths {
+		return path, value
+	}
+
+	for o.CollapseDepth <= 0 || len(path) < o.CollapseDepth {
+		k, v, ok := single(value)
+		if !ok {
+			break
+		}
+
+		path = append(path, k)
+		value = v
+	}
+
+	return path, value
+}
+
+// MakeAttrPath writes an attribute path, every key being made safe
+func MakeAttrPath(path []string, forceUnsafe bool) string {
+	names := make([]string, len(path))
+	for i, k := range path {
+		names[i] = MakeNameSafe(k, forceUnsafe)
+	}
+
+	return strings.Join(names, ".")
+}
+
+// AtPath prepends the keys of an attribute path to the path of err, when err
+// is not nil
+func AtPath(err error, path []string) error {
+	for i := len(path) - 1; i >= 0; i-- {
+		err = converter.AtKey(err, path[i])
+	}
+
+	return err
+}
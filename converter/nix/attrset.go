@@ -0,0 +1,266 @@
+package nix
+
+import (
+	"fmt"
+
+	"github.com/orivej/go-nix/nix/parser"
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/options"
+)
+
+// AttrNode is an attribute of a set, it holds either a value node or the
+// attributes created by attribute paths like a.b.c, in their source order
+type AttrNode struct {
+	Value    *parser.Node
+	Children map[string]*AttrNode
+	Order    []string
+	// Duplicate keys policy of the set, inherited by the nested sets
+	policy string
+	// Collects the errors of the bindings when every error is wanted, it
+	// may be nil
+	errors *Collector
+}
+
+func newAttrNode(policy string, errors *Collector) *AttrNode {
+	return &AttrNode{Children: make(map[string]*AttrNode), policy: policy, errors: errors}
+}
+
+// NewAttrNode returns the attribute of a value node, its sets follow a policy
+// of options.DuplicateKeys
+func NewAttrNode(value *parser.Node, policy string) *AttrNode {
+	a := newAttrNode(policy, nil)
+	a.Value = value
+
+	return a
+}
+
+// VisitStringValue returns the value of a string or indented string node
+func VisitStringValue(p *parser.Parser, node *parser.Node) (string, error) {
+	switch node.Type {
+	case parser.StringNode, parser.IStringNode:
+		if len(node.Nodes) == 0 || len(node.Nodes[0].Tokens) == 0 {
+			return "", nil
+		}
+		// The lexer may split an escaped interpolation into several texts
+		raw := ""
+		for _, child := range node.Nodes {
+			if child.Type != parser.TextNode {
+				return "", fmt.Errorf("string interpolation is not supported")
+			}
+			raw += p.TokenString(child.Tokens[0])
+		}
+
+		if node.Type == parser.IStringNode {
+			return ProcessIndentedString(raw), nil
+		}
+
+		return UnescapeString(raw), nil
+	case parser.ParensNode:
+		return VisitStringValue(p, node.Nodes[0])
+	default:
+		return "", fmt.Errorf("expected a string, got %s", node.Type.String())
+	}
+}
+
+func visitAttrKey(p *parser.Parser, node *parser.Node) (string, error) {
+	switch node.Type {
+	case parser.IDNode:
+		return VisitID(p, node)
+	case parser.StringNode:
+		return VisitStringValue(p, node)
+	default:
+		return "", fmt.Errorf("unsupported key node type: %s", node.Type.String())
+	}
+}
+
+// BuildAttrTree merges the attribute paths of a set node into a tree. Like
+// Nix, a set value and the attribute paths going through it are merged, the
+// other keys defined twice follow a policy of options.DuplicateKeys.
+func BuildAttrTree(p *parser.Parser, node *parser.Node, policy string) (*AttrNode, error) {
+	return buildAttrTree(p, node, policy, nil)
+}
+
+// BuildAttrTree is nix.BuildAttrTree, the errors of the bindings are
+// collected when every error is wanted, the bindings are then skipped so the
+// other ones are checked
+func (c *Collector) BuildAttrTree(node *parser.Node, policy string) (*AttrNode, error) {
+	return buildAttrTree(c.p, node, policy, c)
+}
+
+func buildAttrTree(p *parser.Parser, node *parser.Node, policy string, errors *Collector) (*AttrNode, error) {
+	root := newAttrNode(policy, errors)
+	if err := root.merge(p, node); err != nil {
+		return nil, err
+	}
+
+	return root, nil
+}
+
+// Returns the set node of a value, if it is a set
+func setValue(node *parser.Node) (*parser.Node, bool) {
+	if node == nil {
+		return nil, false
+	}
+
+	for node.Type == parser.ParensNode {
+		node = node.Nodes[0]
+	}
+
+	return node, node.Type == parser.SetNode
+}
+
+// Locates the error of a binding of a set node at the node, it is collected
+// when every error is wanted, nil being returned so the binding is skipped
+func (a *AttrNode) bindingError(p *parser.Parser, set *parser.Node, node *parser.Node, err error) error {
+	err = NodeError(p, node, err)
+	if a.errors == nil {
+		return err
+	}
+
+	// The path of the error leads to the set, the keys of the binding are
+	// not part of it
+	_, err = Collect(a.errors, set, struct{}{}, err, struct{}{})
+	return err
+}
+
+// Returns the keys of an attribute path node
+func attrPathKeys(p *parser.Parser, node *parser.Node) ([]string, error) {
+	keys := []string{}
+	for _, kNode := range node.Nodes {
+		k, err := visitAttrKey(p, kNode)
+		if err != nil {
+			return nil, NodeError(p, kNode, err)
+		}
+		keys = append(keys, k)
+	}
+
+	return keys, nil
+}
+
+// Adds the bindings of a set node to the attributes
+func (a *AttrNode) merge(p *parser.Parser, node *parser.Node) error {
+	for _, child := range node.Nodes {
+		if child.Type != parser.BindNode {
+			if err := a.bindingError(p, node, child, fmt.Errorf("unsupported binding: %s", child.Type.String())); err != nil {
+				return err
+			}
+			continue
+		}
+
+		keys, err := attrPathKeys(p, child.Nodes[0])
+		if err == nil {
+			err = a.insert(p, keys, child.Nodes[1])
+		}
+		if err != nil {
+			if err := a.bindingError(p, node, child, err); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Replaces a set value by its attributes, so attributes can be added to them
+func (a *AttrNode) expand(p *parser.Parser) error {
+	node, isSet := setValue(a.Value)
+	if !isSet {
+		return nil
+	}
+
+	a.Value = nil
+	return a.merge(p, node)
+}
+
+// Returns true if the attribute is a set, either written with attribute
+// paths or as a set value
+func (a *AttrNode) isSet() bool {
+	_, isSet := setValue(a.Value)
+	return a.Value == nil || isSet
+}
+
+// Adds the value of an attribute path
+func (a *AttrNode) insert(p *parser.Parser, keys []string, value *parser.Node) error {
+	k := keys[0]
+
+	child, exists := a.Children[k]
+	if !exists {
+		child = newAttrNode(a.policy, a.errors)
+		a.Children[k] = child
+		a.Order = append(a.Order, k)
+
+		if len(keys) == 1 {
+			child.Value = value
+			return nil
+		}
+
+		return child.insert(p, keys[1:], value)
+	}
+
+	// The path goes through the attribute, it must be a set
+	if len(keys) > 1 {
+		if !child.isSet() {
+			switch a.policy {
+			case options.DuplicateKeysFirstWins:
+				return nil
+			case options.DuplicateKeysLastWins, options.DuplicateKeysDeepMerge:
+				*child = *newAttrNode(a.policy, a.errors)
+			default:
+				return fmt.Errorf("the attribute '%s' is already defined as a value", k)
+			}
+		}
+
+		if err := child.expand(p); err != nil {
+			return err
+		}
+
+		return converter.AtKey(child.insert(p, keys[1:], value), k)
+	}
+
+	set, isSet := setValue(value)
+	canMerge := isSet && child.isSet() && (child.Value == nil || a.policy == options.DuplicateKeysDeepMerge)
+
+	switch {
+	case canMerge:
+		// An empty set adds nothing
+		if len(set.Nodes) == 0 {
+			return nil
+		}
+		if err := child.expand(p); err != nil {
+			return err
+		}
+		return converter.AtKey(child.merge(p, set), k)
+	case a.policy == options.DuplicateKeysFirstWins:
+		return nil
+	case a.policy == options.DuplicateKeysLastWins, a.policy == options.DuplicateKeysDeepMerge:
+		*child = *newAttrNode(a.policy, a.errors)
+		child.Value = value
+		return nil
+	default:
+		return fmt.Errorf("the attribute '%s' is already defined", k)
+	}
+}
+
+// Set returns the attributes of the node if it is a set, either written
+// with attribute paths or as a set value
+func (a *AttrNode) Set(p *parser.Parser) (*AttrNode, bool, error) {
+	if a.Value == nil {
+		return a, true, nil
+	}
+
+	value := a.Value
+	for value.Type == parser.ParensNode {
+		value = value.Nodes[0]
+	}
+
+	if value.Type != parser.SetNode {
+		return nil, false, nil
+	}
+
+	tree, err := buildAttrTree(p, value, a.policy, a.errors)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return tree, true, nil
+}
@@ -0,0 +1,256 @@
+package nix
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/orivej/go-nix/nix/parser"
+	"github.com/theobori/nix-converter/converter"
+)
+
+// Collector records the errors of a visitor when every error of the input is
+// wanted, the visitor then goes on with a placeholder value. It also enforces
+// the limits and the context of the conversion.
+type Collector struct {
+	p       *parser.Parser
+	root    *parser.Node
+	enabled bool
+	errors  converter.Errors
+	limiter *converter.Limiter
+	// The parents of the nodes and the lines of the input, indexed on the
+	// first error
+	parents map[*parser.Node]parentNode
+	lines   converter.Lines
+}
+
+func NewCollector(p *parser.Parser, root *parser.Node, options *converter.ConverterOptions) *Collector {
+	return &Collector{
+		p:       p,
+		root:    root,
+		enabled: options.AllErrors,
+		limiter: converter.NewLimiter(options),
+	}
+}
+
+// Visit visits a node within the limits of the conversion. The visitors of
+// the converters visit every node with it, so an error is located at the
+// innermost node that fails. When every error is wanted, it is collected like
+// with Collect and the placeholder stands for the node, the other nodes being
+// visited.
+func Visit[T any](c *Collector, node *parser.Node, visit func(node *parser.Node) (T, error), placeholder T) (T, error) {
+	if err := c.Enter(node); err != nil {
+		return placeholder, err
+	}
+	defer c.Leave()
+
+	v, err := visit(node)
+	return Collect(c, node, v, err, placeholder)
+}
+
+// Enter counts a node within the limits of the conversion, a successful
+// Enter is followed by Leave once the node is visited. The node locates the
+// error, it may be nil for the sets of expanded attribute paths.
+func (c *Collector) Enter(node *parser.Node) error {
+	err := c.limiter.Enter()
+	if node == nil {
+		return err
+	}
+
+	return c.nodeError(node, err)
+}
+
+// nodeError is NodeError, with the lines of the input indexed once
+func (c *Collector) nodeError(node *parser.Node, err error) error {
+	var errs converter.Errors
+	if err == nil || errors.As(err, &errs) {
+		return err
+	}
+
+	e := converter.AsError(err)
+	if e.Line != 0 {
+		return e
+	}
+
+	token, ok := firstToken(node)
+	if !ok {
+		return e
+	}
+
+	offset, data, ok := tokenOffset(c.p, token)
+	if !ok {
+		return e
+	}
+
+	if c.lines == nil {
+		c.lines = converter.NewLines(data)
+	}
+	e.Line, e.Column = c.lines.LineColumn(offset)
+
+	return e
+}
+
+func (c *Collector) Leave() {
+	c.limiter.Leave()
+}
+
+// Collect locates err at a node. When every error is wanted, err is recorded
+// with the path of the node and the placeholder is returned instead, unless
+// the conversion is interrupted.
+func Collect[T any](c *Collector, node *parser.Node, v T, err error, placeholder T) (T, error) {
+	err = c.nodeError(node, err)
+	if err == nil || !c.enabled || converter.Interrupted(err) {
+		return v, err
+	}
+
+	// The errors of a nested visitor are already collected
+	var errs converter.Errors
+	if !errors.As(err, &errs) {
+		errs = converter.Errors{converter.AsError(err)}
+	}
+
+	segments := c.nodePath(node)
+	for _, e := range errs {
+		for i := len(segments) - 1; i >= 0; i-- {
+			segments[i].at(e)
+		}
+	}
+	c.errors = append(c.errors, errs...)
+
+	return placeholder, nil
+}
+
+// Err returns err, with the recorded errors when every error is wanted. The
+// errors are sorted by their position in the input, the ones without a
+// position come last.
+func (c *Collector) Err(err error) error {
+	if !c.enabled || converter.Interrupted(err) {
+		return err
+	}
+
+	errs := c.errors
+	var nested converter.Errors
+	if errors.As(err, &nested) {
+		errs = append(errs, nested...)
+	} else if err != nil {
+		errs = append(errs, converter.AsError(err))
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool {
+		return positionLess(errs[i], errs[j])
+	})
+
+	return errs
+}
+
+// Returns whether an error is before another in the input
+func positionLess(a *converter.Error, b *converter.Error) bool {
+	switch {
+	case a.Line == 0 || b.Line == 0:
+		return a.Line != 0 && b.Line == 0
+	case a.Line != b.Line:
+		return a.Line < b.Line
+	default:
+		return a.Column < b.Column
+	}
+}
+
+// Done returns the output of a visitor, or its errors like Err, the output
+// must fit in the output limit of the conversion
+func (c *Collector) Done(out string, err error) (string, error) {
+	if err := c.Err(err); err != nil {
+		return "", err
+	}
+
+	return c.limiter.Done(out)
+}
+
+// A key of a set or an index of a list, on the way to a node
+type segment struct {
+	key   string
+	index int
+	isKey bool
+}
+
+func (s segment) at(e *converter.Error) {
+	if s.isKey {
+		converter.AtKey(e, s.key)
+	} else {
+		converter.AtIndex(e, s.index)
+	}
+}
+
+// The parent of a node, with the keys or the index leading from the parent to
+// the node
+type parentNode struct {
+	node     *parser.Node
+	segments []segment
+}
+
+// Returns the keys and the indexes leading from the root to a node. The
+// parents of every node are indexed once, on the first error.
+func (c *Collector) nodePath(target *parser.Node) []segment {
+	if c.parents == nil {
+		c.parents = map[*parser.Node]parentNode{}
+		indexParents(c.p, c.root, c.parents)
+	}
+
+	segments := []segment{}
+	for node := target; node != c.root; {
+		parent, ok := c.parents[node]
+		if !ok {
+			break
+		}
+		segments = append(append([]segment{}, parent.segments...), segments...)
+		node = parent.node
+	}
+
+	return segments
+}
+
+// Records the parent of every node below a node
+func indexParents(p *parser.Parser, node *parser.Node, parents map[*parser.Node]parentNode) {
+	// The nodes are indexed from a stack, deep inputs would exhaust the
+	// goroutine stack otherwise
+	stack := []*parser.Node{node}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		switch node.Type {
+		case parser.SetNode, parser.RecSetNode:
+			for _, bind := range node.Nodes {
+				if bind.Type != parser.BindNode {
+					parents[bind] = parentNode{node: node}
+					stack = append(stack, bind)
+					continue
+				}
+
+				keys := []segment{}
+				for _, kNode := range bind.Nodes[0].Nodes {
+					k, err := visitAttrKey(p, kNode)
+					if err != nil {
+						k = "?"
+					}
+					keys = append(keys, segment{key: k, isKey: true})
+				}
+
+				parents[bind.Nodes[1]] = parentNode{node: node, segments: keys}
+				stack = append(stack, bind.Nodes[1])
+			}
+		case parser.ListNode:
+			for index, child := range node.Nodes {
+				parents[child] = parentNode{node: node, segments: []segment{{index: index}}}
+				stack = append(stack, child)
+			}
+		default:
+			for _, child := range node.Nodes {
+				parents[child] = parentNode{node: node}
+				stack = append(stack, child)
+			}
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package nix
+
+import "github.com/theobori/nix-converter/converter"
+
+// NixConverter reformats Nix data, both directions write canonical Nix
+type NixConverter struct {
+	data    string
+	options *converter.ConverterOptions
+}
+
+func NewNixConverter(data string, options *converter.ConverterOptions) *NixConverter {
+	return &NixConverter{
+		data,
+		options,
+	}
+}
+
+func (n *NixConverter) FromNix() (string, error) {
+	return Format(n.data, n.options)
+}
+
+func (n *NixConverter) ToNix() (string, error) {
+	return Format(n.data, n.options)
+}
+
+func (n *NixConverter) Type() string {
+	return "nix"
+}
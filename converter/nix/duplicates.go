@@ -0,0 +1,59 @@
+package nix
+
+import (
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/options"
+)
+
+// Entry is an entry of a map of the input, with its line and column when
+// they are known
+type Entry[T any] struct {
+	Key    string
+	Value  T
+	Line   int
+	Column int
+}
+
+// MergeEntries applies a duplicate keys policy to the entries of a map, a key
+// keeps the position of its first entry. For deep merges, merge returns the
+// merge of two values and true when both of them are maps, it may be nil.
+func MergeEntries[T any](entries []Entry[T], policy string, merge func(a T, b T) (T, bool, error)) ([]Entry[T], error) {
+	out := make([]Entry[T], 0, len(entries))
+	index := make(map[string]int, len(entries))
+
+	for _, e := range entries {
+		i, exists := index[e.Key]
+		if !exists {
+			index[e.Key] = len(out)
+			out = append(out, e)
+			continue
+		}
+
+		switch policy {
+		case options.DuplicateKeysFirstWins:
+		case options.DuplicateKeysLastWins:
+			out[i].Value = e.Value
+		case options.DuplicateKeysDeepMerge:
+			if merge != nil {
+				v, merged, err := merge(out[i].Value, e.Value)
+				if err != nil {
+					return nil, converter.AtKey(err, e.Key)
+				}
+				if merged {
+					out[i].Value = v
+					continue
+				}
+			}
+			out[i].Value = e.Value
+		default:
+			return nil, DuplicateKeyError(e.Line, e.Column, e.Key)
+		}
+	}
+
+	return out, nil
+}
+
+// DuplicateKeyError returns the error of a key defined twice
+func DuplicateKeyError(line int, column int, key string) error {
+	return converter.Errorf(line, column, "the key '%s' is defined twice", key)
+}
@@ -0,0 +1,452 @@
+package nix
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/internal/common"
+)
+
+// KeyValue is an entry of an OrderedMap
+type KeyValue struct {
+	Key   string
+	Value any
+}
+
+// OrderedMap is a map written as a set with the order of its entries
+type OrderedMap []KeyValue
+
+var (
+	orderedMapType = reflect.TypeFor[OrderedMap]()
+	numberType     = reflect.TypeFor[json.Number]()
+)
+
+// Encoder writes Go values as Nix expressions. The structs are written like
+// with Marshal, the keys of the Go maps are sorted and the OrderedMap keep
+// their order. The converter options give the indentation, the sort of the
+// sets and lists, the quoting of the keys, the collapsed attribute paths, the
+// layout and the limits.
+type Encoder struct {
+	w               io.Writer
+	i               common.Indentation
+	limiter         *converter.Limiter
+	nonFiniteIsNull bool
+	options         *converter.ConverterOptions
+	// The structs, maps and slices being encoded, a value holding itself
+	// would be written forever
+	visiting map[visit]bool
+}
+
+// A struct, a map or a slice being encoded, by its address
+type visit struct {
+	address uintptr
+	length  int
+	t       reflect.Type
+}
+
+func NewEncoder(w io.Writer, options *converter.ConverterOptions) *Encoder {
+	return &Encoder{
+		w:       w,
+		i:       *common.NewIndentationUnit(options.NixIndentUnit()),
+		options: options,
+	}
+}
+
+// SetNonFiniteNull writes the NaN and infinite floats as null instead of
+// failing, as they have no Nix representation
+func (e *Encoder) SetNonFiniteNull(on bool) {
+	e.nonFiniteIsNull = on
+}
+
+// Reports whether v is omitted by the omitempty option, like encoding/json
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
+
+// Returns the set key of a map key
+func keyString(k reflect.Value) (string, error) {
+	switch k.Kind() {
+	case reflect.String:
+		return k.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	default:
+		return "", fmt.Errorf("unsupported map key type: %s", k.Type().String())
+	}
+}
+
+// Returns the encoding.TextMarshaler of v, if its type implements it
+func textMarshaler(v reflect.Value) (encoding.TextMarshaler, bool) {
+	if !v.IsValid() || !v.CanInterface() {
+		return nil, false
+	}
+
+	if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil() {
+		return nil, false
+	}
+
+	t, ok := v.Interface().(encoding.TextMarshaler)
+	return t, ok
+}
+
+// Follows the pointers and the interfaces of v, returning the value when it
+// is written as a set
+func setOf(v reflect.Value) (reflect.Value, bool) {
+	v = elem(v)
+	if _, ok := textMarshaler(v); ok || !v.IsValid() {
+		return v, false
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		return v, true
+	case reflect.Map, reflect.Slice:
+		return v, !v.IsNil() && (v.Kind() == reflect.Map || v.Type() == orderedMapType)
+	default:
+		return v, false
+	}
+}
+
+// Returns the keys and the values of a struct, a map or an OrderedMap
+func (e *Encoder) entries(v reflect.Value) ([]string, []reflect.Value, error) {
+	keys := []string{}
+	values := []reflect.Value{}
+
+	switch {
+	case v.Kind() == reflect.Struct:
+		for _, f := range structFields(v.Type()) {
+			// Like encoding/json, the fields of nil embedded pointers are
+			// skipped
+			fv, err := v.FieldByIndexErr(f.index)
+			if err != nil || f.omitEmpty && isEmptyValue(fv) {
+				continue
+			}
+
+			keys = append(keys, f.name)
+			values = append(values, fv)
+		}
+	case v.Type() == orderedMapType:
+		seen := map[string]bool{}
+		for _, kv := range v.Interface().(OrderedMap) {
+			if seen[kv.Key] {
+				return nil, nil, fmt.Errorf("the key '%s' is defined twice", kv.Key)
+			}
+			seen[kv.Key] = true
+
+			keys = append(keys, kv.Key)
+			values = append(values, reflect.ValueOf(kv.Value))
+		}
+	default:
+		// The order of a Go map has no meaning, its keys are always sorted
+		entries := map[string]reflect.Value{}
+		for iter := v.MapRange(); iter.Next(); {
+			k, err := keyString(iter.Key())
+			if err != nil {
+				return nil, nil, err
+			}
+
+			entries[k] = iter.Value()
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+
+		for _, k := range keys {
+			values = append(values, entries[k])
+		}
+
+		return keys, values, nil
+	}
+
+	if !e.options.SortIterators.SortHashmap {
+		return keys, values, nil
+	}
+
+	indexes := make([]int, len(keys))
+	for index := range indexes {
+		indexes[index] = index
+	}
+	slices.SortStableFunc(indexes, func(a int, b int) int {
+		return strings.Compare(keys[a], keys[b])
+	})
+
+	sortedKeys := make([]string, len(keys))
+	sortedValues := make([]reflect.Value, len(keys))
+	for index, i := range indexes {
+		sortedKeys[index] = keys[i]
+		sortedValues[index] = values[i]
+	}
+
+	return sortedKeys, sortedValues, nil
+}
+
+// Returns the single key of a set and its value
+func (e *Encoder) single(v reflect.Value) (string, reflect.Value, bool) {
+	v, isSet := setOf(v)
+	if !isSet {
+		return "", v, false
+	}
+
+	keys, values, err := e.entries(v)
+	if err != nil || len(keys) != 1 {
+		return "", v, false
+	}
+
+	return keys[0], values[0], true
+}
+
+func (e *Encoder) encodeSet(v reflect.Value) (string, error) {
+	keys, values, err := e.entries(v)
+	if err != nil {
+		return "", err
+	}
+
+	if len(keys) == 0 {
+		return "{}", nil
+	}
+
+	lines := []string{}
+
+	e.i.Indent()
+	for index, k := range keys {
+		// A chain of sets holding itself is not collapsed, encode returns
+		// the cycle
+		var chain map[visit]bool
+		single := func(v reflect.Value) (string, reflect.Value, bool) {
+			if key, ok := visitOf(elem(v)); ok {
+				if e.visiting[key] || chain[key] {
+					return "", v, false
+				}
+				if chain == nil {
+					chain = map[visit]bool{}
+				}
+				chain[key] = true
+			}
+			return e.single(v)
+		}
+
+		path, value := CollapsePath(k, values[index], single, &e.options.NixOutput)
+
+		s, err := e.encode(value)
+		if err != nil {
+			return "", AtPath(err, path)
+		}
+
+		lines = append(lines, e.i.IndentValue()+MakeAttrPath(path, e.options.UnsafeKeys)+" = "+s+";")
+	}
+	e.i.UnIndent()
+
+	return "{\n" + strings.Join(lines, "\n") + "\n" + e.i.IndentValue() + "}", nil
+}
+
+func (e *Encoder) encodeList(v reflect.Value) (string, error) {
+	if v.Len() == 0 {
+		return "[]", nil
+	}
+
+	lines := []string{}
+
+	e.i.Indent()
+	for index := 0; index < v.Len(); index++ {
+		s, err := e.encode(v.Index(index))
+		if err != nil {
+			return "", converter.AtIndex(err, index)
+		}
+
+		lines = append(lines, e.i.IndentValue()+MakeElementSafe(s))
+	}
+	e.i.UnIndent()
+
+	if e.options.SortIterators.SortList {
+		slices.Sort(lines)
+	}
+
+	return "[\n" + strings.Join(lines, "\n") + "\n" + e.i.IndentValue() + "]", nil
+}
+
+func (e *Encoder) encodeString(s string) string {
+	if strings.Contains(s, "\n") {
+		return common.MakeIndentedString(s, e.i.IndentValue(), e.i.Unit())
+	}
+
+	return common.MakeStringSafe(s)
+}
+
+func (e *Encoder) encodeInt(i int64) (string, error) {
+	return FormatInt(i), nil
+}
+
+func (e *Encoder) encodeFloat(f float64) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		if e.nonFiniteIsNull {
+			return "null", nil
+		}
+		return "", fmt.Errorf("the float %v has no Nix representation", f)
+	}
+
+	return FormatFloat(f), nil
+}
+
+// A json.Number is written as an integer when it is one
+func (e *Encoder) encodeNumber(n json.Number) (string, error) {
+	if i, err := n.Int64(); err == nil {
+		return e.encodeInt(i)
+	}
+
+	f, err := n.Float64()
+	if err != nil {
+		return "", fmt.Errorf("invalid number '%s'", n)
+	}
+
+	if !strings.ContainsAny(n.String(), ".eE") {
+		return "", fmt.Errorf("integer %s is out of range", n)
+	}
+
+	return e.encodeFloat(f)
+}
+
+// Follows the pointers and the interfaces of v up to the value they hold, a
+// nil one or an encoding.TextMarshaler
+func elem(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if _, ok := textMarshaler(v); ok || v.IsNil() {
+			break
+		}
+		v = v.Elem()
+	}
+
+	return v
+}
+
+// Returns the visit of a value that may hold itself, a struct reached through
+// a pointer, a map or a slice
+func visitOf(v reflect.Value) (visit, bool) {
+	switch v.Kind() {
+	case reflect.Struct:
+		if v.CanAddr() {
+			return visit{address: v.UnsafeAddr(), t: v.Type()}, true
+		}
+	case reflect.Map, reflect.Slice:
+		if !v.IsNil() {
+			return visit{address: v.Pointer(), length: v.Len(), t: v.Type()}, true
+		}
+	}
+
+	return visit{}, false
+}
+
+func (e *Encoder) encode(v reflect.Value) (string, error) {
+	// The pointers and the interfaces are not values of their own, they do
+	// not count in the limits
+	v = elem(v)
+
+	if err := e.limiter.Enter(); err != nil {
+		return "", err
+	}
+	defer e.limiter.Leave()
+
+	// Like encoding/json, the cycles are errors
+	if key, ok := visitOf(v); ok {
+		if e.visiting[key] {
+			return "", fmt.Errorf("encountered a cycle via %s", v.Type().String())
+		}
+		e.visiting[key] = true
+		defer delete(e.visiting, key)
+	}
+
+	if t, ok := textMarshaler(v); ok {
+		b, err := t.MarshalText()
+		if err != nil {
+			return "", err
+		}
+		return e.encodeString(string(b)), nil
+	}
+
+	if !v.IsValid() {
+		return "null", nil
+	}
+
+	if v.Type() == numberType {
+		return e.encodeNumber(json.Number(v.String()))
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return "null", nil
+	case reflect.Struct:
+		return e.encodeSet(v)
+	case reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			return "null", nil
+		}
+		if v.Kind() == reflect.Map || v.Type() == orderedMapType {
+			return e.encodeSet(v)
+		}
+		return e.encodeList(v)
+	case reflect.Array:
+		return e.encodeList(v)
+	case reflect.String:
+		return e.encodeString(v.String()), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return e.encodeInt(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > math.MaxInt64 {
+			return "", fmt.Errorf("integer %d is out of range", v.Uint())
+		}
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32:
+		// The shortest text of a float32 is kept
+		f, _ := strconv.ParseFloat(strconv.FormatFloat(v.Float(), 'g', -1, 32), 64)
+		return e.encodeFloat(f)
+	case reflect.Float64:
+		return e.encodeFloat(v.Float())
+	default:
+		return "", fmt.Errorf("unsupported Go type: %s", v.Type().String())
+	}
+}
+
+// Encode writes a Go value as a Nix expression, within the limits of the
+// conversion
+func (e *Encoder) Encode(v any) error {
+	e.limiter = converter.NewLimiter(e.options)
+	e.visiting = map[visit]bool{}
+
+	out, err := e.encode(reflect.ValueOf(v))
+	if err != nil {
+		return err
+	}
+
+	out, err = e.limiter.Done(out)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(e.w, Layout(out, &e.options.NixOutput))
+	return err
+}
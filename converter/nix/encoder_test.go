@@ -0,0 +1,183 @@
+package nix
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/theobori/nix-converter/converter"
+)
+
+type encoderService struct {
+	Port    int      `nix:"port"`
+	Enable  bool     `nix:"enable"`
+	Aliases []string `nix:"aliases,omitempty"`
+}
+
+func TestEncoder(t *testing.T) {
+	t.Parallel()
+
+	value := OrderedMap{
+		{"services", OrderedMap{
+			{"nginx", encoderService{Port: 80, Enable: true}},
+		}},
+		{"count", json.Number("12")},
+		{"ratio", json.Number("1e2")},
+		{"started", time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)},
+		{"a key", []any{json.Number("-3"), "b", nil}},
+	}
+
+	tests := []struct {
+		name     string
+		options  func(o *converter.ConverterOptions)
+		expected string
+	}{
+		{
+			"default",
+			func(o *converter.ConverterOptions) {},
+			`{
+  "services" = {
+    "nginx" = {
+      "port" = 80;
+      "enable" = true;
+    };
+  };
+  "count" = 12;
+  "ratio" = 100.0;
+  "started" = "2024-01-01T12:00:00Z";
+  "a key" = [
+    (-3)
+    "b"
+    null
+  ];
+}`,
+		},
+		{
+			"sorted with tabs and unquoted keys",
+			func(o *converter.ConverterOptions) {
+				o.UnsafeKeys = true
+				o.SortIterators.SortHashmap = true
+				o.SortIterators.SortList = true
+				o.Indentation.UseTabs = true
+			},
+			"{\n\t\"a key\" = [\n\t\t\"b\"\n\t\t(-3)\n\t\tnull\n\t];\n\tcount = 12;\n\tratio = 100.0;\n" +
+				"\tservices = {\n\t\tnginx = {\n\t\t\tenable = true;\n\t\t\tport = 80;\n\t\t};\n\t};\n" +
+				"\tstarted = \"2024-01-01T12:00:00Z\";\n}",
+		},
+		{
+			"collapsed paths",
+			func(o *converter.ConverterOptions) {
+				o.UnsafeKeys = true
+				o.NixOutput.CollapsePaths = true
+				o.NixOutput.CollapseDepth = 2
+			},
+			`{
+  services.nginx = {
+    port = 80;
+    enable = true;
+  };
+  count = 12;
+  ratio = 100.0;
+  started = "2024-01-01T12:00:00Z";
+  "a key" = [
+    (-3)
+    "b"
+    null
+  ];
+}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := converter.NewDefaultConverterOptions()
+			tt.options(options)
+
+			var b strings.Builder
+			if err := NewEncoder(&b, options).Encode(value); err != nil {
+				t.Fatal(err)
+			}
+
+			if b.String() != tt.expected {
+				t.Fatalf("expected\n%s\ngot\n%s", tt.expected, b.String())
+			}
+		})
+	}
+}
+
+func TestEncoderError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		v        any
+		expected string
+	}{
+		{OrderedMap{{"a", 1}, {"a", 2}}, "the key 'a' is defined twice"},
+		{map[string]any{"a": json.Number("99999999999999999999")}, "integer 99999999999999999999 is out of range (at a)"},
+		{[]any{json.Number("x")}, "invalid number 'x' (at [0])"},
+	}
+
+	for _, tt := range tests {
+		err := NewEncoder(&strings.Builder{}, converter.NewDefaultConverterOptions()).Encode(tt.v)
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("expected the error %q, got %v", tt.expected, err)
+		}
+	}
+}
+
+func TestEncoderNonFiniteNull(t *testing.T) {
+	t.Parallel()
+
+	var b strings.Builder
+	encoder := NewEncoder(&b, converter.NewDefaultConverterOptions())
+	encoder.SetNonFiniteNull(true)
+
+	if err := encoder.Encode([]float32{float32(1) / 3, float32(math.Inf(1))}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "[\n  0.33333334\n  null\n]"
+	if b.String() != expected {
+		t.Fatalf("expected %s, got %s", expected, b.String())
+	}
+}
+
+type encoderCycle struct {
+	Next *encoderCycle
+	List []any
+}
+
+// Like encoding/json, a value holding itself is an error instead of being
+// written forever
+func TestEncoderCycle(t *testing.T) {
+	t.Parallel()
+
+	c := &encoderCycle{}
+	c.Next = c
+
+	list := []any{1, nil}
+	list[1] = list
+
+	m := map[string]any{}
+	m["a"] = map[string]any{"b": m}
+
+	for _, v := range []any{c, &encoderCycle{List: list}, m} {
+		for _, collapse := range []bool{false, true} {
+			o := converter.NewDefaultConverterOptions()
+			o.NixOutput.CollapsePaths = collapse
+
+			err := NewEncoder(&strings.Builder{}, o).Encode(v)
+			if err == nil || !strings.Contains(err.Error(), "encountered a cycle via") {
+				t.Fatalf("expected a cycle error, got %v", err)
+			}
+		}
+	}
+
+	// A value written twice without holding itself is not a cycle
+	shared := &encoderCycle{}
+	if err := NewEncoder(&strings.Builder{}, converter.NewDefaultConverterOptions()).Encode([]any{shared, shared}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,106 @@
+package nix
+
+import (
+	"reflect"
+	"strings"
+)
+
+// TagName is the struct tag of the Nix attribute names, written like the
+// json tag: `nix:"name,omitempty"`, or `nix:"-"` to skip the field
+const TagName = "nix"
+
+// A struct field written as a Nix attribute
+type field struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+// Returns the fields of a struct type in their declaration order. Like
+// encoding/json, the fields of the embedded structs and struct pointers
+// without a tag are promoted, the fields of the outer struct taking
+// precedence.
+func structFields(t reflect.Type) []field {
+	return typeFields(t, map[reflect.Type]bool{})
+}
+
+// Returns the fields of a struct type, the types embedding it are skipped as
+// they would be promoted forever
+func typeFields(t reflect.Type, visited map[reflect.Type]bool) []field {
+	visited[t] = true
+	defer delete(visited, t)
+
+	fields := []field{}
+	names := map[string]bool{}
+	embedded := []field{}
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+
+		tag := sf.Tag.Get(TagName)
+		if tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		omitEmpty := false
+		for _, opt := range strings.Split(opts, ",") {
+			omitEmpty = omitEmpty || opt == "omitempty"
+		}
+
+		ft := sf.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+
+		if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			if visited[ft] {
+				continue
+			}
+
+			for _, f := range typeFields(ft, visited) {
+				f.index = append([]int{i}, f.index...)
+				embedded = append(embedded, f)
+			}
+			continue
+		}
+
+		if !sf.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+
+		fields = append(fields, field{name: name, index: []int{i}, omitEmpty: omitEmpty})
+		names[name] = true
+	}
+
+	for _, f := range embedded {
+		if !names[f.name] {
+			fields = append(fields, f)
+			names[f.name] = true
+		}
+	}
+
+	return fields
+}
+
+// Returns the field of an attribute name, an exact match is preferred to a
+// case-insensitive one
+func fieldByName(fields []field, name string) (field, bool) {
+	for _, f := range fields {
+		if f.name == name {
+			return f, true
+		}
+	}
+
+	for _, f := range fields {
+		if strings.EqualFold(f.name, name) {
+			return f, true
+		}
+	}
+
+	return field{}, false
+}
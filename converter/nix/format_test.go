@@ -0,0 +1,160 @@
+package nix
+
+import (
+	"testing"
+
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/internal/common"
+)
+
+var formattedStrings = []string{
+	`{
+  a = {
+    b = [
+      1
+      (-2.5)
+      "c"
+      ''
+        multi
+        line
+      ''
+      {}
+      []
+    ];
+  };
+  "with space" = null;
+}`,
+	`[]`,
+	`"string"`,
+}
+
+func TestFormatIdempotent(t *testing.T) {
+	t.Parallel()
+	options := converter.NewDefaultConverterOptions()
+	options.UnsafeKeys = true
+
+	common.TestHelperFromNixStrings(t, formattedStrings, Format, Format, options)
+}
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name          string
+		input         string
+		collapsePaths bool
+		want          string
+	}{
+		{
+			name:  "expand attribute paths",
+			input: `{ services.nginx.enable = true; services.nginx.port = 80; x = -1; }`,
+			want: `{
+  services = {
+    nginx = {
+      enable = true;
+      port = 80;
+    };
+  };
+  x = -1;
+}`,
+		},
+		{
+			name:          "collapse attribute paths",
+			input:         `{ a = { b = { c = 1; d = 2; }; }; e = { f = [ { g = { h = {}; }; } ]; }; }`,
+			collapsePaths: true,
+			want: `{
+  a.b = {
+    c = 1;
+    d = 2;
+  };
+  e.f = [
+    {
+      g.h = {};
+    }
+  ];
+}`,
+		},
+		{
+			name:  "normalize strings and numbers",
+			input: `{ a = ''single''; b = "x\ny"; c = 1.0e3; d = ("q\"uote"); }`,
+			want: `{
+  a = "single";
+  b = ''
+    x
+    y'';
+  c = 1000.0;
+  d = "q\"uote";
+}`,
+		},
+		{
+			name:  "keep the let bindings",
+			input: `let x = { b = 1; }; y = [ x ]; in { a = x; c = [ y (let z = 2; in z) ]; }`,
+			want: `let
+  x = {
+    b = 1;
+  };
+  y = [
+    x
+  ];
+in
+{
+  a = x;
+  c = [
+    y
+    (let
+      z = 2;
+    in
+    z)
+  ];
+}`,
+		},
+		{
+			name:  "write the recursive sets as sets",
+			input: `rec { a.b = 1; c = rec { d = 2; }; }`,
+			want: `{
+  a = {
+    b = 1;
+  };
+  c = {
+    d = 2;
+  };
+}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			options := converter.NewDefaultConverterOptions()
+			options.UnsafeKeys = true
+			options.NixOutput.CollapsePaths = tt.collapsePaths
+
+			output, err := Format(tt.input, options)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if output != tt.want {
+				t.Errorf("Format() = \n%v, want \n%v", output, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	t.Parallel()
+	inputs := []string{
+		`{ a = x; }`,
+		`{ inherit a; }`,
+		`rec { a = 1; b = a; }`,
+		`let a = 1; in b`,
+		`{ a = "${b}"; }`,
+		`[ (-"a") ]`,
+		`x: x`,
+	}
+
+	for _, input := range inputs {
+		if _, err := Format(input, converter.NewDefaultConverterOptions()); err == nil {
+			t.Errorf("Format(%q) expected an error", input)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package nix
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/theobori/nix-converter/converter/options"
+)
+
+// NixfmtLineWidth is the line width of nixfmt
+const NixfmtLineWidth = 100
+
+// Lexer states of the bytes of Nix code
+const (
+	stateCode = iota
+	stateDoubleQuoted
+	stateIndented
+	stateComment
+)
+
+// Returns the lexer state of every byte of Nix code, and the state at the
+// end, so the content of strings and comments is never mistaken for code
+func lex(s string) ([]int, int) {
+	states := make([]int, len(s))
+	state := stateCode
+
+	// Marks the bytes from i to i+n with the current state
+	mark := func(i int, n int) int {
+		for j := i; j < min(i+n, len(s)); j++ {
+			states[j] = state
+		}
+		return i + n - 1
+	}
+
+	for i := 0; i < len(s); i++ {
+		switch state {
+		case stateCode:
+			switch {
+			case s[i] == '"':
+				state = stateDoubleQuoted
+			case s[i] == '#':
+				state = stateComment
+			case strings.HasPrefix(s[i:], "''"):
+				state = stateIndented
+				i = mark(i, 2)
+				continue
+			}
+			states[i] = state
+		case stateDoubleQuoted:
+			switch s[i] {
+			case '\\':
+				i = mark(i, 2)
+			case '"':
+				states[i] = state
+				state = stateCode
+			default:
+				states[i] = state
+			}
+		case stateIndented:
+			switch {
+			case strings.HasPrefix(s[i:], "'''"), strings.HasPrefix(s[i:], "''$"):
+				i = mark(i, 3)
+			case strings.HasPrefix(s[i:], "''\\"):
+				i = mark(i, 4)
+			case strings.HasPrefix(s[i:], "''"):
+				i = mark(i, 2)
+				state = stateCode
+			default:
+				states[i] = state
+			}
+		case stateComment:
+			if s[i] == '\n' {
+				state = stateCode
+			}
+			states[i] = state
+		}
+	}
+
+	return states, state
+}
+
+func isInString(state int) bool {
+	return state == stateDoubleQuoted || state == stateIndented
+}
+
+// line is a line of Nix code with the lexer state around it
+type line struct {
+	text string
+	// The line starts inside a multi-line string
+	inString bool
+	// The line ends inside a string or holds a comment
+	opaque bool
+}
+
+func splitLines(s string) []line {
+	states, end := lex(s)
+
+	lines := []line{}
+	start := 0
+	for i := 0; i <= len(s); i++ {
+		if i < len(s) && s[i] != '\n' {
+			continue
+		}
+
+		l := line{
+			text:     s[start:i],
+			inString: start > 0 && isInString(states[start-1]),
+		}
+
+		state := end
+		if i < len(s) {
+			state = states[i]
+		}
+		l.opaque = isInString(state)
+		for j := start; j < i; j++ {
+			if states[j] == stateComment {
+				l.opaque = true
+			}
+		}
+
+		lines = append(lines, l)
+		start = i + 1
+	}
+
+	return lines
+}
+
+func leadingSpaces(s string) int {
+	return len(s) - len(strings.TrimLeft(s, " \t"))
+}
+
+// Reports whether a line opens a list or a set spanning several lines
+func isOpening(l line) (byte, bool) {
+	text := strings.TrimRight(l.text, " ")
+	if l.inString || l.opaque || text == "" {
+		return 0, false
+	}
+
+	switch text[len(text)-1] {
+	case '[':
+		return ']', true
+	case '{':
+		return '}', true
+	default:
+		return 0, false
+	}
+}
+
+// Reports whether a line is a scalar element or a binding to a scalar
+func isScalar(l line) bool {
+	text := strings.TrimSpace(l.text)
+	if l.inString || l.opaque || text == "" {
+		return false
+	}
+
+	if strings.HasPrefix(text, "]") || strings.HasPrefix(text, "}") {
+		return false
+	}
+
+	_, isOpening := isOpening(l)
+
+	return !isOpening && text != "let" && text != "in"
+}
+
+// inline returns the collection opened at lines[start] written on a single
+// line and the index of its closing line, if it only holds scalars
+func inline(lines []line, start int, closing byte, maxElements int) (string, int, bool) {
+	indent := leadingSpaces(lines[start].text)
+
+	elements := []string{}
+	for end := start + 1; end < len(lines); end++ {
+		l := lines[end]
+		text := strings.TrimSpace(l.text)
+
+		if !l.inString && leadingSpaces(l.text) == indent && text != "" && text[0] == closing {
+			if len(elements) == 0 {
+				return "", 0, false
+			}
+
+			s := strings.TrimRight(lines[start].text, " ") + " " + strings.Join(elements, " ") + " " + text
+			return s, end, true
+		}
+
+		if leadingSpaces(l.text) <= indent || !isScalar(l) || maxElements > 0 && len(elements) == maxElements {
+			return "", 0, false
+		}
+
+		elements = append(elements, text)
+	}
+
+	return "", 0, false
+}
+
+// Writes the empty lists and sets like nixfmt, with a space between the
+// brackets
+func spaceEmptyCollections(s string) string {
+	states, _ := lex(s)
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		b.WriteByte(s[i])
+
+		if states[i] == stateCode && i+1 < len(s) && (s[i:i+2] == "{}" || s[i:i+2] == "[]") {
+			b.WriteByte(' ')
+		}
+	}
+
+	return b.String()
+}
+
+// Layout writes the output of the Nix emitters in the style of the options.
+// Lists and sets holding only scalars are written on a single line when it
+// fits the line width. With the nixfmt style, the output is left unchanged
+// by nixfmt (RFC 166) and the line width is at most the nixfmt one: only the
+// collections with a single element may be inlined, as nixfmt always expands
+// the others, sets being bound to a name are never inlined, empty collections
+// are written "{ }" and "[ ]", blank lines are merged and the lines of
+// indented strings holding only whitespace are emptied.
+func Layout(s string, o *options.NixOutput) string {
+	isNixfmt := o.Style == options.NixStyleNixfmt
+	if o.LineWidth <= 0 && !isNixfmt {
+		return s
+	}
+
+	width := o.LineWidth
+	maxElements := 0
+	if isNixfmt {
+		if width <= 0 || width > NixfmtLineWidth {
+			width = NixfmtLineWidth
+		}
+		maxElements = 1
+	}
+
+	lines := splitLines(s)
+	out := make([]string, 0, len(lines))
+
+	for i := 0; i < len(lines); i++ {
+		l := lines[i]
+
+		if isNixfmt && strings.TrimSpace(l.text) == "" {
+			// Consecutive blank lines of code are merged
+			if l.inString || len(out) > 0 && out[len(out)-1] != "" {
+				out = append(out, "")
+			}
+			continue
+		}
+
+		closing, isOpening := isOpening(l)
+		isBoundSet := closing == '}' && strings.TrimSpace(l.text) != "{"
+		if isOpening && width > 0 && !(isNixfmt && isBoundSet) {
+			text, end, ok := inline(lines, i, closing, maxElements)
+			if ok && utf8.RuneCountInString(text) <= width {
+				out = append(out, text)
+				i = end
+				continue
+			}
+		}
+
+		out = append(out, l.text)
+	}
+
+	if isNixfmt {
+		return spaceEmptyCollections(strings.Join(out, "\n"))
+	}
+
+	return strings.Join(out, "\n")
+}
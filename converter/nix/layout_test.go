@@ -0,0 +1,222 @@
+package nix
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/theobori/nix-converter/converter/options"
+)
+
+func TestLayout(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		input string
+		width int
+		style string
+		want  string
+	}{
+		{
+			name: "scalars fitting the width",
+			input: `{
+  a = [
+    1
+    (-2)
+    "x"
+  ];
+  b = {
+    c = null;
+  };
+}`,
+			width: 80,
+			want: `{
+  a = [ 1 (-2) "x" ];
+  b = { c = null; };
+}`,
+		},
+		{
+			name: "too long",
+			input: `{
+  a = [
+    "aaaa"
+    "bbbb"
+  ];
+}`,
+			width: 20,
+			want: `{
+  a = [
+    "aaaa"
+    "bbbb"
+  ];
+}`,
+		},
+		{
+			name: "exact width",
+			input: `[
+  1
+  2
+]`,
+			width: 7,
+			want:  `[ 1 2 ]`,
+		},
+		{
+			name: "nested collections",
+			input: `[
+  {
+    a = 1;
+  }
+  [
+    {}
+  ]
+]`,
+			width: 80,
+			want: `[
+  { a = 1; }
+  [ {} ]
+]`,
+		},
+		{
+			name: "strings and comments",
+			input: `{
+  a = ''
+    b = {
+      c
+    }
+  '';
+  d = {
+    # comment
+    e = 1;
+  };
+  f = [
+    "g {"
+    "h"
+  ];
+}`,
+			width: 80,
+			want: `{
+  a = ''
+    b = {
+      c
+    }
+  '';
+  d = {
+    # comment
+    e = 1;
+  };
+  f = [ "g {" "h" ];
+}`,
+		},
+		{
+			name: "escaped indented strings",
+			input: `{
+  a = [
+    ''
+      '''
+      x = [
+        1
+      ]
+      ''${y}''
+  ];
+}`,
+			width: 80,
+			want: `{
+  a = [
+    ''
+      '''
+      x = [
+        1
+      ]
+      ''${y}''
+  ];
+}`,
+		},
+		{
+			name: "nixfmt",
+			input: `{
+  a = {
+    b = 1;
+  };
+  c = [
+    {
+      d = [
+        2
+      ];
+    }
+    {
+      e = 3;
+      f = 4;
+    }
+  ];
+
+
+  g = [
+    (-5)
+    6
+  ];
+  h = {};
+  i = ''
+    j
+  ` + "    " + `
+    k
+  '';
+}`,
+			style: options.NixStyleNixfmt,
+			want: `{
+  a = {
+    b = 1;
+  };
+  c = [
+    {
+      d = [ 2 ];
+    }
+    {
+      e = 3;
+      f = 4;
+    }
+  ];
+
+  g = [
+    (-5)
+    6
+  ];
+  h = { };
+  i = ''
+    j
+
+    k
+  '';
+}`,
+		},
+		{
+			name: "nixfmt with a wider line",
+			input: `[
+  "` + strings.Repeat("a", 100) + `"
+]`,
+			width: 200,
+			style: options.NixStyleNixfmt,
+			want: `[
+  "` + strings.Repeat("a", 100) + `"
+]`,
+		},
+		{
+			name: "disabled",
+			input: `[
+  1
+]`,
+			want: `[
+  1
+]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			output := Layout(tt.input, &options.NixOutput{Style: tt.style, LineWidth: tt.width})
+
+			if output != tt.want {
+				t.Errorf("Layout() = \n%v, want \n%v", output, tt.want)
+			}
+		})
+	}
+}
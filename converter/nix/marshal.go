@@ -0,0 +1,29 @@
+package nix
+
+import (
+	"strings"
+
+	"github.com/theobori/nix-converter/converter"
+)
+
+// Marshal writes a Go value as a Nix expression. Like encoding/json, the
+// structs are written as sets with the names of their nix tags, the
+// omitempty option skipping the empty values, the map keys are sorted and
+// the encoding.TextMarshaler implementations are written as strings. The keys
+// are only quoted when needed.
+func Marshal(v any) ([]byte, error) {
+	options := converter.NewDefaultConverterOptions()
+	options.UnsafeKeys = true
+
+	return MarshalWithOptions(v, options)
+}
+
+// MarshalWithOptions is Marshal, written with an Encoder following options
+func MarshalWithOptions(v any, options *converter.ConverterOptions) ([]byte, error) {
+	var b strings.Builder
+	if err := NewEncoder(&b, options).Encode(v); err != nil {
+		return nil, err
+	}
+
+	return []byte(b.String()), nil
+}
@@ -0,0 +1,276 @@
+package nix
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/theobori/nix-converter/converter"
+)
+
+type marshalBase struct {
+	ID string `nix:"id"`
+}
+
+type marshalPackage struct {
+	marshalBase
+	Name        string            `nix:"name"`
+	Version     *string           `nix:"version,omitempty"`
+	Outputs     []string          `nix:"outputs,omitempty"`
+	Meta        map[string]any    `nix:"meta"`
+	Env         map[string]string `nix:"env,omitempty"`
+	Priority    int8              `nix:"priority"`
+	Ratio       float64           `nix:"ratio"`
+	Broken      bool              `nix:"broken"`
+	Released    time.Time         `nix:"released"`
+	Description string
+	Skipped     string `nix:"-"`
+	internal    string
+}
+
+func TestUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	input := `{
+  id = "hello";
+  name = "hello";
+  version = "2.12";
+  outputs = [ "out" "man" ];
+  meta.license = "gpl3";
+  meta.platforms = [ "x86_64-linux" ];
+  priority = -5;
+  ratio = 1;
+  broken = false;
+  released = "2024-01-01T00:00:00Z";
+  description = ''
+    A program
+  '';
+  Skipped = "no";
+  unknown = { a = 1; };
+}`
+
+	var pkg marshalPackage
+	if err := Unmarshal([]byte(input), &pkg); err != nil {
+		t.Fatal(err)
+	}
+
+	version := "2.12"
+	expected := marshalPackage{
+		marshalBase: marshalBase{ID: "hello"},
+		Name:        "hello",
+		Version:     &version,
+		Outputs:     []string{"out", "man"},
+		Meta: map[string]any{
+			"license":   "gpl3",
+			"platforms": []any{"x86_64-linux"},
+		},
+		Priority:    -5,
+		Ratio:       1,
+		Released:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Description: "A program\n",
+	}
+
+	if !reflect.DeepEqual(pkg, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, pkg)
+	}
+}
+
+func TestUnmarshalAny(t *testing.T) {
+	t.Parallel()
+
+	var v any
+	if err := Unmarshal([]byte(`{ a = [ 1 (-2.5) true null ]; b.c = "d"; }`), &v); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]any{
+		"a": []any{int64(1), -2.5, true, nil},
+		"b": map[string]any{"c": "d"},
+	}
+
+	if !reflect.DeepEqual(v, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, v)
+	}
+}
+
+func TestUnmarshalError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input    string
+		v        any
+		expected string
+	}{
+		{`{ name = 1; }`, &marshalPackage{}, "cannot unmarshal an integer into a Go value of type string (at name)"},
+		{`{ priority = 128; }`, &marshalPackage{}, "the integer 128 overflows a Go value of type int8 (at priority)"},
+		{`{ outputs = [ "out" { } ]; }`, &marshalPackage{}, "cannot unmarshal a set into a Go value of type string (at outputs[1])"},
+		{`{ released = 1; }`, &marshalPackage{}, "cannot unmarshal an integer into a Go value of type time.Time (at released)"},
+		{`[ (-1) ]`, &[]uint{}, "the integer -1 overflows a Go value of type uint (at [0])"},
+		{`{ a = 1; }`, &map[int]int{}, "the key 'a' is not a Go value of type int"},
+		{`1`, marshalPackage{}, "a non-nil pointer is needed"},
+	}
+
+	for _, tt := range tests {
+		err := Unmarshal([]byte(tt.input), tt.v)
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Fatalf("%s: expected the error %q, got %v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	t.Parallel()
+
+	pkg := marshalPackage{
+		marshalBase: marshalBase{ID: "hello"},
+		Name:        "hello",
+		Meta:        map[string]any{"z": nil, "a-b": []int{1, -2}, "with space": 1.5},
+		Priority:    -5,
+		Ratio:       1,
+		Broken:      true,
+		Released:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Description: "A program\n",
+		Skipped:     "no",
+		internal:    "no",
+	}
+
+	expected := `{
+  name = "hello";
+  meta = {
+    a-b = [
+      1
+      (-2)
+    ];
+    "with space" = 1.5;
+    z = null;
+  };
+  priority = -5;
+  ratio = 1.0;
+  broken = true;
+  released = "2024-01-01T00:00:00Z";
+  Description = ''
+    A program
+  '';
+  id = "hello";
+}`
+
+	b, err := Marshal(pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != expected {
+		t.Fatalf("expected %s, got %s", expected, b)
+	}
+
+	var back marshalPackage
+	if err := Unmarshal(b, &back); err != nil {
+		t.Fatal(err)
+	}
+
+	if back.Name != pkg.Name || back.ID != pkg.ID || back.Description != pkg.Description || !back.Released.Equal(pkg.Released) {
+		t.Fatalf("expected %+v, got %+v", pkg, back)
+	}
+}
+
+type MarshalPointerBase struct {
+	ID string `nix:"id"`
+}
+
+type marshalPointer struct {
+	*MarshalPointerBase
+	Size json.Number `nix:"size"`
+}
+
+// Like encoding/json, the embedded struct pointers are promoted and the
+// json.Number fields are read back
+func TestMarshalEmbeddedPointer(t *testing.T) {
+	t.Parallel()
+
+	v := marshalPointer{MarshalPointerBase: &MarshalPointerBase{ID: "a"}, Size: "1.5"}
+
+	b, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "{\n  size = 1.5;\n  id = \"a\";\n}"; string(b) != expected {
+		t.Fatalf("expected %q, got %q", expected, b)
+	}
+
+	var back marshalPointer
+	if err := Unmarshal(b, &back); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(back, v) {
+		t.Fatalf("expected %+v, got %+v", v, back)
+	}
+
+	// The fields of a nil embedded pointer are skipped
+	b, err = Marshal(marshalPointer{Size: "-2"})
+	if err != nil || string(b) != "{\n  size = -2;\n}" {
+		t.Fatalf("unexpected output %q, %v", b, err)
+	}
+
+	var n struct{ N json.Number }
+	if err := Unmarshal([]byte(`{ N = "12"; }`), &n); err != nil || n.N != "12" {
+		t.Fatalf("expected 12, got %q, %v", n.N, err)
+	}
+
+	if err := Unmarshal([]byte(`{ N = "x"; }`), &n); err == nil {
+		t.Fatal("expected an error for an invalid number")
+	}
+}
+
+func TestMarshalError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		v        any
+		expected string
+	}{
+		{map[string]any{"a": []any{math.NaN()}}, "the float NaN has no Nix representation (at a[0])"},
+		{[]uint64{math.MaxUint64}, "integer 18446744073709551615 is out of range (at [0])"},
+		{map[bool]int{true: 1}, "unsupported map key type: bool"},
+		{func() {}, "unsupported Go type: func()"},
+	}
+
+	for _, tt := range tests {
+		_, err := Marshal(tt.v)
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("expected the error %q, got %v", tt.expected, err)
+		}
+	}
+}
+
+// The smallest integer has no literal, its subtraction is read back
+func TestMarshalSmallestInt(t *testing.T) {
+	t.Parallel()
+
+	data, err := Marshal([]int64{math.MinInt64, -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "[\n  (-9223372036854775807 - 1)\n  (-1)\n]"; string(data) != expected {
+		t.Fatalf("expected %q, got %q", expected, data)
+	}
+
+	var v []int64
+	if err := Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(v, []int64{math.MinInt64, -1}) {
+		t.Fatalf("expected the smallest integer, got %v", v)
+	}
+
+	formatted, err := Format(string(data), converter.NewDefaultConverterOptions())
+	if err != nil || formatted != string(data) {
+		t.Fatalf("expected %q, got %q, %v", data, formatted, err)
+	}
+}
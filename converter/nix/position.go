@@ -0,0 +1,155 @@
+package nix
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/orivej/go-nix/nix/parser"
+	"github.com/theobori/nix-converter/converter"
+)
+
+// Syntax errors of the parser are located by byte offsets, lexer errors by
+// lines and columns
+var (
+	parserOffsetError = regexp.MustCompile(`^\(string\): (\d+)-\d+:'[^']*': (.*)$`)
+	parserLineError   = regexp.MustCompile(`^\(string\):(\d+):(\d+): (.*)$`)
+)
+
+// Returns the offset of a token and the data of the parser. The parser does
+// not export the offsets of its tokens, they are read by reflection.
+func tokenOffset(p *parser.Parser, token int) (int, []byte, bool) {
+	lexResult := reflect.ValueOf(p).Elem().FieldByName("lexResult")
+	if !lexResult.IsValid() || lexResult.IsNil() {
+		return 0, nil, false
+	}
+
+	tokens := lexResult.Elem().FieldByName("tokens")
+	data := lexResult.Elem().FieldByName("data")
+	if !tokens.IsValid() || !data.IsValid() || token < 0 || token >= tokens.Len() {
+		return 0, nil, false
+	}
+
+	return int(tokens.Index(token).FieldByName("pos").Int()), data.Bytes(), true
+}
+
+// Position returns the line and the column of a token, zero when unknown
+func Position(p *parser.Parser, token int) (int, int) {
+	offset, data, ok := tokenOffset(p, token)
+	if !ok {
+		return 0, 0
+	}
+
+	return converter.LineColumn(data, offset)
+}
+
+// Returns the first token of a node or of its descendants
+func firstToken(node *parser.Node) (int, bool) {
+	first, found := 0, false
+	for _, token := range node.Tokens {
+		if !found || token < first {
+			first, found = token, true
+		}
+	}
+
+	for _, child := range node.Nodes {
+		if token, ok := firstToken(child); ok && (!found || token < first) {
+			first, found = token, true
+		}
+	}
+
+	return first, found
+}
+
+// NodePosition returns the line and the column of a node, zero when unknown
+func NodePosition(p *parser.Parser, node *parser.Node) (int, int) {
+	token, ok := firstToken(node)
+	if !ok {
+		return 0, 0
+	}
+
+	return Position(p, token)
+}
+
+// NodeError locates err at a node, unless it is nil or already located
+func NodeError(p *parser.Parser, node *parser.Node, err error) error {
+	var errs converter.Errors
+	if err == nil || errors.As(err, &errs) {
+		return err
+	}
+
+	e := converter.AsError(err)
+	if e.Line == 0 {
+		e.Line, e.Column = NodePosition(p, node)
+	}
+
+	return e
+}
+
+// Parse reads Nix code, its syntax errors are located. The subtractions
+// written by FormatInt for the smallest integer are read as integers.
+func Parse(data string) (p *parser.Parser, err error) {
+	// The parser panics without any expression
+	defer func() {
+		if r := recover(); r != nil {
+			p, err = nil, converter.Errorf(0, 0, "the input has no Nix expression")
+		}
+	}()
+
+	p, err = parser.ParseString(data)
+	if err == nil {
+		foldSmallestInts(p, p.Result)
+		return p, nil
+	}
+
+	message := err.Error()
+	if m := parserOffsetError.FindStringSubmatch(message); m != nil {
+		offset, _ := strconv.Atoi(m[1])
+		line, column := converter.LineColumn([]byte(data), offset)
+		return nil, &converter.Error{Line: line, Column: column, Err: errors.New(m[2])}
+	}
+
+	if m := parserLineError.FindStringSubmatch(message); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		column, _ := strconv.Atoi(m[2])
+		return nil, &converter.Error{Line: line, Column: column, Err: errors.New(m[3])}
+	}
+
+	return nil, errors.New(strings.TrimPrefix(message, "(string): "))
+}
+
+// Returns whether a node is the subtraction written for the smallest integer
+func isSmallestInt(p *parser.Parser, node *parser.Node) bool {
+	if node.Type != parser.OpNode+'-' || len(node.Nodes) != 2 {
+		return false
+	}
+
+	// The negative unary operator
+	negative, one := node.Nodes[0], node.Nodes[1]
+	if negative.Type != parser.OpNode+57378 || negative.Nodes[0].Type != parser.IntNode || one.Type != parser.IntNode {
+		return false
+	}
+
+	return "(-"+p.TokenString(negative.Nodes[0].Tokens[0])+" - "+p.TokenString(one.Tokens[0])+")" == smallestIntExpression
+}
+
+// Turns the subtractions of the smallest integer into integer nodes, their
+// children are kept to locate them
+func foldSmallestInts(p *parser.Parser, node *parser.Node) {
+	// The nodes are walked from a stack, deep inputs would exhaust the
+	// goroutine stack otherwise
+	stack := []*parser.Node{node}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if isSmallestInt(p, node) {
+			node.Type = parser.IntNode
+			continue
+		}
+
+		stack = append(stack, node.Nodes...)
+	}
+}
@@ -1,20 +1,39 @@
 package nix
 
-import "github.com/theobori/nix-converter/internal/common"
+import (
+	"strconv"
+	"strings"
+
+	"github.com/theobori/nix-converter/internal/common"
+)
 
 func MakeNameSafe(s string, forceUnsafe bool) string {
 	if forceUnsafe {
 		// Check some Nix edges case
 		if IsNameUnsafe(s) {
-			return common.MakeStringSafe(s)
+			return quoteName(s)
 		}
 
 		return s
 	}
 
-	return common.MakeStringSafe(s)
+	return quoteName(s)
 }
 
+// Attribute names are always double quoted strings, as indented strings are
+// not allowed there, so newlines are escaped
+func quoteName(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		quoted := common.MakeStringSafe(line)
+		lines[i] = quoted[1 : len(quoted)-1]
+	}
+
+	return "\"" + strings.Join(lines, "\\n") + "\""
+}
+
+// MakeElementSafe wraps the negative numbers of a list in parentheses, like
+// nixfmt, so they are not read as a subtraction
 func MakeElementSafe(s string) string {
 	if IsElementUnsafe(s) {
 		return "(" + s + ")"
@@ -22,3 +41,62 @@ func MakeElementSafe(s string) string {
 
 	return s
 }
+
+// UnescapeString returns the value of a double quoted Nix string from its
+// raw source text, without the surrounding quotes
+func UnescapeString(raw string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '\\' || i == len(raw)-1 {
+			b.WriteByte(raw[i])
+			continue
+		}
+
+		i++
+		switch raw[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		default:
+			b.WriteByte(raw[i])
+		}
+	}
+
+	return b.String()
+}
+
+// The literal of the smallest integer overflows, it is written as a
+// subtraction that Parse folds back into an integer
+const (
+	smallestInt           = "-9223372036854775808"
+	smallestIntExpression = "(-9223372036854775807 - 1)"
+)
+
+// FormatInt writes an integer that is read back as a Nix integer
+func FormatInt(i int64) string {
+	return FormatIntLiteral(strconv.FormatInt(i, 10))
+}
+
+// FormatIntLiteral writes the decimal literal of a 64-bit integer of another
+// format, like FormatInt
+func FormatIntLiteral(s string) string {
+	if s == smallestInt {
+		return smallestIntExpression
+	}
+
+	return s
+}
+
+// FormatFloat writes a float that is read back as a Nix float
+func FormatFloat(f float64) string {
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+
+	return s
+}
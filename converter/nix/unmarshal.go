@@ -0,0 +1,450 @@
+package nix
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/orivej/go-nix/nix/parser"
+	"github.com/theobori/nix-converter/converter"
+)
+
+// Unmarshaler stores the values of a Nix expression into Go values, it
+// follows the rules of encoding/json
+type Unmarshaler struct {
+	p      *parser.Parser
+	errors *Collector
+	node   *parser.Node
+	policy string
+}
+
+func NewUnmarshaler(p *parser.Parser, node *parser.Node, options *converter.ConverterOptions) *Unmarshaler {
+	return &Unmarshaler{
+		node:   node,
+		p:      p,
+		errors: NewCollector(p, node, options),
+		policy: options.DuplicateKeys.Policy,
+	}
+}
+
+// Describes the value of a node, for the errors
+func nodeKind(node *parser.Node) string {
+	switch node.Type {
+	case parser.SetNode:
+		return "a set"
+	case parser.ListNode:
+		return "a list"
+	case parser.StringNode, parser.IStringNode:
+		return "a string"
+	case parser.IntNode:
+		return "an integer"
+	case parser.FloatNode, parser.ApplyNode:
+		return "a float"
+	case parser.IDNode:
+		return "a boolean"
+	case parser.OpNode + 57378: // The negative unary operator
+		return nodeKind(node.Nodes[0])
+	default:
+		return node.Type.String()
+	}
+}
+
+func typeError(kind string, t reflect.Type) error {
+	return fmt.Errorf("cannot unmarshal %s into a Go value of type %s", kind, t.String())
+}
+
+func isNull(p *parser.Parser, node *parser.Node) bool {
+	return node.Type == parser.IDNode && p.TokenString(node.Tokens[0]) == "null"
+}
+
+// Follows the pointers of v, allocating them when they are nil
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	return v
+}
+
+// Returns the field of a struct at an index, allocating the nil embedded
+// pointers on the way
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				// Like encoding/json, the pointers to unexported structs
+				// cannot be allocated
+				if !v.CanSet() {
+					return reflect.Value{}, fmt.Errorf("cannot set the embedded pointer to the unexported struct %s", v.Type().Elem().String())
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+
+	return v, nil
+}
+
+// Returns the encoding.TextUnmarshaler of v, if its type implements it
+func textUnmarshaler(v reflect.Value) (encoding.TextUnmarshaler, bool) {
+	if !v.CanAddr() || !v.Addr().CanInterface() {
+		return nil, false
+	}
+
+	u, ok := v.Addr().Interface().(encoding.TextUnmarshaler)
+	return u, ok
+}
+
+func (u *Unmarshaler) visitSet(tree *AttrNode, v reflect.Value) error {
+	v = indirect(v)
+
+	if _, ok := textUnmarshaler(v); ok {
+		return typeError("a set", v.Type())
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.NumMethod() > 0 {
+			return typeError("a set", v.Type())
+		}
+
+		m := reflect.ValueOf(map[string]any{})
+		if err := u.visitMap(tree, m); err != nil {
+			return err
+		}
+		v.Set(m)
+	case reflect.Map:
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		return u.visitMap(tree, v)
+	case reflect.Struct:
+		fields := structFields(v.Type())
+		for _, k := range tree.Order {
+			f, exists := fieldByName(fields, k)
+			// Like encoding/json, the unknown keys are ignored
+			if !exists {
+				continue
+			}
+
+			fv, err := fieldByIndex(v, f.index)
+			if err != nil {
+				return converter.AtKey(err, k)
+			}
+
+			if err := u.visitAttr(tree.Children[k], fv); err != nil {
+				return converter.AtKey(err, k)
+			}
+		}
+	default:
+		return typeError("a set", v.Type())
+	}
+
+	return nil
+}
+
+// Returns a map key of a set key
+func mapKey(k string, t reflect.Type) (reflect.Value, error) {
+	switch t.Kind() {
+	case reflect.String:
+		return reflect.ValueOf(k).Convert(t), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(k, 10, t.Bits())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("the key '%s' is not a Go value of type %s", k, t.String())
+		}
+		return reflect.ValueOf(n).Convert(t), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(k, 10, t.Bits())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("the key '%s' is not a Go value of type %s", k, t.String())
+		}
+		return reflect.ValueOf(n).Convert(t), nil
+	default:
+		return reflect.Value{}, fmt.Errorf("unsupported map key type: %s", t.String())
+	}
+}
+
+func (u *Unmarshaler) visitMap(tree *AttrNode, m reflect.Value) error {
+	t := m.Type()
+
+	for _, k := range tree.Order {
+		key, err := mapKey(k, t.Key())
+		if err != nil {
+			return err
+		}
+
+		elem := reflect.New(t.Elem()).Elem()
+		if err := u.visitAttr(tree.Children[k], elem); err != nil {
+			return converter.AtKey(err, k)
+		}
+
+		m.SetMapIndex(key, elem)
+	}
+
+	return nil
+}
+
+// The attributes of an attribute path have no value node
+func (u *Unmarshaler) visitAttr(attr *AttrNode, v reflect.Value) error {
+	if attr.Value != nil {
+		return u.visit(attr.Value, v)
+	}
+
+	if err := u.errors.Enter(nil); err != nil {
+		return err
+	}
+	defer u.errors.Leave()
+
+	return u.visitSet(attr, v)
+}
+
+func (u *Unmarshaler) visitList(node *parser.Node, v reflect.Value) error {
+	v = indirect(v)
+
+	if _, ok := textUnmarshaler(v); ok {
+		return typeError("a list", v.Type())
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.NumMethod() > 0 {
+			return typeError("a list", v.Type())
+		}
+
+		l := reflect.ValueOf(make([]any, len(node.Nodes)))
+		if err := u.visitElements(node, l); err != nil {
+			return err
+		}
+		v.Set(l)
+	case reflect.Slice:
+		l := reflect.MakeSlice(v.Type(), len(node.Nodes), len(node.Nodes))
+		if err := u.visitElements(node, l); err != nil {
+			return err
+		}
+		v.Set(l)
+	case reflect.Array:
+		// Like encoding/json, the extra elements are ignored and the missing
+		// ones are zeroed
+		v.SetZero()
+		nodes := node.Nodes[:min(len(node.Nodes), v.Len())]
+		if err := u.visitElements(&parser.Node{Nodes: nodes}, v); err != nil {
+			return err
+		}
+	default:
+		return typeError("a list", v.Type())
+	}
+
+	return nil
+}
+
+func (u *Unmarshaler) visitElements(node *parser.Node, l reflect.Value) error {
+	for index, child := range node.Nodes {
+		if err := u.visit(child, l.Index(index)); err != nil {
+			return converter.AtIndex(err, index)
+		}
+	}
+
+	return nil
+}
+
+// Returns the Go value of a scalar node, a string, an int64, a float64 or a
+// bool
+func (u *Unmarshaler) scalar(node *parser.Node) (any, error) {
+	switch node.Type {
+	case parser.StringNode, parser.IStringNode:
+		return VisitStringValue(u.p, node)
+	case parser.IntNode:
+		return VisitIntRaw(u.p, node)
+	case parser.FloatNode:
+		return VisitFloatRaw(u.p, node)
+	case parser.ApplyNode:
+		return VisitApplyRaw(u.p, node)
+	case parser.IDNode:
+		id, err := VisitID(u.p, node)
+		if err != nil {
+			return nil, err
+		}
+		switch id {
+		case "true", "false":
+			return id == "true", nil
+		default:
+			return nil, fmt.Errorf("unsupported identifier: %s", id)
+		}
+	case parser.OpNode + 57378: // The negative unary operator
+		x, err := u.scalar(node.Nodes[0])
+		if err != nil {
+			return nil, err
+		}
+		switch x := x.(type) {
+		case int64:
+			return -x, nil
+		case float64:
+			return -x, nil
+		default:
+			return nil, fmt.Errorf("expected a number, got %s", nodeKind(node.Nodes[0]))
+		}
+	case parser.ParensNode:
+		return u.scalar(node.Nodes[0])
+	default:
+		return nil, fmt.Errorf("unsupported node type: %s", node.Type.String())
+	}
+}
+
+func (u *Unmarshaler) visitScalar(node *parser.Node, v reflect.Value) error {
+	x, err := u.scalar(node)
+	if err != nil {
+		return err
+	}
+
+	v = indirect(v)
+
+	if t, ok := textUnmarshaler(v); ok {
+		s, isString := x.(string)
+		if !isString {
+			return typeError(nodeKind(node), v.Type())
+		}
+		return t.UnmarshalText([]byte(s))
+	}
+
+	if v.Kind() == reflect.Interface && v.NumMethod() == 0 {
+		v.Set(reflect.ValueOf(x))
+		return nil
+	}
+
+	if v.Type() == numberType {
+		return storeNumber(x, v)
+	}
+
+	switch x := x.(type) {
+	case string:
+		if v.Kind() != reflect.String {
+			return typeError("a string", v.Type())
+		}
+		v.SetString(x)
+	case bool:
+		if v.Kind() != reflect.Bool {
+			return typeError("a boolean", v.Type())
+		}
+		v.SetBool(x)
+	case int64:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if v.OverflowInt(x) {
+				return fmt.Errorf("the integer %d overflows a Go value of type %s", x, v.Type().String())
+			}
+			v.SetInt(x)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if x < 0 || v.OverflowUint(uint64(x)) {
+				return fmt.Errorf("the integer %d overflows a Go value of type %s", x, v.Type().String())
+			}
+			v.SetUint(uint64(x))
+		case reflect.Float32, reflect.Float64:
+			v.SetFloat(float64(x))
+		default:
+			return typeError("an integer", v.Type())
+		}
+	case float64:
+		switch v.Kind() {
+		case reflect.Float32, reflect.Float64:
+			if v.OverflowFloat(x) {
+				return fmt.Errorf("the float %v overflows a Go value of type %s", x, v.Type().String())
+			}
+			v.SetFloat(x)
+		default:
+			return typeError("a float", v.Type())
+		}
+	}
+
+	return nil
+}
+
+// Stores a number, or a string holding one, in a json.Number like
+// encoding/json
+func storeNumber(x any, v reflect.Value) error {
+	switch x := x.(type) {
+	case int64:
+		v.SetString(strconv.FormatInt(x, 10))
+	case float64:
+		v.SetString(FormatFloat(x))
+	case string:
+		if x == "" || !strings.ContainsRune("-0123456789", rune(x[0])) || !json.Valid([]byte(x)) {
+			return fmt.Errorf("invalid number literal, trying to unmarshal %q into Number", x)
+		}
+		v.SetString(x)
+	default:
+		return typeError("a boolean", v.Type())
+	}
+
+	return nil
+}
+
+func (u *Unmarshaler) visit(node *parser.Node, v reflect.Value) error {
+	_, err := Visit(u.errors, node, func(node *parser.Node) (struct{}, error) {
+		return struct{}{}, u.visitNode(node, v)
+	}, struct{}{})
+
+	return err
+}
+
+func (u *Unmarshaler) visitNode(node *parser.Node, v reflect.Value) error {
+	switch {
+	case node.Type == parser.ParensNode:
+		return u.visit(node.Nodes[0], v)
+	case isNull(u.p, node):
+		// Like encoding/json, null only zeroes the values that may be nil
+		switch v.Kind() {
+		case reflect.Interface, reflect.Pointer, reflect.Map, reflect.Slice:
+			v.SetZero()
+		}
+		return nil
+	case node.Type == parser.SetNode:
+		tree, err := u.errors.BuildAttrTree(node, u.policy)
+		if err != nil {
+			return err
+		}
+		return u.visitSet(tree, v)
+	case node.Type == parser.ListNode:
+		return u.visitList(node, v)
+	default:
+		return u.visitScalar(node, v)
+	}
+}
+
+// Unmarshal stores the Nix expression in the value pointed to by v
+func (u *Unmarshaler) Unmarshal(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("cannot unmarshal into a Go value of type %s, a non-nil pointer is needed", reflect.TypeOf(v))
+	}
+
+	err := u.visit(u.node, rv.Elem())
+	return u.errors.Err(err)
+}
+
+// Unmarshal parses a Nix expression and stores it in the value pointed to by
+// v. Like encoding/json, the sets are stored in structs or maps, a struct
+// field being matched by its nix tag or its name, ignoring the case. The
+// encoding.TextUnmarshaler implementations are given the strings.
+func Unmarshal(data []byte, v any) error {
+	return UnmarshalWithOptions(data, v, converter.NewDefaultConverterOptions())
+}
+
+// UnmarshalWithOptions is Unmarshal, collecting every error of the input when
+// options.AllErrors is set and following its duplicate keys policy
+func UnmarshalWithOptions(data []byte, v any, options *converter.ConverterOptions) error {
+	p, err := Parse(string(data))
+	if err != nil {
+		return err
+	}
+
+	return NewUnmarshaler(p, p.Result, options).Unmarshal(v)
+}
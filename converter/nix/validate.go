@@ -1,11 +1,40 @@
 package nix
 
-import "github.com/theobori/nix-converter/internal/common"
+import (
+	"slices"
+
+	"github.com/theobori/nix-converter/internal/common"
+)
+
+// Keywords are the reserved words of Nix, they are never identifiers
+var Keywords = []string{
+	"assert",
+	"else",
+	"if",
+	"in",
+	"inherit",
+	"let",
+	"or",
+	"rec",
+	"then",
+	"with",
+}
+
+// IsIdentifierStart reports whether an identifier may start with c
+func IsIdentifierStart(c byte) bool {
+	return common.IsCharAlpha(c) || c == '_'
+}
 
 func IsCharSafe(c byte) bool {
-	return common.IsCharAlphaNumeric(c) || c == '-' || c == '_'
+	return common.IsCharAlphaNumeric(c) || c == '-' || c == '_' || c == '\''
+}
+
+func IsKeyword(s string) bool {
+	return slices.Contains(Keywords, s)
 }
 
+// IsNameUnsafe reports whether s must be quoted to be an attribute name, an
+// identifier being [a-zA-Z_][a-zA-Z0-9_'-]* except the keywords
 func IsNameUnsafe(s string) bool {
 	n := len(s)
 
@@ -13,7 +42,7 @@ func IsNameUnsafe(s string) bool {
 		return true
 	}
 
-	if !common.IsCharAlpha(s[0]) {
+	if !IsIdentifierStart(s[0]) {
 		return true
 	}
 
@@ -23,7 +52,7 @@ func IsNameUnsafe(s string) bool {
 		}
 	}
 
-	return false
+	return IsKeyword(s)
 }
 
 func IsElementUnsafe(s string) bool {
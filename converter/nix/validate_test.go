@@ -0,0 +1,108 @@
+package nix
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/orivej/go-nix/nix/parser"
+)
+
+var namesSafe = []string{
+	"a",
+	"_type",
+	"__curPos",
+	"a'",
+	"a''",
+	"foo-bar_1",
+	"true",
+	"null",
+	"iff",
+	"inherit'",
+}
+
+var namesUnsafe = []string{
+	"",
+	"1a",
+	"-a",
+	"'a",
+	"a.b",
+	"a b",
+	"é",
+	"assert",
+	"else",
+	"if",
+	"in",
+	"inherit",
+	"let",
+	"or",
+	"rec",
+	"then",
+	"with",
+}
+
+func TestIsNameSafe(t *testing.T) {
+	t.Parallel()
+	for _, name := range namesSafe {
+		if IsNameUnsafe(name) {
+			t.Fatalf("the name '%s' is an identifier", name)
+		}
+	}
+}
+
+func TestIsNameUnsafe(t *testing.T) {
+	t.Parallel()
+	for _, name := range namesUnsafe {
+		if !IsNameUnsafe(name) {
+			t.Fatalf("the name '%s' is not an identifier", name)
+		}
+	}
+}
+
+// Returns a random key made of identifier characters, keywords and
+// characters that must be escaped
+func randomName(r *rand.Rand) string {
+	parts := append([]string{
+		"a", "Z", "0", "_", "'", "-", ".", " ", "\"", "\\", "\n", "\t", "$", "{", "}", "é",
+	}, Keywords...)
+
+	var b strings.Builder
+	for range r.Intn(5) {
+		b.WriteString(parts[r.Intn(len(parts))])
+	}
+
+	return b.String()
+}
+
+// TestMakeNameSafeRoundTrip tests that every generated key is read back as the
+// same string
+func TestMakeNameSafeRoundTrip(t *testing.T) {
+	t.Parallel()
+	r := rand.New(rand.NewSource(1))
+
+	// The dollars before an escape sequence, then random keys
+	names := []string{"$\"", "$\\", "$$\t", "$${a}", "a$$$\r"}
+	for range 10000 {
+		names = append(names, randomName(r))
+	}
+
+	for _, name := range names {
+		for _, forceUnsafe := range []bool{false, true} {
+			source := "{ " + MakeNameSafe(name, forceUnsafe) + " = 1; }"
+
+			p, err := parser.ParseString(source)
+			if err != nil {
+				t.Fatalf("the key %q is written as %s, which does not parse: %v", name, source, err)
+			}
+
+			tree, err := BuildAttrTree(p, p.Result, "")
+			if err != nil {
+				t.Fatalf("the key %q is written as %s: %v", name, source, err)
+			}
+
+			if len(tree.Order) != 1 || tree.Order[0] != name {
+				t.Fatalf("the key %q is written as %s, read back as %q", name, source, tree.Order)
+			}
+		}
+	}
+}
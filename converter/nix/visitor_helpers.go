@@ -1,13 +1,17 @@
 package nix
 
 import (
+	"fmt"
 	"strconv"
 
 	"github.com/orivej/go-nix/nix/parser"
+	"github.com/theobori/nix-converter/converter/options"
 	"github.com/theobori/nix-converter/internal/common"
 )
 
-const IndentSize = 2
+// Deprecated: IndentSize is the default indentation, use
+// options.DefaultIndentSize instead.
+const IndentSize = options.DefaultIndentSize
 
 func VisitText(p *parser.Parser, node *parser.Node) (string, error) {
 	return p.TokenString(node.Tokens[0]), nil
@@ -44,14 +48,25 @@ func VisitString(p *parser.Parser, node *parser.Node) (string, error) {
 	return common.MakeStringSafe(token), nil
 }
 
+// VisitInt returns the text of an integer, Nix integers are 64-bit
 func VisitInt(p *parser.Parser, node *parser.Node) (string, error) {
-	return p.TokenString(node.Tokens[0]), nil
+	// The subtraction of the smallest integer, folded by Parse
+	if len(node.Nodes) > 0 {
+		return smallestInt, nil
+	}
+
+	s := p.TokenString(node.Tokens[0])
+	if _, err := strconv.ParseInt(s, 10, 64); err != nil {
+		return "", fmt.Errorf("the integer %s is out of the 64-bit range", s)
+	}
+
+	return s, nil
 }
 
 func VisitIntRaw(p *parser.Parser, node *parser.Node) (int64, error) {
 	s, err := VisitInt(p, node)
 	if err != nil {
-		return 0.0, nil
+		return 0, err
 	}
 
 	return strconv.ParseInt(s, 10, 64)
@@ -0,0 +1,316 @@
+package nix
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/orivej/go-nix/nix/parser"
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/internal/common"
+)
+
+// FormatVisitor writes Nix data back in the layout of the other Nix
+// emitters, attribute paths are merged into nested sets
+type FormatVisitor struct {
+	i       common.Indentation
+	p       *parser.Parser
+	errors  *Collector
+	node    *parser.Node
+	scopes  []scope
+	options *converter.ConverterOptions
+}
+
+// The names bound by a let expression or by a recursive set
+type scope struct {
+	names     map[string]bool
+	recursive bool
+}
+
+func NewFormatVisitor(p *parser.Parser, node *parser.Node, options *converter.ConverterOptions) *FormatVisitor {
+	return &FormatVisitor{
+		i:       *common.NewIndentationUnit(options.NixIndentUnit()),
+		p:       p,
+		errors:  NewCollector(p, node, options),
+		node:    node,
+		options: options,
+	}
+}
+
+func (f *FormatVisitor) visitString(node *parser.Node) (string, error) {
+	s, err := VisitStringValue(f.p, node)
+	if err != nil {
+		return "", err
+	}
+
+	if strings.Contains(s, "\n") {
+		return common.MakeIndentedString(s, f.i.IndentValue(), f.i.Unit()), nil
+	}
+
+	return common.MakeStringSafe(s), nil
+}
+
+// Returns the single attribute of a set
+func (f *FormatVisitor) single(attr *AttrNode) (string, *AttrNode, bool) {
+	tree, isSet, err := attr.Set(f.p)
+	if err != nil || !isSet || len(tree.Order) != 1 {
+		return "", nil, false
+	}
+
+	key := tree.Order[0]
+
+	return key, tree.Children[key], true
+}
+
+func (f *FormatVisitor) visitAttr(attr *AttrNode) (string, error) {
+	tree, isSet, err := attr.Set(f.p)
+	if err != nil {
+		return "", err
+	}
+
+	if isSet {
+		return f.visitTree(tree)
+	}
+
+	return f.visit(attr.Value)
+}
+
+func (f *FormatVisitor) visitTree(tree *AttrNode) (string, error) {
+	if len(tree.Order) == 0 {
+		return "{}", nil
+	}
+
+	e, err := f.visitBindings(tree)
+	if err != nil {
+		return "", err
+	}
+
+	return "{\n" + strings.Join(e, "\n") + "\n" + f.i.IndentValue() + "}", nil
+}
+
+// Returns the lines of the attributes of a tree, indented one level deeper
+func (f *FormatVisitor) visitBindings(tree *AttrNode) ([]string, error) {
+	e := []string{}
+	for _, key := range tree.Order {
+		path, attr := CollapsePath(key, tree.Children[key], f.single, &f.options.NixOutput)
+
+		f.i.Indent()
+		right, err := f.visitAttr(attr)
+		if err != nil {
+			return nil, AtPath(err, path)
+		}
+
+		left := MakeAttrPath(path, f.options.UnsafeKeys)
+
+		e = append(e, f.i.IndentValue()+left+" = "+right+";")
+		f.i.UnIndent()
+	}
+
+	if f.options.SortIterators.SortHashmap {
+		slices.Sort(e)
+	}
+
+	return e, nil
+}
+
+// Returns a scope binding the top-level names of a tree
+func newScope(tree *AttrNode, recursive bool) scope {
+	names := map[string]bool{}
+	for _, key := range tree.Order {
+		names[key] = true
+	}
+
+	return scope{names, recursive}
+}
+
+// A let expression is written with its bindings, which the body and the
+// bindings themselves may refer to
+func (f *FormatVisitor) visitLet(node *parser.Node) (string, error) {
+	tree, err := f.errors.BuildAttrTree(node.Nodes[0], f.options.DuplicateKeys.Policy)
+	if err != nil {
+		return "", err
+	}
+
+	f.scopes = append(f.scopes, newScope(tree, false))
+	defer func() { f.scopes = f.scopes[:len(f.scopes)-1] }()
+
+	if len(tree.Order) == 0 {
+		return f.visit(node.Nodes[1])
+	}
+
+	e, err := f.visitBindings(tree)
+	if err != nil {
+		return "", err
+	}
+
+	body, err := f.visit(node.Nodes[1])
+	if err != nil {
+		return "", err
+	}
+
+	indent := f.i.IndentValue()
+	return "let\n" + strings.Join(e, "\n") + "\n" + indent + "in\n" + indent + body, nil
+}
+
+// A recursive set is written as a set, its attributes cannot refer to each
+// other
+func (f *FormatVisitor) visitRecursiveSet(node *parser.Node) (string, error) {
+	tree, err := f.errors.BuildAttrTree(node, f.options.DuplicateKeys.Policy)
+	if err != nil {
+		return "", err
+	}
+
+	f.scopes = append(f.scopes, newScope(tree, true))
+	defer func() { f.scopes = f.scopes[:len(f.scopes)-1] }()
+
+	return f.visitTree(tree)
+}
+
+// Returns an identifier bound by an enclosing let expression
+func (f *FormatVisitor) visitID(id string) (string, error) {
+	for i := len(f.scopes) - 1; i >= 0; i-- {
+		s := f.scopes[i]
+		if !s.names[id] {
+			continue
+		}
+
+		if s.recursive {
+			return "", fmt.Errorf("the recursive set refers to its attribute '%s'", id)
+		}
+		return id, nil
+	}
+
+	return "", fmt.Errorf("unsupported identifier: %s", id)
+}
+
+// Returns true when a node is a let expression, which a list element cannot
+// be without parentheses
+func isLet(node *parser.Node) bool {
+	for node.Type == parser.ParensNode {
+		node = node.Nodes[0]
+	}
+
+	return node.Type == parser.LetNode
+}
+
+func (f *FormatVisitor) visitList(node *parser.Node) (string, error) {
+	if len(node.Nodes) == 0 {
+		return "[]", nil
+	}
+
+	e := []string{}
+	for index, child := range node.Nodes {
+		f.i.Indent()
+		s, err := f.visit(child)
+		if err != nil {
+			return "", converter.AtIndex(err, index)
+		}
+
+		element := MakeElementSafe(s)
+		if isLet(child) {
+			element = "(" + s + ")"
+		}
+
+		e = append(e, f.i.IndentValue()+element)
+		f.i.UnIndent()
+	}
+
+	if f.options.SortIterators.SortList {
+		slices.Sort(e)
+	}
+
+	return "[\n" + strings.Join(e, "\n") + "\n" + f.i.IndentValue() + "]", nil
+}
+
+func (f *FormatVisitor) visitNegative(node *parser.Node) (string, error) {
+	child := node.Nodes[0]
+	for child.Type == parser.ParensNode {
+		child = child.Nodes[0]
+	}
+
+	switch child.Type {
+	case parser.IntNode, parser.FloatNode, parser.ApplyNode:
+		s, err := f.visit(child)
+		if err != nil {
+			return "", err
+		}
+		return "-" + s, nil
+	default:
+		return "", fmt.Errorf("expected a number after '-', got %s", child.Type.String())
+	}
+}
+
+func (f *FormatVisitor) visit(node *parser.Node) (string, error) {
+	return Visit(f.errors, node, f.visitNode, "null")
+}
+
+func (f *FormatVisitor) visitNode(node *parser.Node) (string, error) {
+	switch node.Type {
+	case parser.SetNode:
+		tree, err := f.errors.BuildAttrTree(node, f.options.DuplicateKeys.Policy)
+		if err != nil {
+			return "", err
+		}
+		return f.visitTree(tree)
+	case parser.RecSetNode:
+		return f.visitRecursiveSet(node)
+	case parser.LetNode:
+		return f.visitLet(node)
+	case parser.ListNode:
+		return f.visitList(node)
+	case parser.StringNode, parser.IStringNode:
+		return f.visitString(node)
+	case parser.IntNode:
+		s, err := VisitInt(f.p, node)
+		return FormatIntLiteral(s), err
+	case parser.FloatNode:
+		v, err := VisitFloatRaw(f.p, node)
+		if err != nil {
+			return "", err
+		}
+		return FormatFloat(v), nil
+	case parser.ApplyNode:
+		v, err := VisitApplyRaw(f.p, node)
+		if err != nil {
+			return "", err
+		}
+		return FormatFloat(v), nil
+	case parser.IDNode:
+		id, err := VisitID(f.p, node)
+		if err != nil {
+			return "", err
+		}
+		switch id {
+		case "true", "false", "null":
+			return id, nil
+		default:
+			return f.visitID(id)
+		}
+	case parser.OpNode + 57378: // The negative unary operator
+		return f.visitNegative(node)
+	case parser.ParensNode:
+		return f.visit(node.Nodes[0])
+	default:
+		return "", fmt.Errorf("unsupported node type: %s", node.Type.String())
+	}
+}
+
+func (f *FormatVisitor) Visit() (string, error) {
+	v, err := f.visit(f.node)
+	return f.errors.Done(v, err)
+}
+
+// Format parses Nix data and writes it back canonically
+func Format(data string, options *converter.ConverterOptions) (string, error) {
+	p, err := Parse(data)
+	if err != nil {
+		return "", err
+	}
+
+	out, err := NewFormatVisitor(p, p.Result, options).Visit()
+	if err != nil {
+		return "", err
+	}
+
+	return Layout(out, &options.NixOutput), nil
+}
@@ -6,58 +6,53 @@ import (
 	"strings"
 
 	"github.com/orivej/go-nix/nix/parser"
+	"github.com/theobori/nix-converter/converter"
 )
 
 type NixVisitor struct {
-	p    *parser.Parser
-	node *parser.Node
+	p      *parser.Parser
+	errors *Collector
+	node   *parser.Node
+	policy string
 }
 
 func NewNixVisitor(p *parser.Parser, node *parser.Node) *NixVisitor {
 	return &NixVisitor{
-		node: node,
-		p:    p,
+		node:   node,
+		p:      p,
+		errors: NewCollector(p, node, converter.NewDefaultConverterOptions()),
 	}
 }
 
 func (n *NixVisitor) visitSet(node *parser.Node) (any, error) {
+	tree, err := n.errors.BuildAttrTree(node, n.policy)
+	if err != nil {
+		return nil, err
+	}
+
+	return n.buildSet(tree)
+}
+
+func (n *NixVisitor) buildSet(tree *AttrNode) (any, error) {
 	out := map[string]any{}
 
-	for _, child := range node.Nodes {
-		// Handle nested attribute paths (e.g., package.meta.desc)
-		attrPathNode := child.Nodes[0]
-		value, err := n.visit(child.Nodes[1])
-		if err != nil {
-			return map[string]any{}, err
+	for _, k := range tree.Order {
+		child := tree.Children[k]
+
+		var (
+			value any
+			err   error
+		)
+		if child.Value != nil {
+			value, err = n.visit(child.Value)
+		} else {
+			value, err = n.buildSet(child)
 		}
-
-		// Extract all keys in the path
-		keys := make([]string, len(attrPathNode.Nodes))
-		for i, keyNode := range attrPathNode.Nodes {
-			keyVal, err := n.visit(keyNode)
-			if err != nil {
-				return map[string]any{}, err
-			}
-			keyStr, ok := keyVal.(string)
-			if !ok {
-				return nil, fmt.Errorf("unable to convert attr key to string")
-			}
-			keys[i] = keyStr
+		if err != nil {
+			return nil, converter.AtKey(err, k)
 		}
 
-		// Create nested structure
-		current := out
-		for i := 0; i < len(keys)-1; i++ {
-			if _, exists := current[keys[i]]; !exists {
-				current[keys[i]] = map[string]any{}
-			}
-			var ok bool
-			current, ok = current[keys[i]].(map[string]any)
-			if !ok {
-				return nil, fmt.Errorf("conflicting attribute path")
-			}
-		}
-		current[keys[len(keys)-1]] = value
+		out[k] = value
 	}
 
 	return out, nil
@@ -66,10 +61,10 @@ func (n *NixVisitor) visitSet(node *parser.Node) (any, error) {
 func (n *NixVisitor) visitList(node *parser.Node) (any, error) {
 	out := []any{}
 
-	for _, child := range node.Nodes {
+	for index, child := range node.Nodes {
 		item, err := n.visit(child)
 		if err != nil {
-			return []any{}, err
+			return []any{}, converter.AtIndex(err, index)
 		}
 
 		out = append(out, item)
@@ -117,6 +112,10 @@ func (n *NixVisitor) visitParens(node *parser.Node) (any, error) {
 }
 
 func (n *NixVisitor) visit(node *parser.Node) (any, error) {
+	return Visit(n.errors, node, n.visitNode, nil)
+}
+
+func (n *NixVisitor) visitNode(node *parser.Node) (any, error) {
 	switch node.Type {
 	case parser.SetNode:
 		return n.visitSet(node)
@@ -194,16 +193,27 @@ func ProcessIndentedString(raw string) string {
 }
 
 func (n *NixVisitor) Visit() (any, error) {
-	return n.visit(n.node)
+	v, err := n.visit(n.node)
+	return v, n.errors.Err(err)
 }
 
 func GoValue(data string) (any, error) {
-	p, err := parser.ParseString(data)
+	return GoValueWithOptions(data, converter.NewDefaultConverterOptions())
+}
+
+// GoValueWithOptions is GoValue, collecting every error of the input when
+// options.AllErrors is set and following its duplicate keys policy
+func GoValueWithOptions(data string, options *converter.ConverterOptions) (any, error) {
+	p, err := Parse(data)
 	if err != nil {
 		return nil, err
 	}
 
-	out, err := NewNixVisitor(p, p.Result).Visit()
+	v := NewNixVisitor(p, p.Result)
+	v.errors = NewCollector(p, p.Result, options)
+	v.policy = options.DuplicateKeys.Policy
+
+	out, err := v.Visit()
 	if err != nil {
 		return nil, err
 	}
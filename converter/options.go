@@ -1,17 +1,68 @@
 package converter
 
 import (
+	"context"
+
 	"github.com/theobori/nix-converter/converter/options"
 )
 
 type ConverterOptions struct {
 	SortIterators options.SortIterators
 	UnsafeKeys    bool
+	AllErrors     bool
+	JSONOutput    options.JSONOutput
+	CSV           options.CSV
+	INI           options.INI
+	Textproto     options.Textproto
+	NixOutput     options.NixOutput
+	Indentation   options.Indentation
+	DuplicateKeys options.DuplicateKeys
+	Limits        options.Limits
+
+	ctx context.Context
 }
 
 func NewDefaultConverterOptions() *ConverterOptions {
 	return &ConverterOptions{
 		SortIterators: *options.NewDefaultSortIterators(),
 		UnsafeKeys:    false,
+		AllErrors:     false,
+		JSONOutput:    *options.NewDefaultJSONOutput(),
+		CSV:           *options.NewDefaultCSV(),
+		INI:           *options.NewDefaultINI(),
+		Textproto:     *options.NewDefaultTextproto(),
+		NixOutput:     *options.NewDefaultNixOutput(),
+		Indentation:   *options.NewDefaultIndentation(),
+		DuplicateKeys: *options.NewDefaultDuplicateKeys(),
+		Limits:        *options.NewDefaultLimits(),
 	}
 }
+
+// NixIndentUnit returns the text of an indentation level of the Nix outputs,
+// the nixfmt style always indents with two spaces like nixfmt
+func (o *ConverterOptions) NixIndentUnit() string {
+	if o.NixOutput.Style == options.NixStyleNixfmt {
+		return options.NewDefaultIndentation().Unit()
+	}
+
+	return o.Indentation.Unit()
+}
+
+// Context returns the context of the conversions, the background context
+// when none is set
+func (o *ConverterOptions) Context() context.Context {
+	if o.ctx == nil {
+		return context.Background()
+	}
+
+	return o.ctx
+}
+
+// WithContext returns a copy of the options whose conversions stop when the
+// context is done
+func (o *ConverterOptions) WithContext(ctx context.Context) *ConverterOptions {
+	c := *o
+	c.ctx = ctx
+
+	return &c
+}
@@ -0,0 +1,46 @@
+package options
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+type CSV struct {
+	// Field delimiter, zero means the default one of the language, ',' for
+	// CSV and a tab for TSV
+	Delimiter rune
+	// The first record holds data instead of the column names
+	NoHeader bool
+	// Fields are read as numbers, booleans and null when possible, instead of strings
+	InferTypes bool
+}
+
+func NewDefaultCSV() *CSV {
+	return &CSV{
+		Delimiter:  0,
+		NoHeader:   false,
+		InferTypes: false,
+	}
+}
+
+func NewCSV(delimiter string, noHeader bool, inferTypes bool) (*CSV, error) {
+	var r rune
+
+	if delimiter != "" {
+		if delimiter == `\t` {
+			delimiter = "\t"
+		}
+
+		var size int
+		r, size = utf8.DecodeRuneInString(delimiter)
+		if size != len(delimiter) || r == utf8.RuneError || r == '"' || r == '\r' || r == '\n' {
+			return nil, fmt.Errorf("the CSV delimiter must be a single character other than a quote or a newline, got '%s'", delimiter)
+		}
+	}
+
+	return &CSV{
+		Delimiter:  r,
+		NoHeader:   noHeader,
+		InferTypes: inferTypes,
+	}, nil
+}
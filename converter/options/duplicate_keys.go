@@ -0,0 +1,44 @@
+package options
+
+import "fmt"
+
+const (
+	DuplicateKeysError     = "error"
+	DuplicateKeysLastWins  = "last-wins"
+	DuplicateKeysFirstWins = "first-wins"
+	DuplicateKeysDeepMerge = "deep-merge"
+)
+
+type DuplicateKeys struct {
+	// One of the DuplicateKeys constants, an empty policy is the error one.
+	// A key defined twice, or both as a value and as a set, is an error,
+	// keeps its last or its first value, or merges the sets recursively,
+	// the last value winning when one of them is not a set. The TOML keys
+	// and the HCL attributes defined twice are always errors.
+	Policy string
+}
+
+func NewDefaultDuplicateKeys() *DuplicateKeys {
+	return &DuplicateKeys{
+		Policy: DuplicateKeysError,
+	}
+}
+
+func NewDuplicateKeys(policy string) (*DuplicateKeys, error) {
+	switch policy {
+	case DuplicateKeysError, DuplicateKeysLastWins, DuplicateKeysFirstWins, DuplicateKeysDeepMerge:
+	default:
+		return nil, fmt.Errorf(
+			"the duplicate keys policy '%s' is unsupported, it must be '%s', '%s', '%s' or '%s'",
+			policy,
+			DuplicateKeysError,
+			DuplicateKeysLastWins,
+			DuplicateKeysFirstWins,
+			DuplicateKeysDeepMerge,
+		)
+	}
+
+	return &DuplicateKeys{
+		Policy: policy,
+	}, nil
+}
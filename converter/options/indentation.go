@@ -0,0 +1,47 @@
+package options
+
+import (
+	"fmt"
+	"strings"
+)
+
+const DefaultIndentSize = 2
+
+type Indentation struct {
+	// Spaces per indentation level
+	Size int
+	// Indentation levels are a tab instead of spaces
+	UseTabs bool
+}
+
+func NewDefaultIndentation() *Indentation {
+	return &Indentation{
+		Size:    DefaultIndentSize,
+		UseTabs: false,
+	}
+}
+
+func NewIndentation(size int, useTabs bool) (*Indentation, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("the indentation size must be strictly positive, got %d", size)
+	}
+
+	return &Indentation{
+		Size:    size,
+		UseTabs: useTabs,
+	}, nil
+}
+
+// Unit returns the text of an indentation level
+func (i *Indentation) Unit() string {
+	if i.UseTabs {
+		return "\t"
+	}
+
+	// The zero value is the default indentation
+	if i.Size <= 0 {
+		return strings.Repeat(" ", DefaultIndentSize)
+	}
+
+	return strings.Repeat(" ", i.Size)
+}
@@ -0,0 +1,20 @@
+package options
+
+type INI struct {
+	// A ';' or a '#' after a whitespace starts a comment in the unquoted
+	// values, like in git-config, otherwise the values are kept whole, like
+	// in systemd units
+	InlineComments bool
+}
+
+func NewDefaultINI() *INI {
+	return &INI{
+		InlineComments: false,
+	}
+}
+
+func NewINI(inlineComments bool) *INI {
+	return &INI{
+		InlineComments: inlineComments,
+	}
+}
@@ -0,0 +1,50 @@
+package options
+
+import "fmt"
+
+const (
+	JSONStylePretty   = "pretty"
+	JSONStyleCompact  = "compact"
+	JSONStyleMinified = "minified"
+)
+
+type JSONOutput struct {
+	// One of the JSONStyle constants, an empty style is pretty
+	Style string
+	// Spaces per indentation level with the pretty style, zero means the default size
+	IndentSize int
+}
+
+func NewDefaultJSONOutput() *JSONOutput {
+	return &JSONOutput{
+		Style:      JSONStylePretty,
+		IndentSize: 0,
+	}
+}
+
+func NewJSONOutput(style string, indentSize int) (*JSONOutput, error) {
+	switch style {
+	case JSONStylePretty, JSONStyleCompact, JSONStyleMinified:
+	default:
+		return nil, fmt.Errorf(
+			"the JSON style '%s' is unsupported, it must be '%s', '%s' or '%s'",
+			style,
+			JSONStylePretty,
+			JSONStyleCompact,
+			JSONStyleMinified,
+		)
+	}
+
+	if indentSize < 0 {
+		return nil, fmt.Errorf("the JSON indentation size must be positive, got %d", indentSize)
+	}
+
+	return &JSONOutput{
+		Style:      style,
+		IndentSize: indentSize,
+	}, nil
+}
+
+func (j *JSONOutput) IsPretty() bool {
+	return j.Style == "" || j.Style == JSONStylePretty
+}
@@ -0,0 +1,49 @@
+package options
+
+import "fmt"
+
+// Limits bounds the resources of a conversion, a zero limit is no limit
+type Limits struct {
+	// Maximum nesting of the values, the top-level value being at depth 1
+	MaxDepth int
+	// Maximum amount of values, including the sets, lists and their elements
+	MaxNodes int
+	// Maximum size of the output in bytes
+	MaxOutputBytes int
+	// Maximum amount of YAML aliases referencing an anchor
+	MaxAliasExpansions int
+}
+
+func NewDefaultLimits() *Limits {
+	return &Limits{
+		MaxDepth:           0,
+		MaxNodes:           0,
+		MaxOutputBytes:     0,
+		MaxAliasExpansions: 0,
+	}
+}
+
+func NewLimits(maxDepth int, maxNodes int, maxOutputBytes int, maxAliasExpansions int) (*Limits, error) {
+	limits := []struct {
+		name  string
+		limit int
+	}{
+		{"depth", maxDepth},
+		{"nodes", maxNodes},
+		{"output bytes", maxOutputBytes},
+		{"alias expansions", maxAliasExpansions},
+	}
+
+	for _, l := range limits {
+		if l.limit < 0 {
+			return nil, fmt.Errorf("the %s limit must be positive, got %d", l.name, l.limit)
+		}
+	}
+
+	return &Limits{
+		MaxDepth:           maxDepth,
+		MaxNodes:           maxNodes,
+		MaxOutputBytes:     maxOutputBytes,
+		MaxAliasExpansions: maxAliasExpansions,
+	}, nil
+}
@@ -0,0 +1,58 @@
+package options
+
+import "fmt"
+
+const (
+	NixStyleDefault = "default"
+	NixStyleNixfmt  = "nixfmt"
+)
+
+type NixOutput struct {
+	// One of the NixStyle constants, an empty style is the default one
+	Style string
+	// Chains of sets with a single key are written as attribute paths, like
+	// a.b.c = 1; instead of nested sets
+	CollapsePaths bool
+	// Maximum amount of keys of a collapsed attribute path, zero means no limit
+	CollapseDepth int
+	// Lists and sets holding only scalars are written on a single line when
+	// it fits this width, zero means they are always written over several lines
+	LineWidth int
+}
+
+func NewDefaultNixOutput() *NixOutput {
+	return &NixOutput{
+		Style:         NixStyleDefault,
+		CollapsePaths: false,
+		CollapseDepth: 0,
+		LineWidth:     0,
+	}
+}
+
+func NewNixOutput(style string, collapsePaths bool, collapseDepth int, lineWidth int) (*NixOutput, error) {
+	switch style {
+	case NixStyleDefault, NixStyleNixfmt:
+	default:
+		return nil, fmt.Errorf(
+			"the Nix style '%s' is unsupported, it must be '%s' or '%s'",
+			style,
+			NixStyleDefault,
+			NixStyleNixfmt,
+		)
+	}
+
+	if collapseDepth < 0 {
+		return nil, fmt.Errorf("the attribute path depth must be positive, got %d", collapseDepth)
+	}
+
+	if lineWidth < 0 {
+		return nil, fmt.Errorf("the line width must be positive, got %d", lineWidth)
+	}
+
+	return &NixOutput{
+		Style:         style,
+		CollapsePaths: collapsePaths,
+		CollapseDepth: collapseDepth,
+		LineWidth:     lineWidth,
+	}, nil
+}
@@ -0,0 +1,33 @@
+package options
+
+import "fmt"
+
+type Textproto struct {
+	// Serialized FileDescriptorSet, like the output of protoc
+	// --descriptor_set_out, nil when there is no schema
+	DescriptorSet []byte
+	// Full name of the message type of the document, like "foo.v1.Config"
+	Message string
+}
+
+func NewDefaultTextproto() *Textproto {
+	return &Textproto{
+		DescriptorSet: nil,
+		Message:       "",
+	}
+}
+
+func NewTextproto(descriptorSet []byte, message string) (*Textproto, error) {
+	if descriptorSet != nil && message == "" {
+		return nil, fmt.Errorf("a message name is needed with a descriptor set")
+	}
+
+	if descriptorSet == nil && message != "" {
+		return nil, fmt.Errorf("a descriptor set is needed with a message name")
+	}
+
+	return &Textproto{
+		DescriptorSet: descriptorSet,
+		Message:       message,
+	}, nil
+}
@@ -0,0 +1,27 @@
+package plist
+
+import "github.com/theobori/nix-converter/converter"
+
+type PlistConverter struct {
+	data    string
+	options *converter.ConverterOptions
+}
+
+func NewPlistConverter(data string, options *converter.ConverterOptions) *PlistConverter {
+	return &PlistConverter{
+		data,
+		options,
+	}
+}
+
+func (p *PlistConverter) FromNix() (string, error) {
+	return FromNix(p.data, p.options)
+}
+
+func (p *PlistConverter) ToNix() (string, error) {
+	return ToNix(p.data, p.options)
+}
+
+func (p *PlistConverter) Type() string {
+	return "plist"
+}
@@ -0,0 +1,176 @@
+package plist
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/options"
+	"github.com/theobori/nix-converter/internal/common"
+)
+
+var plistStrings = []string{
+	`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+  <key>Label</key>
+  <string>org.nixos.nix-daemon</string>
+  <key>ProgramArguments</key>
+  <array>
+    <string>/bin/sh</string>
+    <string>-c</string>
+    <string>exec nix-daemon &amp;&amp; echo &lt;done&gt;</string>
+  </array>
+  <key>RunAtLoad</key>
+  <true/>
+  <key>KeepAlive</key>
+  <false/>
+  <key>Nice</key>
+  <integer>-5</integer>
+  <key>Ratio</key>
+  <real>0.5</real>
+  <key>Created</key>
+  <date>2024-01-02T03:04:05Z</date>
+  <key>Blob</key>
+  <data>AAFiaW5hcnk=</data>
+  <key>EnvironmentVariables</key>
+  <dict/>
+  <key>Sockets</key>
+  <array/>
+  <key>Description</key>
+  <string/>
+</dict>
+</plist>`,
+}
+
+var nixStrings = []string{
+	`{
+  Label = "org.nixos.test";
+  StartCalendarInterval = [
+    {
+      Hour = 3;
+      Minute = 15;
+    }
+  ];
+  Ratio = 1.0;
+  Offset = -0.25;
+  Created = {
+    _type = "date";
+    value = "2024-01-02T03:04:05Z";
+  };
+  Blob = {
+    _type = "data";
+    value = "AAFiaW5hcnk=";
+  };
+  Script = ''
+    echo "hello"
+  '';
+}`,
+}
+
+// Written by Python plistlib with FMT_BINARY
+const binaryPlist = "YnBsaXN0MDDdAQIDBAUGBwgJCgsMDQ4PExQVFhcYGRobHB1VTGFiZWxfEBBQcm9ncmFtQXJndW1lbnRzWVJ1bkF0TG9hZFlLZWVwQWxpdmVdU3RhcnRJbnRlcnZhbFROaWNlVVJhdGlvU0JpZ1REYXRlVEJsb2JVRW1wdHlZRW1wdHlMaXN0V1VuaWNvZGVeb3JnLm5peG9zLnRlc3SjEBESVy9iaW4vc2hSLWNfEBJlY2hvICdoaScgJiA8ZG9uZT4JCBEBLBP/////////+yM/8AAAAAAAABMAAAEAAAAAADNBxaHaUoAAAEgAAWJpbmFyedCgZwBoAOkAbABsAG8AICcTAAgAIwApADwARgBQAF4AYwBpAG0AcgB3AH0AhwCPAJ4AogCqAK0AwgDDAMQAxwDQANkA4gDrAPQA9QD2AAAAAAAAAgEAAAAAAAAAHgAAAAAAAAAAAAAAAAAAAQU="
+
+func TestPlistToNix(t *testing.T) {
+	t.Parallel()
+	common.TestHelperToNixStrings(t, plistStrings, FromNix, ToNix, converter.NewDefaultConverterOptions())
+}
+
+func TestPlistFromNix(t *testing.T) {
+	t.Parallel()
+	options := converter.ConverterOptions{
+		SortIterators: *options.NewDefaultSortIterators(),
+		UnsafeKeys:    true,
+	}
+
+	common.TestHelperFromNixStrings(t, nixStrings, FromNix, ToNix, &options)
+}
+
+func TestPlistBinary(t *testing.T) {
+	t.Parallel()
+	options := converter.NewDefaultConverterOptions()
+	options.UnsafeKeys = true
+
+	data, err := base64.StdEncoding.DecodeString(binaryPlist)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{
+  Label = "org.nixos.test";
+  ProgramArguments = [
+    "/bin/sh"
+    "-c"
+    "echo 'hi' & <done>"
+  ];
+  RunAtLoad = true;
+  KeepAlive = false;
+  StartInterval = 300;
+  Nice = -5;
+  Ratio = 1.0;
+  Big = 1099511627776;
+  Date = {
+    _type = "date";
+    value = "2024-01-02T03:04:05Z";
+  };
+  Blob = {
+    _type = "data";
+    value = "AAFiaW5hcnk=";
+  };
+  Empty = {};
+  EmptyList = [];
+  Unicode = "héllo ✓";
+}`
+
+	output, err := ToNix(string(data), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if output != want {
+		t.Errorf("ToNix() = \n%v, want \n%v", output, want)
+	}
+}
+
+func TestPlistErrors(t *testing.T) {
+	t.Parallel()
+	plistInputs := []struct {
+		input    string
+		expected string
+	}{
+		{`<dict/>`, "line 1, column 1: the root element must be <plist>"},
+		{"<plist>\n  <dict>\n    <key>a</key>\n  </dict>\n</plist>", "line 3, column 5: <dict>: missing value for key 'a'"},
+		{`<plist><dict><string>a</string></dict></plist>`, "line 1, column 14: <dict>: expected <key>, got <string>"},
+		{"<plist>\n  <dict>\n    <key>a</key><true/>\n    <key>a</key><true/>\n  </dict>\n</plist>", "line 4, column 5: the key 'a' is defined twice"},
+		{"<plist>\n  <integer>9223372036854775808</integer>\n</plist>", "line 2, column 3: <integer>: '9223372036854775808' is out of range"},
+		{`<plist><real>nan</real></plist>`, "line 1, column 8: <real>: invalid value 'nan'"},
+		{`<plist><date>yesterday</date></plist>`, "line 1, column 8: <date>: invalid value 'yesterday'"},
+		{`<plist><data>!!</data></plist>`, "line 1, column 8: <data>: illegal base64 data at input byte 0"},
+		{`<plist><true/><false/></plist>`, "line 1, column 15: <plist>: expected a single value"},
+		{"<plist>\n  <array>\n    oops\n  </array>\n</plist>", "line 3, column 5: unexpected text \"oops\""},
+		{"<plist>\n  <array>\n    <string>a</strin>\n  </array>\n</plist>", "line 3, column 22: element <string> closed by </strin>"},
+		{"bplist00", "the binary property list is too short"},
+		{"bplist15", "unsupported binary property list version \"bplist15\""},
+	}
+
+	for _, test := range plistInputs {
+		_, err := ToNix(test.input, converter.NewDefaultConverterOptions())
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("ToNix(%q) expected the error %q, got %v", test.input, test.expected, err)
+		}
+	}
+
+	nixInputs := []string{
+		`null`,
+		`{ a = null; }`,
+		`{ a = { _type = "date"; value = "yesterday"; }; }`,
+		`{ a = { _type = "data"; value = "!!"; }; }`,
+	}
+
+	for _, input := range nixInputs {
+		if _, err := FromNix(input, converter.NewDefaultConverterOptions()); err == nil {
+			t.Errorf("FromNix(%q) expected an error", input)
+		}
+	}
+}
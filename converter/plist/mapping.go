@@ -0,0 +1,101 @@
+// Package plist converts Apple property lists to Nix and vice versa.
+//
+// XML and binary (bplist00) property lists are read, and Nix is always
+// written as an XML property list like the ones of launchd.
+//
+// Values are mapped with the following rules.
+//
+//   - A dictionary is a set and an array is a list, a binary set is a list.
+//   - A string is a string and a boolean is a boolean.
+//   - An integer is a Nix integer and a real is a Nix float, always written
+//     with a decimal point so it is read back as a real.
+//   - A date is the set { _type = "date"; value = "2006-01-02T15:04:05Z"; },
+//     in UTC with a precision of one second.
+//   - A data blob is the set { _type = "data"; value = "<base64>"; }.
+//   - A binary UID is the set { "CF$UID" = 1; }, like in XML property lists.
+//
+// Nix null has no property list representation and is rejected.
+package plist
+
+import (
+	"time"
+
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/nix"
+)
+
+const (
+	TypeKey    = "_type"
+	ValueKey   = "value"
+	TypeDate   = "date"
+	TypeData   = "data"
+	UIDKey     = "CF$UID"
+	DateFormat = "2006-01-02T15:04:05Z"
+)
+
+// Dict is a dictionary keeping the order of its keys
+type Dict struct {
+	Keys   []string
+	Values map[string]any
+}
+
+func newDict() *Dict {
+	return &Dict{Values: map[string]any{}}
+}
+
+func (d *Dict) set(key string, value any) {
+	if _, exists := d.Values[key]; !exists {
+		d.Keys = append(d.Keys, key)
+	}
+
+	d.Values[key] = value
+}
+
+// Adds an entry, a key defined twice follows a policy of options.DuplicateKeys
+func (d *Dict) add(key string, value any, policy string) error {
+	previous, exists := d.Values[key]
+	if !exists {
+		d.set(key, value)
+		return nil
+	}
+
+	merged, err := nix.MergeEntries([]nix.Entry[any]{{Key: key, Value: previous}, {Key: key, Value: value}}, policy, mergeDicts(policy))
+	if err != nil {
+		return err
+	}
+
+	d.Values[key] = merged[0].Value
+
+	return nil
+}
+
+// Returns the deep merge of two dictionaries
+func mergeDicts(policy string) func(a any, b any) (any, bool, error) {
+	return func(a any, b any) (any, bool, error) {
+		da, isDict := a.(*Dict)
+		db, isOtherDict := b.(*Dict)
+		if !isDict || !isOtherDict {
+			return nil, false, nil
+		}
+
+		merged := newDict()
+		for _, d := range []*Dict{da, db} {
+			for _, key := range d.Keys {
+				if err := merged.add(key, d.Values[key], policy); err != nil {
+					return nil, false, converter.AtKey(err, key)
+				}
+			}
+		}
+
+		return merged, true, nil
+	}
+}
+
+// Date is a property list date
+type Date time.Time
+
+// Data is a property list data blob
+type Data []byte
+
+// UID is a binary property list object reference, used by keyed archives
+type UID uint64
@@ -0,0 +1,277 @@
+package plist
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+	"unicode/utf16"
+)
+
+const (
+	BinaryMagic   = "bplist00"
+	trailerSize   = 32
+	maxBinaryRefs = 1 << 24
+)
+
+// The dates are seconds since 2001-01-01 00:00:00 UTC
+var binaryEpoch = time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+type binaryParser struct {
+	data       []byte
+	offsets    []uint64
+	refSize    int
+	inProgress map[uint64]bool
+	policy     string
+}
+
+func readUint(b []byte) uint64 {
+	var u uint64
+	for _, c := range b {
+		u = u<<8 | uint64(c)
+	}
+
+	return u
+}
+
+func (b *binaryParser) slice(offset uint64, size uint64) ([]byte, error) {
+	if offset > uint64(len(b.data)) || size > uint64(len(b.data))-offset {
+		return nil, fmt.Errorf("object at offset %d is out of bounds", offset)
+	}
+
+	return b.data[offset : offset+size], nil
+}
+
+// Returns the length stored in the marker or in the following integer and
+// the offset of the content
+func (b *binaryParser) length(offset uint64, info byte) (uint64, uint64, error) {
+	if info != 0x0F {
+		return uint64(info), offset + 1, nil
+	}
+
+	marker, err := b.slice(offset+1, 1)
+	if err != nil {
+		return 0, 0, err
+	}
+	if marker[0]>>4 != 0x1 || marker[0]&0x0F > 3 {
+		return 0, 0, fmt.Errorf("invalid length at offset %d", offset)
+	}
+
+	size := uint64(1) << (marker[0] & 0x0F)
+	raw, err := b.slice(offset+2, size)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return readUint(raw), offset + 2 + size, nil
+}
+
+func (b *binaryParser) refs(offset uint64, count uint64) ([]uint64, error) {
+	if count > maxBinaryRefs {
+		return nil, fmt.Errorf("too many references at offset %d", offset)
+	}
+
+	raw, err := b.slice(offset, count*uint64(b.refSize))
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make([]uint64, count)
+	for i := range refs {
+		refs[i] = readUint(raw[i*b.refSize : (i+1)*b.refSize])
+	}
+
+	return refs, nil
+}
+
+func (b *binaryParser) object(ref uint64) (any, error) {
+	if ref >= uint64(len(b.offsets)) {
+		return nil, fmt.Errorf("invalid object reference %d", ref)
+	}
+	if b.inProgress[ref] {
+		return nil, fmt.Errorf("object %d contains itself", ref)
+	}
+	b.inProgress[ref] = true
+	defer delete(b.inProgress, ref)
+
+	offset := b.offsets[ref]
+	marker, err := b.slice(offset, 1)
+	if err != nil {
+		return nil, err
+	}
+	kind, info := marker[0]>>4, marker[0]&0x0F
+
+	switch kind {
+	case 0x0:
+		switch info {
+		case 0x8:
+			return false, nil
+		case 0x9:
+			return true, nil
+		default:
+			return nil, fmt.Errorf("unsupported object 0x%02x at offset %d", marker[0], offset)
+		}
+	case 0x1:
+		if info > 4 {
+			return nil, fmt.Errorf("invalid integer at offset %d", offset)
+		}
+		raw, err := b.slice(offset+1, uint64(1)<<info)
+		if err != nil {
+			return nil, err
+		}
+		// 16 bytes integers hold unsigned 64 bits values
+		if info == 4 {
+			if readUint(raw[:8]) != 0 || readUint(raw[8:]) > math.MaxInt64 {
+				return nil, fmt.Errorf("integer at offset %d is out of range", offset)
+			}
+			raw = raw[8:]
+		}
+		// Smaller integers are unsigned, 8 bytes integers are signed
+		return int64(readUint(raw)), nil
+	case 0x2:
+		switch info {
+		case 2:
+			raw, err := b.slice(offset+1, 4)
+			if err != nil {
+				return nil, err
+			}
+			return float64(math.Float32frombits(binary.BigEndian.Uint32(raw))), nil
+		case 3:
+			raw, err := b.slice(offset+1, 8)
+			if err != nil {
+				return nil, err
+			}
+			return math.Float64frombits(binary.BigEndian.Uint64(raw)), nil
+		default:
+			return nil, fmt.Errorf("invalid real at offset %d", offset)
+		}
+	case 0x3:
+		raw, err := b.slice(offset+1, 8)
+		if err != nil {
+			return nil, err
+		}
+		seconds := math.Float64frombits(binary.BigEndian.Uint64(raw))
+		if math.IsNaN(seconds) || math.Abs(seconds) > 1e15 {
+			return nil, fmt.Errorf("invalid date at offset %d", offset)
+		}
+		return Date(binaryEpoch.Add(time.Duration(math.Round(seconds)) * time.Second)), nil
+	case 0x4, 0x5, 0x6:
+		size, start, err := b.length(offset, info)
+		if err != nil {
+			return nil, err
+		}
+		if kind == 0x6 {
+			size *= 2
+		}
+		raw, err := b.slice(start, size)
+		if err != nil {
+			return nil, err
+		}
+		switch kind {
+		case 0x4:
+			return Data(append([]byte{}, raw...)), nil
+		case 0x5:
+			return string(raw), nil
+		default:
+			units := make([]uint16, len(raw)/2)
+			for i := range units {
+				units[i] = binary.BigEndian.Uint16(raw[i*2:])
+			}
+			return string(utf16.Decode(units)), nil
+		}
+	case 0x8:
+		raw, err := b.slice(offset+1, uint64(info)+1)
+		if err != nil {
+			return nil, err
+		}
+		if info > 7 {
+			return nil, fmt.Errorf("invalid UID at offset %d", offset)
+		}
+		return UID(readUint(raw)), nil
+	case 0xA, 0xC:
+		count, start, err := b.length(offset, info)
+		if err != nil {
+			return nil, err
+		}
+		refs, err := b.refs(start, count)
+		if err != nil {
+			return nil, err
+		}
+		array := []any{}
+		for _, r := range refs {
+			value, err := b.object(r)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		return array, nil
+	case 0xD:
+		count, start, err := b.length(offset, info)
+		if err != nil {
+			return nil, err
+		}
+		refs, err := b.refs(start, count*2)
+		if err != nil {
+			return nil, err
+		}
+		dict := newDict()
+		for i := uint64(0); i < count; i++ {
+			k, err := b.object(refs[i])
+			if err != nil {
+				return nil, err
+			}
+			key, isString := k.(string)
+			if !isString {
+				return nil, fmt.Errorf("dictionary key at offset %d is not a string", b.offsets[refs[i]])
+			}
+			value, err := b.object(refs[count+i])
+			if err != nil {
+				return nil, err
+			}
+			if err := dict.add(key, value, b.policy); err != nil {
+				return nil, err
+			}
+		}
+		return dict, nil
+	default:
+		return nil, fmt.Errorf("unsupported object 0x%02x at offset %d", marker[0], offset)
+	}
+}
+
+func parseBinary(data []byte, policy string) (any, error) {
+	if len(data) < len(BinaryMagic)+trailerSize {
+		return nil, fmt.Errorf("the binary property list is too short")
+	}
+
+	trailer := data[len(data)-trailerSize:]
+	offsetSize := int(trailer[6])
+	refSize := int(trailer[7])
+	count := readUint(trailer[8:16])
+	top := readUint(trailer[16:24])
+	tableOffset := readUint(trailer[24:32])
+
+	if offsetSize < 1 || offsetSize > 8 || refSize < 1 || refSize > 8 {
+		return nil, fmt.Errorf("invalid binary property list trailer")
+	}
+
+	end := uint64(len(data) - trailerSize)
+	if tableOffset > end || count > (end-tableOffset)/uint64(offsetSize) {
+		return nil, fmt.Errorf("invalid binary property list offset table")
+	}
+
+	b := binaryParser{
+		data:       data[:end],
+		offsets:    make([]uint64, count),
+		refSize:    refSize,
+		inProgress: map[uint64]bool{},
+		policy:     policy,
+	}
+
+	for i := range b.offsets {
+		start := tableOffset + uint64(i*offsetSize)
+		b.offsets[i] = readUint(data[start : start+uint64(offsetSize)])
+	}
+
+	return b.object(top)
+}
@@ -0,0 +1,276 @@
+package plist
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/theobori/nix-converter/converter"
+)
+
+type xmlParser struct {
+	d      *xml.Decoder
+	data   []byte
+	policy string
+	// The offset of the last start element
+	offset int64
+}
+
+// Locates an error at an offset of the input, unless it is already located
+func (x *xmlParser) errorAt(offset int64, err error) error {
+	e := converter.AsError(err)
+	if e.Line == 0 {
+		e.Line, e.Column = converter.LineColumn(x.data, int(offset))
+	}
+
+	return e
+}
+
+// Returns the next token and its offset, the syntax errors are located
+func (x *xmlParser) token() (xml.Token, int64, error) {
+	offset := x.d.InputOffset()
+
+	token, err := x.d.Token()
+	var syntaxErr *xml.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return nil, offset, x.errorAt(x.d.InputOffset(), errors.New(syntaxErr.Msg))
+	}
+
+	return token, offset, err
+}
+
+// Returns the next start element, or nil when the parent element ends
+func (x *xmlParser) next() (*xml.StartElement, error) {
+	for {
+		token, offset, err := x.token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			x.offset = offset
+			return &t, nil
+		case xml.EndElement:
+			return nil, nil
+		case xml.CharData:
+			if text := strings.TrimSpace(string(t)); text != "" {
+				offset += int64(strings.Index(string(t), text))
+				return nil, x.errorAt(offset, fmt.Errorf("unexpected text %q", text))
+			}
+		}
+	}
+}
+
+func (x *xmlParser) text(name string) (string, error) {
+	var b strings.Builder
+
+	for {
+		token, offset, err := x.token()
+		if err != nil {
+			return "", err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			return "", x.errorAt(offset, fmt.Errorf("<%s>: unexpected element <%s>", name, t.Name.Local))
+		case xml.EndElement:
+			return b.String(), nil
+		case xml.CharData:
+			b.Write(t)
+		}
+	}
+}
+
+func (x *xmlParser) dict() (*Dict, error) {
+	dict := newDict()
+
+	for {
+		start, err := x.next()
+		if err != nil {
+			return nil, err
+		}
+		if start == nil {
+			return dict, nil
+		}
+
+		if start.Name.Local != "key" {
+			return nil, x.errorAt(x.offset, fmt.Errorf("<dict>: expected <key>, got <%s>", start.Name.Local))
+		}
+
+		keyOffset := x.offset
+		key, err := x.text("key")
+		if err != nil {
+			return nil, err
+		}
+
+		start, err = x.next()
+		if err != nil {
+			return nil, err
+		}
+		if start == nil {
+			return nil, x.errorAt(keyOffset, fmt.Errorf("<dict>: missing value for key '%s'", key))
+		}
+
+		value, err := x.value(start)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := dict.add(key, value, x.policy); err != nil {
+			return nil, x.errorAt(keyOffset, err)
+		}
+	}
+}
+
+func (x *xmlParser) array() ([]any, error) {
+	array := []any{}
+
+	for {
+		start, err := x.next()
+		if err != nil {
+			return nil, err
+		}
+		if start == nil {
+			return array, nil
+		}
+
+		value, err := x.value(start)
+		if err != nil {
+			return nil, err
+		}
+
+		array = append(array, value)
+	}
+}
+
+func parseInteger(s string) (int64, error) {
+	negative := strings.HasPrefix(s, "-")
+	digits := strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	base := 10
+	if strings.HasPrefix(digits, "0x") || strings.HasPrefix(digits, "0X") {
+		base, digits = 16, digits[2:]
+	}
+
+	u, err := strconv.ParseUint(digits, base, 64)
+	if err != nil {
+		return 0, fmt.Errorf("<integer>: invalid value '%s'", s)
+	}
+
+	if negative {
+		if u > 1<<63 {
+			return 0, fmt.Errorf("<integer>: '%s' is out of range", s)
+		}
+		return -int64(u), nil
+	}
+
+	if u > math.MaxInt64 {
+		return 0, fmt.Errorf("<integer>: '%s' is out of range", s)
+	}
+
+	return int64(u), nil
+}
+
+// The errors of a value are located at its start element
+func (x *xmlParser) value(start *xml.StartElement) (any, error) {
+	offset := x.offset
+
+	v, err := x.scalar(start)
+	if err != nil {
+		return nil, x.errorAt(offset, err)
+	}
+
+	return v, nil
+}
+
+func (x *xmlParser) scalar(start *xml.StartElement) (any, error) {
+	name := start.Name.Local
+
+	switch name {
+	case "dict":
+		return x.dict()
+	case "array":
+		return x.array()
+	case "true", "false":
+		s, err := x.text(name)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(s) != "" {
+			return nil, fmt.Errorf("<%s>: unexpected text", name)
+		}
+		return name == "true", nil
+	}
+
+	s, err := x.text(name)
+	if err != nil {
+		return nil, err
+	}
+
+	switch name {
+	case "string":
+		return s, nil
+	case "integer":
+		return parseInteger(strings.TrimSpace(s))
+	case "real":
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("<real>: invalid value '%s'", s)
+		}
+		return f, nil
+	case "date":
+		t, err := time.Parse(DateFormat, strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("<date>: invalid value '%s'", s)
+		}
+		return Date(t), nil
+	case "data":
+		b, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+		if err != nil {
+			return nil, fmt.Errorf("<data>: %w", err)
+		}
+		return Data(b), nil
+	default:
+		return nil, fmt.Errorf("unsupported element <%s>", name)
+	}
+}
+
+func parseXML(data string, policy string) (any, error) {
+	x := xmlParser{d: xml.NewDecoder(strings.NewReader(data)), data: []byte(data), policy: policy}
+
+	root, err := x.next()
+	if err != nil {
+		return nil, err
+	}
+	if root == nil || root.Name.Local != "plist" {
+		return nil, x.errorAt(x.offset, fmt.Errorf("the root element must be <plist>"))
+	}
+
+	start, err := x.next()
+	if err != nil {
+		return nil, err
+	}
+	if start == nil {
+		return nil, x.errorAt(x.offset, fmt.Errorf("<plist>: missing value"))
+	}
+
+	value, err := x.value(start)
+	if err != nil {
+		return nil, err
+	}
+
+	if start, err = x.next(); err != nil {
+		return nil, err
+	}
+	if start != nil {
+		return nil, x.errorAt(x.offset, fmt.Errorf("<plist>: expected a single value"))
+	}
+
+	return value, nil
+}
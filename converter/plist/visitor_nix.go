@@ -0,0 +1,257 @@
+package plist
+
+import (
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/orivej/go-nix/nix/parser"
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/nix"
+	"github.com/theobori/nix-converter/converter/xml"
+	"github.com/theobori/nix-converter/internal/common"
+)
+
+const Header = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">`
+
+type NixVisitor struct {
+	i       common.Indentation
+	p       *parser.Parser
+	errors  *nix.Collector
+	node    *parser.Node
+	options *converter.ConverterOptions
+}
+
+func NewNixVisitor(p *parser.Parser, node *parser.Node, options *converter.ConverterOptions) *NixVisitor {
+	return &NixVisitor{
+		i:       *common.NewIndentationUnit(options.Indentation.Unit()),
+		node:    node,
+		p:       p,
+		errors:  nix.NewCollector(p, node, options),
+		options: options,
+	}
+}
+
+func element(name string, text string) string {
+	if text == "" {
+		return "<" + name + "/>"
+	}
+
+	return "<" + name + ">" + xml.EscapeText(text) + "</" + name + ">"
+}
+
+// Returns the element of a date or data set, if the set is one of them
+func (n *NixVisitor) visitTagged(tree *nix.AttrNode) (string, bool, error) {
+	kind, hasType := tree.Children[TypeKey]
+	value, hasValue := tree.Children[ValueKey]
+	if !hasType || !hasValue || len(tree.Order) != 2 || kind.Value == nil || value.Value == nil {
+		return "", false, nil
+	}
+
+	k, err := nix.VisitStringValue(n.p, kind.Value)
+	if err != nil || k != TypeDate && k != TypeData {
+		return "", false, nil
+	}
+
+	s, err := nix.VisitStringValue(n.p, value.Value)
+	if err != nil {
+		return "", false, fmt.Errorf("%s: %w", k, err)
+	}
+
+	if k == TypeDate {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return "", false, fmt.Errorf("invalid date '%s', expected the format %s", s, DateFormat)
+		}
+		return "<date>" + t.UTC().Format(DateFormat) + "</date>", true, nil
+	}
+
+	if _, err := base64.StdEncoding.DecodeString(s); err != nil {
+		return "", false, fmt.Errorf("invalid data '%s': %w", s, err)
+	}
+
+	return element("data", s), true, nil
+}
+
+func (n *NixVisitor) visitDict(tree *nix.AttrNode) (string, error) {
+	tagged, isTagged, err := n.visitTagged(tree)
+	if err != nil || isTagged {
+		return tagged, err
+	}
+
+	if len(tree.Order) == 0 {
+		return "<dict/>", nil
+	}
+
+	keys := tree.Order
+	if n.options.SortIterators.SortHashmap {
+		keys = slices.Clone(tree.Order)
+		slices.Sort(keys)
+	}
+
+	e := []string{}
+	n.i.Indent()
+	for _, k := range keys {
+		s, err := n.visitAttr(tree.Children[k])
+		if err != nil {
+			return "", converter.AtKey(err, k)
+		}
+
+		e = append(e, n.i.IndentValue()+element("key", k), n.i.IndentValue()+s)
+	}
+	n.i.UnIndent()
+
+	return "<dict>\n" + strings.Join(e, "\n") + "\n" + n.i.IndentValue() + "</dict>", nil
+}
+
+func (n *NixVisitor) visitArray(node *parser.Node) (string, error) {
+	if len(node.Nodes) == 0 {
+		return "<array/>", nil
+	}
+
+	e := []string{}
+	n.i.Indent()
+	for index, child := range node.Nodes {
+		s, err := n.visit(child)
+		if err != nil {
+			return "", converter.AtIndex(err, index)
+		}
+
+		e = append(e, n.i.IndentValue()+s)
+	}
+	n.i.UnIndent()
+
+	if n.options.SortIterators.SortList {
+		slices.Sort(e)
+	}
+
+	return "<array>\n" + strings.Join(e, "\n") + "\n" + n.i.IndentValue() + "</array>", nil
+}
+
+func (n *NixVisitor) visitAttr(attr *nix.AttrNode) (string, error) {
+	tree, isSet, err := attr.Set(n.p)
+	if err != nil {
+		return "", err
+	}
+
+	if isSet {
+		return n.visitDict(tree)
+	}
+
+	return n.visit(attr.Value)
+}
+
+func (n *NixVisitor) visitNumber(node *parser.Node, negative bool) (string, error) {
+	var s string
+
+	switch node.Type {
+	case parser.IntNode:
+		i, err := nix.VisitIntRaw(n.p, node)
+		if err != nil {
+			return "", err
+		}
+		if negative {
+			i = -i
+		}
+		return "<integer>" + strconv.FormatInt(i, 10) + "</integer>", nil
+	case parser.FloatNode:
+		f, err := nix.VisitFloatRaw(n.p, node)
+		if err != nil {
+			return "", err
+		}
+		s = strconv.FormatFloat(f, 'f', -1, 64)
+	case parser.ApplyNode:
+		f, err := nix.VisitApplyRaw(n.p, node)
+		if err != nil {
+			return "", err
+		}
+		s = strconv.FormatFloat(f, 'f', -1, 64)
+	case parser.ParensNode:
+		return n.visitNumber(node.Nodes[0], negative)
+	default:
+		return "", fmt.Errorf("expected a number, got %s", node.Type.String())
+	}
+
+	if negative {
+		s = "-" + s
+	}
+
+	return "<real>" + s + "</real>", nil
+}
+
+func (n *NixVisitor) visit(node *parser.Node) (string, error) {
+	return nix.Visit(n.errors, node, n.visitNode, "<string/>")
+}
+
+func (n *NixVisitor) visitNode(node *parser.Node) (string, error) {
+	switch node.Type {
+	case parser.SetNode:
+		tree, err := n.errors.BuildAttrTree(node, n.options.DuplicateKeys.Policy)
+		if err != nil {
+			return "", err
+		}
+		return n.visitDict(tree)
+	case parser.ListNode:
+		return n.visitArray(node)
+	case parser.StringNode, parser.IStringNode:
+		s, err := nix.VisitStringValue(n.p, node)
+		if err != nil {
+			return "", err
+		}
+		return element("string", s), nil
+	case parser.IDNode:
+		id, err := nix.VisitID(n.p, node)
+		if err != nil {
+			return "", err
+		}
+		switch id {
+		case "true", "false":
+			return "<" + id + "/>", nil
+		case "null":
+			return "", fmt.Errorf("null has no property list representation")
+		default:
+			return "", fmt.Errorf("unsupported identifier: %s", id)
+		}
+	case parser.IntNode, parser.FloatNode, parser.ApplyNode:
+		return n.visitNumber(node, false)
+	case parser.OpNode + 57378: // The negative unary operator
+		return n.visitNumber(node.Nodes[0], true)
+	case parser.ParensNode:
+		return n.visit(node.Nodes[0])
+	default:
+		return "", fmt.Errorf("unsupported node type: %s", node.Type.String())
+	}
+}
+
+func (n *NixVisitor) visitRoot() (string, error) {
+	s, err := n.visit(n.node)
+	if err != nil {
+		return "", err
+	}
+
+	return Header + "\n" + s + "\n</plist>", nil
+}
+
+func (n *NixVisitor) Visit() (string, error) {
+	v, err := n.visitRoot()
+	return n.errors.Done(v, err)
+}
+
+func FromNix(data string, options *converter.ConverterOptions) (string, error) {
+	p, err := nix.Parse(data)
+	if err != nil {
+		return "", err
+	}
+
+	out, err := NewNixVisitor(p, p.Result, options).Visit()
+	if err != nil {
+		return "", err
+	}
+
+	return out, nil
+}
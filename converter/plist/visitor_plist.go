@@ -0,0 +1,191 @@
+package plist
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/nix"
+	"github.com/theobori/nix-converter/internal/common"
+)
+
+type PlistVisitor struct {
+	i       common.Indentation
+	node    any
+	limiter *converter.Limiter
+	options *converter.ConverterOptions
+}
+
+func NewPlistVisitor(node any, options *converter.ConverterOptions) *PlistVisitor {
+	return &PlistVisitor{
+		i:       *common.NewIndentationUnit(options.NixIndentUnit()),
+		node:    node,
+		limiter: converter.NewLimiter(options),
+		options: options,
+	}
+}
+
+func (p *PlistVisitor) visitString(s string) string {
+	if strings.Contains(s, "\n") {
+		return common.MakeIndentedString(s, p.i.IndentValue(), p.i.Unit())
+	}
+
+	return common.MakeStringSafe(s)
+}
+
+func (p *PlistVisitor) visitSet(keys []string, values []string) string {
+	if len(keys) == 0 {
+		return "{}"
+	}
+
+	e := []string{}
+	p.i.Indent()
+	for i, key := range keys {
+		left := nix.MakeNameSafe(key, p.options.UnsafeKeys)
+		e = append(e, p.i.IndentValue()+left+" = "+values[i]+";")
+	}
+	p.i.UnIndent()
+
+	return "{\n" + strings.Join(e, "\n") + "\n" + p.i.IndentValue() + "}"
+}
+
+func (p *PlistVisitor) visitTagged(kind string, value string) string {
+	return p.visitSet(
+		[]string{TypeKey, ValueKey},
+		[]string{common.MakeStringSafe(kind), common.MakeStringSafe(value)},
+	)
+}
+
+// Returns the single key of a dictionary and its value
+func (p *PlistVisitor) single(node any) (string, any, bool) {
+	dict, isDict := node.(*Dict)
+	if !isDict || len(dict.Keys) != 1 {
+		return "", nil, false
+	}
+
+	return dict.Keys[0], dict.Values[dict.Keys[0]], true
+}
+
+func (p *PlistVisitor) visitDict(dict *Dict) (string, error) {
+	if len(dict.Keys) == 0 {
+		return "{}", nil
+	}
+
+	e := []string{}
+	for _, key := range dict.Keys {
+		path, value := nix.CollapsePath(key, dict.Values[key], p.single, &p.options.NixOutput)
+
+		p.i.Indent()
+		s, err := p.visit(value)
+		if err != nil {
+			return "", err
+		}
+
+		left := nix.MakeAttrPath(path, p.options.UnsafeKeys)
+		e = append(e, p.i.IndentValue()+left+" = "+s+";")
+		p.i.UnIndent()
+	}
+
+	if p.options.SortIterators.SortHashmap {
+		slices.Sort(e)
+	}
+
+	return "{\n" + strings.Join(e, "\n") + "\n" + p.i.IndentValue() + "}", nil
+}
+
+func (p *PlistVisitor) visitArray(array []any) (string, error) {
+	if len(array) == 0 {
+		return "[]", nil
+	}
+
+	e := []string{}
+	for _, item := range array {
+		p.i.Indent()
+		s, err := p.visit(item)
+		if err != nil {
+			return "", err
+		}
+
+		e = append(e, p.i.IndentValue()+nix.MakeElementSafe(s))
+		p.i.UnIndent()
+	}
+
+	if p.options.SortIterators.SortList {
+		slices.Sort(e)
+	}
+
+	return "[\n" + strings.Join(e, "\n") + "\n" + p.i.IndentValue() + "]", nil
+}
+
+func (p *PlistVisitor) visit(node any) (string, error) {
+	if err := p.limiter.Enter(); err != nil {
+		return "", err
+	}
+	defer p.limiter.Leave()
+
+	switch v := node.(type) {
+	case *Dict:
+		return p.visitDict(v)
+	case []any:
+		return p.visitArray(v)
+	case string:
+		return p.visitString(v), nil
+	case int64:
+		return nix.FormatInt(v), nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return "", fmt.Errorf("the real %v has no Nix representation", v)
+		}
+		return nix.FormatFloat(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case Date:
+		return p.visitTagged(TypeDate, time.Time(v).UTC().Format(DateFormat)), nil
+	case Data:
+		return p.visitTagged(TypeData, base64.StdEncoding.EncodeToString(v)), nil
+	case UID:
+		return p.visitSet([]string{UIDKey}, []string{strconv.FormatUint(uint64(v), 10)}), nil
+	default:
+		return "", fmt.Errorf("unsupported value type: %T", v)
+	}
+}
+
+func (p *PlistVisitor) Visit() (string, error) {
+	out, err := p.visit(p.node)
+	if err != nil {
+		return "", err
+	}
+
+	return p.limiter.Done(out)
+}
+
+func ToNix(data string, options *converter.ConverterOptions) (string, error) {
+	var (
+		node any
+		err  error
+	)
+
+	if strings.HasPrefix(data, "bplist") {
+		if !strings.HasPrefix(data, BinaryMagic) {
+			return "", fmt.Errorf("unsupported binary property list version %q", data[:min(len(data), 8)])
+		}
+		node, err = parseBinary([]byte(data), options.DuplicateKeys.Policy)
+	} else {
+		node, err = parseXML(data, options.DuplicateKeys.Policy)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	out, err := NewPlistVisitor(node, options).Visit()
+	if err != nil {
+		return "", err
+	}
+
+	return nix.Layout(out, &options.NixOutput), nil
+}
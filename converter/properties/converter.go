@@ -0,0 +1,27 @@
+package properties
+
+import "github.com/theobori/nix-converter/converter"
+
+type PropertiesConverter struct {
+	data    string
+	options *converter.ConverterOptions
+}
+
+func NewPropertiesConverter(data string, options *converter.ConverterOptions) *PropertiesConverter {
+	return &PropertiesConverter{
+		data,
+		options,
+	}
+}
+
+func (p *PropertiesConverter) FromNix() (string, error) {
+	return FromNix(p.data, p.options)
+}
+
+func (p *PropertiesConverter) ToNix() (string, error) {
+	return ToNix(p.data, p.options)
+}
+
+func (p *PropertiesConverter) Type() string {
+	return "properties"
+}
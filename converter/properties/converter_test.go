@@ -0,0 +1,159 @@
+package properties
+
+import (
+	"testing"
+
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/options"
+	"github.com/theobori/nix-converter/internal/common"
+)
+
+var propertiesStrings = []string{
+	`server.port=8080
+server.address=0.0.0.0
+spring.datasource.url=jdbc:h2:mem:test
+spring.datasource.username=sa
+greeting=caf\u00E9 \uD83D\uDE80
+path=C:\\Program Files\\App
+key\ with\=separators\:=value
+multiline=first\nsecond
+\#not-a-comment=x
+padded=\  leading`,
+}
+
+var nixStrings = []string{
+	`{
+  server = {
+    port = "8080";
+    ssl = {
+      enabled = "true";
+    };
+  };
+  text = ''
+    first
+    second'';
+}`,
+}
+
+func TestPropertiesToNix(t *testing.T) {
+	t.Parallel()
+	common.TestHelperToNixStrings(t, propertiesStrings, FromNix, ToNix, converter.NewDefaultConverterOptions())
+}
+
+func TestPropertiesFromNix(t *testing.T) {
+	t.Parallel()
+	options := converter.ConverterOptions{
+		SortIterators: *options.NewDefaultSortIterators(),
+		UnsafeKeys:    true,
+	}
+
+	common.TestHelperFromNixStrings(t, nixStrings, FromNix, ToNix, &options)
+}
+
+func TestPropertiesSyntax(t *testing.T) {
+	t.Parallel()
+	options := converter.NewDefaultConverterOptions()
+	options.UnsafeKeys = true
+
+	input := `# Spring configuration
+! Server
+server.port : 8080
+server.address   127.0.0.1
+app.description = A long \
+                  description
+app.empty
+app.name=Caf\u00e9`
+
+	want := `{
+  server = {
+    # Spring configuration
+    # Server
+    port = "8080";
+    address = "127.0.0.1";
+  };
+  app = {
+    description = "A long description";
+    empty = "";
+    name = "Café";
+  };
+}`
+
+	output, err := ToNix(input, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if output != want {
+		t.Errorf("ToNix() = \n%v, want \n%v", output, want)
+	}
+}
+
+func TestPropertiesPrefixValue(t *testing.T) {
+	t.Parallel()
+	options := converter.NewDefaultConverterOptions()
+	options.UnsafeKeys = true
+
+	input := "a=1\na.b=2\nc.d=3\n# The value of c\nc=4"
+
+	want := `{
+  a = {
+    _value = "1";
+    b = "2";
+  };
+  # The value of c
+  c = {
+    _value = "4";
+    d = "3";
+  };
+}`
+
+	output, err := ToNix(input, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if output != want {
+		t.Errorf("ToNix() = \n%v, want \n%v", output, want)
+	}
+
+	output, err = FromNix(want, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "a=1\na.b=2\nc=4\nc.d=3"; output != want {
+		t.Errorf("FromNix() = \n%v, want \n%v", output, want)
+	}
+}
+
+func TestPropertiesErrors(t *testing.T) {
+	t.Parallel()
+	propertiesInputs := []string{
+		"a=1\na._value=2",
+		"a._value.b=1",
+		"a..b=1",
+		`a=\u12`,
+	}
+
+	for _, input := range propertiesInputs {
+		if _, err := ToNix(input, converter.NewDefaultConverterOptions()); err == nil {
+			t.Errorf("ToNix(%q) expected an error", input)
+		}
+	}
+
+	nixInputs := []string{
+		`[ "a" ]`,
+		`{ a = [ "x" ]; }`,
+		`{ a = null; }`,
+		`{ a = { }; }`,
+		`{ "" = "x"; }`,
+		`{ "a.b" = "x"; }`,
+		`{ a._value.b = "x"; }`,
+	}
+
+	for _, input := range nixInputs {
+		if _, err := FromNix(input, converter.NewDefaultConverterOptions()); err == nil {
+			t.Errorf("FromNix(%q) expected an error", input)
+		}
+	}
+}
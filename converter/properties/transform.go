@@ -0,0 +1,98 @@
+package properties
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Unescape the escape sequences of a key or a value, including \uXXXX
+func Unescape(s string) (string, error) {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		i++
+		if i == len(s) {
+			break
+		}
+
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+4 >= len(s) {
+				return "", fmt.Errorf("malformed \\uXXXX encoding")
+			}
+
+			r, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("malformed \\uXXXX encoding")
+			}
+			i += 4
+
+			// Characters outside of the BMP are written as surrogate pairs
+			if utf16.IsSurrogate(rune(r)) && i+6 < len(s) && s[i+1:i+3] == "\\u" {
+				low, err := strconv.ParseUint(s[i+3:i+7], 16, 16)
+				if err == nil {
+					b.WriteRune(utf16.DecodeRune(rune(r), rune(low)))
+					i += 6
+					continue
+				}
+			}
+
+			b.WriteRune(rune(r))
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+
+	return b.String(), nil
+}
+
+// Escape a key or a value, non ASCII characters are written as \uXXXX
+func Escape(s string, isKey bool) string {
+	var b strings.Builder
+
+	for i, r := range s {
+		switch {
+		case r == '\\':
+			b.WriteString("\\\\")
+		case r == '\t':
+			b.WriteString("\\t")
+		case r == '\n':
+			b.WriteString("\\n")
+		case r == '\r':
+			b.WriteString("\\r")
+		case r == '\f':
+			b.WriteString("\\f")
+		case r == ' ' && (isKey || i == 0):
+			b.WriteString("\\ ")
+		case (r == '=' || r == ':') && isKey:
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case (r == '#' || r == '!') && isKey && i == 0:
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			for _, c := range utf16.Encode([]rune{r}) {
+				fmt.Fprintf(&b, "\\u%04X", c)
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,246 @@
+package properties
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/nix"
+	"github.com/theobori/nix-converter/converter/options"
+	"github.com/theobori/nix-converter/internal/common"
+)
+
+// ValueKey holds the value of a key that is also the prefix of longer keys,
+// like "a" with "a.b", in the set of the key
+const ValueKey = "_value"
+
+// Entry is a value, a set of entries created by splitting dotted keys, or both
+type Entry struct {
+	key      string
+	comments []string
+	value    *string
+	children []*Entry
+	index    map[string]*Entry
+}
+
+func newEntry(key string) *Entry {
+	return &Entry{key: key, index: map[string]*Entry{}}
+}
+
+func (e *Entry) child(key string) *Entry {
+	c, exists := e.index[key]
+	if !exists {
+		c = newEntry(key)
+		e.index[key] = c
+		e.children = append(e.children, c)
+	}
+
+	return c
+}
+
+type PropertiesVisitor struct {
+	i       common.Indentation
+	root    *Entry
+	limiter *converter.Limiter
+	options *converter.ConverterOptions
+}
+
+func NewPropertiesVisitor(root *Entry, options *converter.ConverterOptions) *PropertiesVisitor {
+	return &PropertiesVisitor{
+		i:       *common.NewIndentationUnit(options.NixIndentUnit()),
+		root:    root,
+		limiter: converter.NewLimiter(options),
+		options: options,
+	}
+}
+
+func (p *PropertiesVisitor) visitValue(s string) string {
+	if strings.Contains(s, "\n") {
+		return common.MakeIndentedString(s, p.i.IndentValue(), p.i.Unit())
+	}
+
+	return common.MakeStringSafe(s)
+}
+
+// Returns the single child of an entry, the comments of an entry are kept
+// above its own key
+func (p *PropertiesVisitor) single(entry *Entry) (string, *Entry, bool) {
+	if entry.value != nil || len(entry.children) != 1 || len(entry.children[0].comments) > 0 {
+		return "", nil, false
+	}
+
+	child := entry.children[0]
+
+	return child.key, child, true
+}
+
+// visitEntry stops at the first exceeded limit, the error is returned by Err
+func (p *PropertiesVisitor) visitEntry(entry *Entry) string {
+	if p.limiter.Enter() != nil {
+		return ""
+	}
+	defer p.limiter.Leave()
+
+	if entry.value != nil && len(entry.children) == 0 {
+		return p.visitValue(*entry.value)
+	}
+
+	if len(entry.children) == 0 {
+		return "{}"
+	}
+
+	children := entry.children
+	if p.options.SortIterators.SortHashmap {
+		children = slices.Clone(children)
+		slices.SortStableFunc(children, func(a, b *Entry) int {
+			return strings.Compare(a.key, b.key)
+		})
+	}
+
+	e := []string{}
+	p.i.Indent()
+	if entry.value != nil {
+		key := nix.MakeNameSafe(ValueKey, p.options.UnsafeKeys)
+		e = append(e, p.i.IndentValue()+key+" = "+p.visitValue(*entry.value)+";")
+	}
+	for _, child := range children {
+		for _, comment := range child.comments {
+			e = append(e, p.i.IndentValue()+strings.TrimRight("# "+comment, " "))
+		}
+
+		path, last := nix.CollapsePath(child.key, child, p.single, &p.options.NixOutput)
+		key := nix.MakeAttrPath(path, p.options.UnsafeKeys)
+		e = append(e, p.i.IndentValue()+key+" = "+p.visitEntry(last)+";")
+	}
+	p.i.UnIndent()
+
+	return "{\n" + strings.Join(e, "\n") + "\n" + p.i.IndentValue() + "}"
+}
+
+func (p *PropertiesVisitor) Visit() string {
+	return p.visitEntry(p.root)
+}
+
+// Err returns the error of the first limit exceeded by Visit
+func (p *PropertiesVisitor) Err() error {
+	return p.limiter.Err()
+}
+
+func isWhitespace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\f'
+}
+
+// A line is continued when it ends with an odd number of backslashes
+func isContinued(line string) bool {
+	n := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		n++
+	}
+
+	return n%2 == 1
+}
+
+// Split a logical line between its raw key and its raw value
+func splitKeyValue(line string) (string, string) {
+	i := 0
+	for ; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+		if line[i] == '=' || line[i] == ':' || isWhitespace(line[i]) {
+			break
+		}
+	}
+
+	if i > len(line) {
+		i = len(line)
+	}
+
+	key := line[:i]
+	rest := strings.TrimLeft(line[i:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+
+	return key, rest
+}
+
+// Parse reads a properties document and splits the dotted keys, the value of
+// a repeated key is chosen by the policy
+func Parse(data string, policy string) (*Entry, error) {
+	root := newEntry("")
+	comments := []string{}
+
+	lines := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+	for n := 0; n < len(lines); n++ {
+		lineNumber := n + 1
+		line := strings.TrimLeft(lines[n], " \t\f")
+
+		if line == "" {
+			continue
+		}
+
+		if line[0] == '#' || line[0] == '!' {
+			comments = append(comments, strings.TrimPrefix(line[1:], " "))
+			continue
+		}
+
+		for isContinued(line) && n+1 < len(lines) {
+			n++
+			line = line[:len(line)-1] + strings.TrimLeft(lines[n], " \t\f")
+		}
+
+		rawKey, rawValue := splitKeyValue(line)
+
+		key, err := Unescape(rawKey)
+		if err != nil {
+			return nil, converter.Errorf(lineNumber, 0, "%w", err)
+		}
+
+		value, err := Unescape(rawValue)
+		if err != nil {
+			return nil, converter.Errorf(lineNumber, 0, "%w", err)
+		}
+
+		segments := strings.Split(key, ".")
+		if slices.Contains(segments, "") {
+			return nil, converter.Errorf(lineNumber, 0, "the key '%s' has an empty segment", key)
+		}
+
+		// "a._value" is the value of "a", it may also be a prefix
+		if len(segments) > 1 && segments[len(segments)-1] == ValueKey {
+			segments = segments[:len(segments)-1]
+		}
+		if slices.Contains(segments[1:], ValueKey) {
+			return nil, converter.Errorf(lineNumber, 0, "the key '%s' has a %s segment before its end", key, ValueKey)
+		}
+
+		curr := root
+		for _, segment := range segments {
+			curr = curr.child(segment)
+		}
+
+		if curr.value != nil && policy == options.DuplicateKeysError {
+			return nil, nix.DuplicateKeyError(lineNumber, 0, key)
+		}
+		if curr.value == nil || policy != options.DuplicateKeysFirstWins {
+			curr.value = &value
+		}
+		curr.comments = append(curr.comments, comments...)
+		comments = []string{}
+	}
+
+	return root, nil
+}
+
+func ToNix(data string, options *converter.ConverterOptions) (string, error) {
+	root, err := Parse(data, options.DuplicateKeys.Policy)
+	if err != nil {
+		return "", err
+	}
+
+	visitor := NewPropertiesVisitor(root, options)
+
+	return visitor.limiter.Done(nix.Layout(visitor.Visit(), &options.NixOutput))
+}
@@ -0,0 +1,72 @@
+package converter
+
+import (
+	"context"
+	"io"
+	"strings"
+)
+
+// StreamConverter reads its input from r and writes its output to w. The
+// output is written as it is produced only by the incremental conversions,
+// like json.ToNixStream, the others hold their whole input first.
+type StreamConverter interface {
+	Convert(ctx context.Context, r io.Reader, w io.Writer) error
+}
+
+// StreamFunc is a conversion from a reader to a writer
+type StreamFunc func(ctx context.Context, r io.Reader, w io.Writer, options *ConverterOptions) error
+
+type streamConverter struct {
+	f       StreamFunc
+	options *ConverterOptions
+}
+
+// NewStreamConverter returns a StreamConverter calling f with options
+func NewStreamConverter(f StreamFunc, options *ConverterOptions) StreamConverter {
+	return &streamConverter{
+		f,
+		options,
+	}
+}
+
+func (s *streamConverter) Convert(ctx context.Context, r io.Reader, w io.Writer) error {
+	options := s.options.WithContext(ctx)
+
+	return s.f(ctx, r, NewLimiter(options).Writer(w), options)
+}
+
+// Buffered returns a StreamFunc for a format that is not converted
+// incrementally, the whole input is read before being converted
+func Buffered(convert func(data string, options *ConverterOptions) (string, error)) StreamFunc {
+	return func(ctx context.Context, r io.Reader, w io.Writer, options *ConverterOptions) error {
+		var b strings.Builder
+		if _, err := io.Copy(&b, r); err != nil {
+			return err
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		out, err := convert(b.String(), options)
+		if err != nil {
+			return err
+		}
+
+		_, err = io.WriteString(w, out)
+		return err
+	}
+}
+
+// ConvertString runs a StreamFunc on a string, for the string API of the
+// formats converted incrementally
+func ConvertString(f StreamFunc, data string, options *ConverterOptions) (string, error) {
+	var b strings.Builder
+
+	err := f(options.Context(), strings.NewReader(data), &b, options)
+	if err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,27 @@
+package textproto
+
+import "github.com/theobori/nix-converter/converter"
+
+type TextprotoConverter struct {
+	data    string
+	options *converter.ConverterOptions
+}
+
+func NewTextprotoConverter(data string, options *converter.ConverterOptions) *TextprotoConverter {
+	return &TextprotoConverter{
+		data,
+		options,
+	}
+}
+
+func (t *TextprotoConverter) FromNix() (string, error) {
+	return FromNix(t.data, t.options)
+}
+
+func (t *TextprotoConverter) ToNix() (string, error) {
+	return ToNix(t.data, t.options)
+}
+
+func (t *TextprotoConverter) Type() string {
+	return "textproto"
+}
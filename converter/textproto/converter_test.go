@@ -0,0 +1,253 @@
+package textproto
+
+import (
+	"testing"
+
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/options"
+	"github.com/theobori/nix-converter/internal/common"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+var textprotoStrings = []string{
+	`name: "server"
+port: 8080
+ratio: -1.5
+mode: "FAST"
+enabled: true
+tags: ["a", "b\tc"]
+backend {
+  host: "h1"
+  weight: 16
+}
+backend {
+  host: "h2\n"
+}
+limits {
+  cpu: -2
+  nested {}
+}
+empty: []
+[ext.field]: "é"`,
+	``,
+}
+
+var nixStrings = []string{
+	`{
+  name = "server";
+  backends = [
+    {
+      host = "h1";
+    }
+    {
+      host = ''
+        h2
+        h3
+      '';
+    }
+  ];
+  limits = {
+    cpu = 1.5;
+    flags = [
+      true
+      false
+    ];
+  };
+}`,
+	`{}`,
+}
+
+const descriptorSet = `
+file {
+  name: "config.proto"
+  package: "test.v1"
+  syntax: "proto3"
+  message_type {
+    name: "Config"
+    field { name: "name" number: 1 type: TYPE_STRING label: LABEL_OPTIONAL }
+    field { name: "port" number: 2 type: TYPE_INT32 label: LABEL_OPTIONAL }
+    field { name: "mode" number: 3 type: TYPE_ENUM type_name: ".test.v1.Mode" label: LABEL_OPTIONAL }
+    field { name: "tags" number: 4 type: TYPE_STRING label: LABEL_REPEATED }
+    field { name: "backends" number: 5 type: TYPE_MESSAGE type_name: ".test.v1.Backend" label: LABEL_REPEATED }
+    field { name: "labels" number: 6 type: TYPE_MESSAGE type_name: ".test.v1.Config.LabelsEntry" label: LABEL_REPEATED }
+    field { name: "ratio" number: 7 type: TYPE_DOUBLE label: LABEL_OPTIONAL }
+    field { name: "data" number: 8 type: TYPE_BYTES label: LABEL_OPTIONAL }
+    field { name: "big" number: 9 type: TYPE_UINT64 label: LABEL_OPTIONAL }
+    nested_type {
+      name: "LabelsEntry"
+      field { name: "key" number: 1 type: TYPE_STRING label: LABEL_OPTIONAL }
+      field { name: "value" number: 2 type: TYPE_INT64 label: LABEL_OPTIONAL }
+      options { map_entry: true }
+    }
+  }
+  message_type {
+    name: "Backend"
+    field { name: "host" number: 1 type: TYPE_STRING label: LABEL_OPTIONAL }
+    field { name: "weight" number: 2 type: TYPE_UINT32 label: LABEL_OPTIONAL }
+  }
+  enum_type {
+    name: "Mode"
+    value { name: "MODE_UNSPECIFIED" number: 0 }
+    value { name: "FAST" number: 1 }
+  }
+}`
+
+func schemaOptions(t *testing.T) *converter.ConverterOptions {
+	set := &descriptorpb.FileDescriptorSet{}
+	err := prototext.Unmarshal([]byte(descriptorSet), set)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := proto.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	textproto, err := options.NewTextproto(b, "test.v1.Config")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o := converter.NewDefaultConverterOptions()
+	o.UnsafeKeys = true
+	o.Textproto = *textproto
+
+	return o
+}
+
+func TestTextprotoToNix(t *testing.T) {
+	t.Parallel()
+	common.TestHelperToNixStrings(t, textprotoStrings, FromNix, ToNix, converter.NewDefaultConverterOptions())
+}
+
+func TestTextprotoFromNix(t *testing.T) {
+	t.Parallel()
+	options := converter.ConverterOptions{
+		SortIterators: *options.NewDefaultSortIterators(),
+		UnsafeKeys:    true,
+	}
+
+	common.TestHelperFromNixStrings(t, nixStrings, FromNix, ToNix, &options)
+}
+
+func TestTextprotoSchema(t *testing.T) {
+	t.Parallel()
+	options := schemaOptions(t)
+
+	input := `# the fields are typed from the schema
+name: "server"
+mode: FAST
+tags: "single"
+backends { host: "h1" weight: 1 }
+labels { key: "b" value: 2 }
+labels { key: "a" value: 1 }
+ratio: 1
+data: "\x00\xff"`
+
+	want := `{
+  name = "server";
+  mode = "FAST";
+  tags = [
+    "single"
+  ];
+  backends = [
+    {
+      host = "h1";
+      weight = 1;
+    }
+  ];
+  labels = {
+    a = 1;
+    b = 2;
+  };
+  ratio = 1.0;
+  data = "AP8=";
+}`
+
+	output, err := ToNix(input, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if output != want {
+		t.Errorf("ToNix() = \n%v, want \n%v", output, want)
+	}
+
+	common.TestHelperFromNix(t, want, FromNix, ToNix, options)
+}
+
+func TestTextprotoErrors(t *testing.T) {
+	t.Parallel()
+	textprotoInputs := []string{
+		`a {`,
+		`a: `,
+		`a 1`,
+		`a: "unterminated`,
+		`a: "\q"`,
+		`a: [1, 2`,
+		`a: 99999999999999999999`,
+		`a: inf`,
+		`a: -FOO`,
+		`a: "\377"`,
+		`1a: 1`,
+	}
+
+	for _, input := range textprotoInputs {
+		if _, err := ToNix(input, converter.NewDefaultConverterOptions()); err == nil {
+			t.Errorf("ToNix(%q) expected an error", input)
+		}
+	}
+
+	nixInputs := []string{
+		`[ 1 ]`,
+		`{ a = null; }`,
+		`{ a = [ [ 1 ] ]; }`,
+		`{ a = [ { } 1 ]; }`,
+		`{ "a b" = 1; }`,
+	}
+
+	for _, input := range nixInputs {
+		if _, err := FromNix(input, converter.NewDefaultConverterOptions()); err == nil {
+			t.Errorf("FromNix(%q) expected an error", input)
+		}
+	}
+}
+
+func TestTextprotoSchemaErrors(t *testing.T) {
+	t.Parallel()
+	options := schemaOptions(t)
+
+	textprotoInputs := []string{
+		`unknown: 1`,
+		`port: "a"`,
+		`mode: SLOW`,
+		`big: 18446744073709551615`,
+	}
+
+	for _, input := range textprotoInputs {
+		if _, err := ToNix(input, options); err == nil {
+			t.Errorf("ToNix(%q) expected an error", input)
+		}
+	}
+
+	nixInputs := []string{
+		`{ unknown = 1; }`,
+		`{ port = "a"; }`,
+		`{ port = 99999999999; }`,
+		`{ mode = "SLOW"; }`,
+		`{ name = [ "a" ]; }`,
+		`{ backends = [ 1 ]; }`,
+		`{ labels = [ ]; }`,
+		`{ data = "not base64"; }`,
+		`{ ratio = true; }`,
+	}
+
+	for _, input := range nixInputs {
+		if _, err := FromNix(input, options); err == nil {
+			t.Errorf("FromNix(%q) expected an error", input)
+		}
+	}
+}
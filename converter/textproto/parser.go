@@ -0,0 +1,366 @@
+package textproto
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/internal/common"
+)
+
+type textprotoParser struct {
+	s   string
+	pos int
+}
+
+func (p *textprotoParser) errorf(format string, a ...any) error {
+	before := p.s[:min(p.pos, len(p.s))]
+	line := 1 + strings.Count(before, "\n")
+	column := len(before) - strings.LastIndexByte(before, '\n')
+	return converter.Errorf(line, column, format, a...)
+}
+
+func (p *textprotoParser) peek() int {
+	if p.pos >= len(p.s) {
+		return -1
+	}
+
+	return int(p.s[p.pos])
+}
+
+// Skips whitespace and comments
+func (p *textprotoParser) skipSpace() {
+	for {
+		switch c := p.peek(); c {
+		case ' ', '\t', '\n', '\r', '\f', '\v':
+			p.pos++
+		case '#':
+			for p.peek() != -1 && p.peek() != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *textprotoParser) consume(c byte) bool {
+	p.skipSpace()
+	if p.peek() != int(c) {
+		return false
+	}
+
+	p.pos++
+
+	return true
+}
+
+func (p *textprotoParser) identifier() string {
+	start := p.pos
+	for p.peek() != -1 && IsIdentifierChar(byte(p.peek())) {
+		p.pos++
+	}
+
+	return p.s[start:p.pos]
+}
+
+// Reads a field name, an extension or an Any type URL is kept with its brackets
+func (p *textprotoParser) fieldName() (string, error) {
+	p.skipSpace()
+
+	if p.peek() != '[' {
+		name := p.identifier()
+		if !IsIdentifierValid(name) {
+			return "", p.errorf("expected a field name")
+		}
+		return name, nil
+	}
+
+	p.pos++
+	start := p.pos
+	for p.peek() != -1 && p.peek() != ']' {
+		p.pos++
+	}
+	if p.peek() == -1 {
+		return "", p.errorf("unterminated field name")
+	}
+
+	name := strings.Join(strings.Fields(p.s[start:p.pos]), "")
+	p.pos++
+	if name == "" {
+		return "", p.errorf("empty field name")
+	}
+
+	return "[" + name + "]", nil
+}
+
+func (p *textprotoParser) quoted() (string, error) {
+	quote := p.s[p.pos]
+	p.pos++
+
+	var b strings.Builder
+	for {
+		c := p.peek()
+		switch c {
+		case -1, '\n':
+			return "", p.errorf("unterminated string")
+		case int(quote):
+			p.pos++
+			return b.String(), nil
+		case '\\':
+			p.pos++
+		default:
+			b.WriteByte(byte(c))
+			p.pos++
+			continue
+		}
+
+		c = p.peek()
+		p.pos++
+		switch c {
+		case 'a':
+			b.WriteByte('\a')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'v':
+			b.WriteByte('\v')
+		case '\\', '\'', '"', '?':
+			b.WriteByte(byte(c))
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			start := p.pos - 1
+			for p.pos < start+3 && p.peek() >= '0' && p.peek() <= '7' {
+				p.pos++
+			}
+			n, _ := strconv.ParseUint(p.s[start:p.pos], 8, 16)
+			if n > 0xff {
+				return "", p.errorf("invalid octal escape sequence")
+			}
+			b.WriteByte(byte(n))
+		case 'x', 'X', 'u', 'U':
+			size := map[int]int{'x': 2, 'X': 2, 'u': 4, 'U': 8}[c]
+			start := p.pos
+			for p.pos < start+size && p.peek() != -1 && strings.IndexByte("0123456789abcdefABCDEF", byte(p.peek())) >= 0 {
+				p.pos++
+			}
+			if p.pos == start || c != 'x' && c != 'X' && p.pos != start+size {
+				return "", p.errorf("invalid escape sequence '\\%c'", c)
+			}
+			n, _ := strconv.ParseUint(p.s[start:p.pos], 16, 32)
+			if c == 'x' || c == 'X' {
+				b.WriteByte(byte(n))
+			} else if n > utf8.MaxRune || n >= 0xd800 && n <= 0xdfff {
+				return "", p.errorf("invalid unicode code point %x", n)
+			} else {
+				b.WriteRune(rune(n))
+			}
+		default:
+			return "", p.errorf("invalid escape sequence '\\%c'", c)
+		}
+	}
+}
+
+// Reads adjacent strings, they are concatenated
+func (p *textprotoParser) string() (string, error) {
+	var b strings.Builder
+	for {
+		s, err := p.quoted()
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(s)
+
+		p.skipSpace()
+		if p.peek() != '"' && p.peek() != '\'' {
+			return b.String(), nil
+		}
+	}
+}
+
+func (p *textprotoParser) number(negative bool) (any, error) {
+	start := p.pos
+	for {
+		c := p.peek()
+		isExponentSign := (c == '+' || c == '-') && p.pos > start &&
+			(p.s[p.pos-1] == 'e' || p.s[p.pos-1] == 'E') && !strings.HasPrefix(strings.ToLower(p.s[start:]), "0x")
+		if c == -1 || !IsIdentifierChar(byte(c)) && c != '.' && !isExponentSign {
+			break
+		}
+		p.pos++
+	}
+
+	token := p.s[start:p.pos]
+	sign := ""
+	if negative {
+		sign = "-"
+	}
+
+	lower := strings.ToLower(token)
+	isHex := strings.HasPrefix(lower, "0x")
+	if isHex || !strings.ContainsAny(lower, ".ef") {
+		base := 10
+		digits := token
+		switch {
+		case isHex:
+			base, digits = 16, token[2:]
+		case len(token) > 1 && token[0] == '0':
+			base, digits = 8, token[1:]
+		}
+
+		i, err := strconv.ParseInt(sign+digits, base, 64)
+		if err != nil {
+			return nil, p.errorf("invalid integer '%s%s'", sign, token)
+		}
+		return i, nil
+	}
+
+	f, err := strconv.ParseFloat(sign+strings.TrimRight(token, "fF"), 64)
+	if err != nil || strings.Contains(lower, "_") {
+		return nil, p.errorf("invalid number '%s%s'", sign, token)
+	}
+
+	return f, nil
+}
+
+func (p *textprotoParser) scalar() (any, error) {
+	p.skipSpace()
+
+	negative := p.consume('-')
+	p.skipSpace()
+
+	c := p.peek()
+	switch {
+	case (c == '"' || c == '\'') && !negative:
+		s, err := p.string()
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	case c != -1 && (common.IsNumeric(byte(c)) || c == '.'):
+		return p.number(negative)
+	case c != -1 && IsIdentifierChar(byte(c)):
+		id := p.identifier()
+		switch strings.ToLower(id) {
+		case "inf", "infinity", "nan":
+			return nil, p.errorf("the number '%s' has no Nix representation", id)
+		}
+		if negative {
+			return nil, p.errorf("invalid value '-%s'", id)
+		}
+		return Identifier(id), nil
+	default:
+		return nil, p.errorf("expected a value")
+	}
+}
+
+func (p *textprotoParser) value() (any, error) {
+	p.skipSpace()
+
+	switch p.peek() {
+	case '{', '<':
+		return p.message()
+	default:
+		return p.scalar()
+	}
+}
+
+func (p *textprotoParser) list() (List, error) {
+	l := List{}
+	if p.consume(']') {
+		return l, nil
+	}
+
+	for {
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		l = append(l, v)
+
+		if p.consume(']') {
+			return l, nil
+		}
+		if !p.consume(',') {
+			return nil, p.errorf("expected ',' or ']'")
+		}
+	}
+}
+
+func (p *textprotoParser) field() (Field, error) {
+	name, err := p.fieldName()
+	if err != nil {
+		return Field{}, err
+	}
+
+	hasColon := p.consume(':')
+	p.skipSpace()
+
+	var v any
+	switch c := p.peek(); {
+	case c == '{' || c == '<':
+		v, err = p.message()
+	case !hasColon:
+		return Field{}, p.errorf("expected ':' after the field '%s'", name)
+	case c == '[':
+		p.pos++
+		v, err = p.list()
+	default:
+		v, err = p.scalar()
+	}
+	if err != nil {
+		return Field{}, err
+	}
+
+	// Fields may be separated by a comma or a semicolon
+	if !p.consume(',') {
+		p.consume(';')
+	}
+
+	return Field{name, v}, nil
+}
+
+// Reads the fields until the end delimiter, -1 being the end of the input
+func (p *textprotoParser) fields(end int) (*Message, error) {
+	m := &Message{Fields: []Field{}}
+	for {
+		p.skipSpace()
+		if p.peek() == end {
+			p.pos++
+			return m, nil
+		}
+		if p.peek() == -1 {
+			return nil, p.errorf("unexpected end of input")
+		}
+
+		f, err := p.field()
+		if err != nil {
+			return nil, err
+		}
+		m.Fields = append(m.Fields, f)
+	}
+}
+
+func (p *textprotoParser) message() (*Message, error) {
+	end := '}'
+	if p.peek() == '<' {
+		end = '>'
+	}
+	p.pos++
+
+	return p.fields(int(end))
+}
+
+// Parse reads a text format document, the fields of the root message
+func Parse(data string) (*Message, error) {
+	p := textprotoParser{s: data}
+
+	return p.fields(-1)
+}
@@ -0,0 +1,53 @@
+package textproto
+
+import (
+	"fmt"
+
+	"github.com/theobori/nix-converter/converter/options"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Schema is the descriptor of the document message and the types of the
+// descriptor set, used to resolve extensions and Any messages
+type Schema struct {
+	Message protoreflect.MessageDescriptor
+	Types   *dynamicpb.Types
+}
+
+// NewSchema loads the message schema from the options, it returns nil when
+// there is no descriptor set
+func NewSchema(o *options.Textproto) (*Schema, error) {
+	if o.DescriptorSet == nil {
+		return nil, nil
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	err := proto.Unmarshal(o.DescriptorSet, set)
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptor set: %w", err)
+	}
+
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptor set: %w", err)
+	}
+
+	d, err := files.FindDescriptorByName(protoreflect.FullName(o.Message))
+	if err != nil {
+		return nil, fmt.Errorf("unknown message '%s' in the descriptor set", o.Message)
+	}
+
+	md, isMessage := d.(protoreflect.MessageDescriptor)
+	if !isMessage {
+		return nil, fmt.Errorf("'%s' is not a message", o.Message)
+	}
+
+	return &Schema{
+		Message: md,
+		Types:   dynamicpb.NewTypes(files),
+	}, nil
+}
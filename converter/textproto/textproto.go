@@ -0,0 +1,32 @@
+// Package textproto converts Protocol Buffers text format documents
+// (.textproto, .txtpb) to Nix and vice versa.
+//
+// A message is a set whose keys are the field names. Without a schema, a
+// field is a list when it is repeated or written with the list syntax, enum
+// values are strings and integers are Nix integers.
+//
+// With a FileDescriptorSet and a message name, the document is checked
+// against the message schema and the fields are typed from it: repeated
+// fields are always lists, map fields are sets, enums are the names of their
+// values and bytes are base64 strings, like the protobuf JSON mapping. From
+// Nix, the values are checked against the field types and the strings of
+// enum fields are written as identifiers.
+package textproto
+
+// Message is a text format message, its fields are in the document order
+type Message struct {
+	Fields []Field
+}
+
+// Field is a field of a message, an extension or an expanded Any field has a
+// name between brackets
+type Field struct {
+	Name  string
+	Value any
+}
+
+// List is a value written with the list syntax, [1, 2]
+type List []any
+
+// Identifier is an identifier value, like an enum value name
+type Identifier string
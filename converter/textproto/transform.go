@@ -0,0 +1,40 @@
+package textproto
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Quote writes a double quoted string with the text format escape sequences,
+// the valid UTF-8 characters are kept
+func Quote(s string) string {
+	var b strings.Builder
+
+	b.WriteByte('"')
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+
+		switch {
+		case r == '"':
+			b.WriteString(`\"`)
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == utf8.RuneError && size == 1, r < 0x20, r == 0x7f:
+			fmt.Fprintf(&b, `\%03o`, s[i])
+		default:
+			b.WriteString(s[i : i+size])
+		}
+
+		i += size
+	}
+	b.WriteByte('"')
+
+	return b.String()
+}
@@ -0,0 +1,22 @@
+package textproto
+
+import "github.com/theobori/nix-converter/internal/common"
+
+func IsIdentifierChar(c byte) bool {
+	return common.IsCharAlphaNumeric(c) || c == '_'
+}
+
+// IsIdentifierValid reports whether s is a field name or an enum value name
+func IsIdentifierValid(s string) bool {
+	if s == "" || common.IsNumeric(s[0]) {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if !IsIdentifierChar(s[i]) {
+			return false
+		}
+	}
+
+	return true
+}
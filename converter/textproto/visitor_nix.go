@@ -0,0 +1,450 @@
+package textproto
+
+import (
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/orivej/go-nix/nix/parser"
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/nix"
+	"github.com/theobori/nix-converter/internal/common"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+type NixVisitor struct {
+	i       common.Indentation
+	p       *parser.Parser
+	errors  *nix.Collector
+	node    *parser.Node
+	schema  *Schema
+	options *converter.ConverterOptions
+}
+
+func NewNixVisitor(p *parser.Parser, node *parser.Node, schema *Schema, options *converter.ConverterOptions) *NixVisitor {
+	return &NixVisitor{
+		i:       *common.NewIndentationUnit(options.Indentation.Unit()),
+		p:       p,
+		errors:  nix.NewCollector(p, node, options),
+		node:    node,
+		schema:  schema,
+		options: options,
+	}
+}
+
+func unwrapParens(node *parser.Node) *parser.Node {
+	for node.Type == parser.ParensNode {
+		node = node.Nodes[0]
+	}
+
+	return node
+}
+
+// Returns the Go value of a scalar node, nil being null
+func (n *NixVisitor) scalar(node *parser.Node) (any, error) {
+	return nix.Visit(n.errors, node, n.scalarNode, nil)
+}
+
+func (n *NixVisitor) scalarNode(node *parser.Node) (any, error) {
+	switch node.Type {
+	case parser.StringNode, parser.IStringNode:
+		return nix.VisitStringValue(n.p, node)
+	case parser.IntNode:
+		return nix.VisitIntRaw(n.p, node)
+	case parser.FloatNode:
+		return nix.VisitFloatRaw(n.p, node)
+	case parser.ApplyNode:
+		return nix.VisitApplyRaw(n.p, node)
+	case parser.OpNode + 57378: // The negative unary operator
+		v, err := n.scalar(node.Nodes[0])
+		if err != nil {
+			return nil, err
+		}
+		switch v := v.(type) {
+		case int64:
+			return -v, nil
+		case float64:
+			return -v, nil
+		default:
+			return nil, fmt.Errorf("expected a number after '-'")
+		}
+	case parser.IDNode:
+		id, err := nix.VisitID(n.p, node)
+		if err != nil {
+			return nil, err
+		}
+		switch id {
+		case "true", "false":
+			return id == "true", nil
+		case "null":
+			return nil, nil
+		default:
+			return nil, fmt.Errorf("unsupported identifier: %s", id)
+		}
+	case parser.ParensNode:
+		return n.scalar(node.Nodes[0])
+	default:
+		return nil, fmt.Errorf("unsupported node type: %s", node.Type.String())
+	}
+}
+
+func isInteger(kind protoreflect.Kind) bool {
+	switch kind {
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return true
+	default:
+		return false
+	}
+}
+
+// Writes a scalar value, checked against the field type when there is one
+func (n *NixVisitor) literal(name string, fd protoreflect.FieldDescriptor, v any) (string, error) {
+	if v == nil {
+		return "", fmt.Errorf("%s: null has no text format representation", name)
+	}
+
+	if fd == nil {
+		switch v := v.(type) {
+		case string:
+			return Quote(v), nil
+		case int64:
+			return strconv.FormatInt(v, 10), nil
+		case float64:
+			return nix.FormatFloat(v), nil
+		default:
+			return strconv.FormatBool(v.(bool)), nil
+		}
+	}
+
+	kind := fd.Kind()
+	switch v := v.(type) {
+	case string:
+		switch kind {
+		case protoreflect.StringKind:
+			return Quote(v), nil
+		case protoreflect.BytesKind:
+			b, err := base64.StdEncoding.DecodeString(v)
+			if err != nil {
+				return "", fmt.Errorf("%s: invalid base64 bytes: %w", name, err)
+			}
+			return Quote(string(b)), nil
+		case protoreflect.EnumKind:
+			if fd.Enum().Values().ByName(protoreflect.Name(v)) == nil {
+				return "", fmt.Errorf("%s: unknown value '%s' of the enum %s", name, v, fd.Enum().FullName())
+			}
+			return v, nil
+		}
+	case int64:
+		if isInteger(kind) || kind == protoreflect.EnumKind {
+			return strconv.FormatInt(v, 10), nil
+		}
+		if kind == protoreflect.FloatKind || kind == protoreflect.DoubleKind {
+			return nix.FormatFloat(float64(v)), nil
+		}
+	case float64:
+		if kind == protoreflect.FloatKind || kind == protoreflect.DoubleKind {
+			return nix.FormatFloat(v), nil
+		}
+	case bool:
+		if kind == protoreflect.BoolKind {
+			return strconv.FormatBool(v), nil
+		}
+	}
+
+	return "", fmt.Errorf("%s: expected a value of type %s", name, kind)
+}
+
+func isMessage(fd protoreflect.FieldDescriptor) bool {
+	return fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind
+}
+
+// Finds the descriptor of a field, an extension name is between brackets
+func (n *NixVisitor) field(md protoreflect.MessageDescriptor, name string) (protoreflect.FieldDescriptor, error) {
+	if fd := md.Fields().ByTextName(name); fd != nil {
+		return fd, nil
+	}
+
+	if strings.HasPrefix(name, "[") && strings.HasSuffix(name, "]") {
+		xt, err := n.schema.Types.FindExtensionByName(protoreflect.FullName(name[1 : len(name)-1]))
+		if err == nil && xt.TypeDescriptor().ContainingMessage().FullName() == md.FullName() {
+			return xt.TypeDescriptor(), nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown field '%s' in the message %s", name, md.FullName())
+}
+
+func (n *NixVisitor) keys(tree *nix.AttrNode) []string {
+	if !n.options.SortIterators.SortHashmap {
+		return tree.Order
+	}
+
+	keys := slices.Clone(tree.Order)
+	slices.Sort(keys)
+
+	return keys
+}
+
+// Writes a message field as a block, its fields are one level deeper
+func (n *NixVisitor) visitBlock(name string, tree *nix.AttrNode, md protoreflect.MessageDescriptor) ([]string, error) {
+	if err := n.errors.Enter(tree.Value); err != nil {
+		return nil, err
+	}
+	defer n.errors.Leave()
+
+	n.i.Indent()
+	e, err := n.visitFields(tree, md)
+	n.i.UnIndent()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(e) == 0 {
+		return []string{n.i.IndentValue() + name + " {}"}, nil
+	}
+
+	e = append([]string{n.i.IndentValue() + name + " {"}, e...)
+
+	return append(e, n.i.IndentValue()+"}"), nil
+}
+
+// Map fields are repeated entries with a key and a value field
+func (n *NixVisitor) visitMap(name string, attr *nix.AttrNode, fd protoreflect.FieldDescriptor) ([]string, error) {
+	tree, isSet, err := attr.Set(n.p)
+	if err != nil {
+		return nil, err
+	}
+	if !isSet {
+		return nil, fmt.Errorf("%s: a map field must be a set", name)
+	}
+
+	e := []string{}
+	for _, k := range n.keys(tree) {
+		var key string
+		switch fd.MapKey().Kind() {
+		case protoreflect.StringKind:
+			key = Quote(k)
+		case protoreflect.BoolKind:
+			if k != "true" && k != "false" {
+				return nil, fmt.Errorf("%s: invalid boolean map key '%s'", name, k)
+			}
+			key = k
+		default:
+			if _, err := strconv.ParseInt(k, 10, 64); err != nil {
+				return nil, fmt.Errorf("%s: invalid integer map key '%s'", name, k)
+			}
+			key = k
+		}
+
+		e = append(e, n.i.IndentValue()+name+" {")
+
+		n.i.Indent()
+		e = append(e, n.i.IndentValue()+"key: "+key)
+		lines, err := n.visitAttr("value", tree.Children[k], fd.MapValue())
+		n.i.UnIndent()
+		if err != nil {
+			return nil, converter.AtKey(err, k)
+		}
+
+		e = append(e, lines...)
+		e = append(e, n.i.IndentValue()+"}")
+	}
+
+	return e, nil
+}
+
+func (n *NixVisitor) visitList(name string, node *parser.Node, fd protoreflect.FieldDescriptor) ([]string, error) {
+	var md protoreflect.MessageDescriptor
+	if fd != nil && isMessage(fd) {
+		md = fd.Message()
+	}
+
+	blocks := []string{}
+	values := []string{}
+	for index, child := range node.Nodes {
+		child = unwrapParens(child)
+
+		switch {
+		case child.Type == parser.ListNode:
+			return nil, fmt.Errorf("%s: nested lists have no text format representation", name)
+		case child.Type == parser.SetNode:
+			if fd != nil && md == nil {
+				return nil, fmt.Errorf("%s: expected a value of type %s", name, fd.Kind())
+			}
+			tree, err := n.errors.BuildAttrTree(child, n.options.DuplicateKeys.Policy)
+			if err != nil {
+				return nil, converter.AtIndex(err, index)
+			}
+			lines, err := n.visitBlock(name, tree, md)
+			if err != nil {
+				return nil, converter.AtIndex(err, index)
+			}
+			blocks = append(blocks, lines...)
+		case md != nil:
+			return nil, fmt.Errorf("%s: expected a set", name)
+		default:
+			v, err := n.scalar(child)
+			if err != nil {
+				return nil, converter.AtIndex(err, index)
+			}
+			s, err := n.literal(name, fd, v)
+			if err != nil {
+				return nil, converter.AtIndex(err, index)
+			}
+			values = append(values, s)
+		}
+	}
+
+	if len(blocks) > 0 && len(values) > 0 {
+		return nil, fmt.Errorf("%s: a list cannot mix sets and other values", name)
+	}
+
+	if len(blocks) > 0 {
+		return blocks, nil
+	}
+
+	// An empty repeated field of the schema is omitted
+	if len(values) == 0 && fd != nil {
+		return []string{}, nil
+	}
+
+	if n.options.SortIterators.SortList {
+		slices.Sort(values)
+	}
+
+	return []string{n.i.IndentValue() + name + ": [" + strings.Join(values, ", ") + "]"}, nil
+}
+
+func (n *NixVisitor) visitAttr(name string, attr *nix.AttrNode, fd protoreflect.FieldDescriptor) ([]string, error) {
+	if fd != nil && fd.IsMap() {
+		return n.visitMap(name, attr, fd)
+	}
+
+	if attr.Value != nil && unwrapParens(attr.Value).Type == parser.ListNode {
+		if fd != nil && !fd.IsList() {
+			return nil, fmt.Errorf("%s: the field is not repeated", name)
+		}
+		return n.visitList(name, unwrapParens(attr.Value), fd)
+	}
+
+	tree, isSet, err := attr.Set(n.p)
+	if err != nil {
+		return nil, err
+	}
+
+	if isSet {
+		var md protoreflect.MessageDescriptor
+		if fd != nil {
+			if !isMessage(fd) {
+				return nil, fmt.Errorf("%s: expected a value of type %s", name, fd.Kind())
+			}
+			md = fd.Message()
+		}
+		return n.visitBlock(name, tree, md)
+	}
+
+	v, err := n.scalar(attr.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	if fd != nil && isMessage(fd) {
+		return nil, fmt.Errorf("%s: expected a set", name)
+	}
+
+	s, err := n.literal(name, fd, v)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{n.i.IndentValue() + name + ": " + s}, nil
+}
+
+func (n *NixVisitor) visitFields(tree *nix.AttrNode, md protoreflect.MessageDescriptor) ([]string, error) {
+	e := []string{}
+	for _, k := range n.keys(tree) {
+		var fd protoreflect.FieldDescriptor
+		if md != nil {
+			var err error
+			fd, err = n.field(md, k)
+			if err != nil {
+				return nil, converter.AtKey(err, k)
+			}
+		} else if !IsIdentifierValid(k) && !(strings.HasPrefix(k, "[") && strings.HasSuffix(k, "]")) {
+			return nil, fmt.Errorf("invalid field name '%s'", k)
+		}
+
+		lines, err := n.visitAttr(k, tree.Children[k], fd)
+		if err != nil {
+			return nil, converter.AtKey(err, k)
+		}
+		e = append(e, lines...)
+	}
+
+	return e, nil
+}
+
+func (n *NixVisitor) visitRoot() (string, error) {
+	node := unwrapParens(n.node)
+	if node.Type != parser.SetNode {
+		return "", fmt.Errorf("the Nix expression must be a set")
+	}
+
+	tree, err := n.errors.BuildAttrTree(node, n.options.DuplicateKeys.Policy)
+	if err != nil {
+		return "", err
+	}
+
+	var md protoreflect.MessageDescriptor
+	if n.schema != nil {
+		md = n.schema.Message
+	}
+
+	e, err := n.visitFields(tree, md)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join(e, "\n"), nil
+}
+
+func (n *NixVisitor) Visit() (string, error) {
+	v, err := n.visitRoot()
+	return n.errors.Done(v, err)
+}
+
+func FromNix(data string, options *converter.ConverterOptions) (string, error) {
+	schema, err := NewSchema(&options.Textproto)
+	if err != nil {
+		return "", err
+	}
+
+	p, err := nix.Parse(data)
+	if err != nil {
+		return "", err
+	}
+
+	out, err := NewNixVisitor(p, p.Result, schema, options).Visit()
+	if err != nil {
+		return "", err
+	}
+
+	// The output is read back to check the constraints left, like the
+	// required fields or the integer ranges
+	if schema != nil {
+		m := dynamicpb.NewMessage(schema.Message)
+		err = prototext.UnmarshalOptions{Resolver: schema.Types}.Unmarshal([]byte(out), m)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,247 @@
+package textproto
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/nix"
+	"github.com/theobori/nix-converter/internal/common"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ProtoVisitor converts a message checked against its schema
+type ProtoVisitor struct {
+	i       common.Indentation
+	message protoreflect.Message
+	limiter *converter.Limiter
+	options *converter.ConverterOptions
+}
+
+func NewProtoVisitor(message protoreflect.Message, options *converter.ConverterOptions) *ProtoVisitor {
+	return &ProtoVisitor{
+		i:       *common.NewIndentationUnit(options.NixIndentUnit()),
+		message: message,
+		limiter: converter.NewLimiter(options),
+		options: options,
+	}
+}
+
+func (p *ProtoVisitor) visitString(s string) string {
+	if strings.Contains(s, "\n") {
+		return common.MakeIndentedString(s, p.i.IndentValue(), p.i.Unit())
+	}
+
+	return common.MakeStringSafe(s)
+}
+
+func (p *ProtoVisitor) visitSingular(fd protoreflect.FieldDescriptor, v protoreflect.Value) (string, error) {
+	if err := p.limiter.Enter(); err != nil {
+		return "", err
+	}
+	defer p.limiter.Leave()
+
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return strconv.FormatBool(v.Bool()), nil
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return nix.FormatInt(v.Int()), nil
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		if v.Uint() > math.MaxInt64 {
+			return "", fmt.Errorf("%s: integer %d is out of range", fd.FullName(), v.Uint())
+		}
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		f := v.Float()
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return "", fmt.Errorf("%s: the number %v has no Nix representation", fd.FullName(), f)
+		}
+		return nix.FormatFloat(f), nil
+	case protoreflect.StringKind:
+		return p.visitString(v.String()), nil
+	case protoreflect.BytesKind:
+		return p.visitString(base64.StdEncoding.EncodeToString(v.Bytes())), nil
+	case protoreflect.EnumKind:
+		ev := fd.Enum().Values().ByNumber(v.Enum())
+		if ev == nil {
+			return strconv.FormatInt(int64(v.Enum()), 10), nil
+		}
+		return p.visitString(string(ev.Name())), nil
+	default:
+		return p.visitMessage(v.Message())
+	}
+}
+
+func (p *ProtoVisitor) visitList(fd protoreflect.FieldDescriptor, l protoreflect.List) (string, error) {
+	if l.Len() == 0 {
+		return "[]", nil
+	}
+
+	e := []string{}
+	for i := 0; i < l.Len(); i++ {
+		p.i.Indent()
+		s, err := p.visitSingular(fd, l.Get(i))
+		if err != nil {
+			return "", err
+		}
+
+		e = append(e, p.i.IndentValue()+nix.MakeElementSafe(s))
+		p.i.UnIndent()
+	}
+
+	if p.options.SortIterators.SortList {
+		slices.Sort(e)
+	}
+
+	return "[\n" + strings.Join(e, "\n") + "\n" + p.i.IndentValue() + "]", nil
+}
+
+// Builds a set from its attribute paths and visited values, one indentation
+// level deeper
+func (p *ProtoVisitor) buildSet(paths [][]string, values []string, sort bool) string {
+	if len(paths) == 0 {
+		return "{}"
+	}
+
+	e := []string{}
+	p.i.Indent()
+	for i, path := range paths {
+		left := nix.MakeAttrPath(path, p.options.UnsafeKeys)
+		e = append(e, p.i.IndentValue()+left+" = "+values[i]+";")
+	}
+	p.i.UnIndent()
+
+	if sort {
+		slices.Sort(e)
+	}
+
+	return "{\n" + strings.Join(e, "\n") + "\n" + p.i.IndentValue() + "}"
+}
+
+// The map entries have no order, they are always sorted
+func (p *ProtoVisitor) visitMap(fd protoreflect.FieldDescriptor, m protoreflect.Map) (string, error) {
+	paths := [][]string{}
+	values := []string{}
+
+	var err error
+	p.i.Indent()
+	m.Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+		var s string
+		s, err = p.visitSingular(fd.MapValue(), v)
+		paths = append(paths, []string{k.String()})
+		values = append(values, s)
+		return err == nil
+	})
+	p.i.UnIndent()
+	if err != nil {
+		return "", err
+	}
+
+	return p.buildSet(paths, values, true), nil
+}
+
+func (p *ProtoVisitor) visitField(fd protoreflect.FieldDescriptor, v protoreflect.Value) (string, error) {
+	switch {
+	case fd.IsMap():
+		return p.visitMap(fd, v.Map())
+	case fd.IsList():
+		return p.visitList(fd, v.List())
+	default:
+		return p.visitSingular(fd, v)
+	}
+}
+
+func fieldName(fd protoreflect.FieldDescriptor) string {
+	if fd.IsExtension() {
+		return "[" + string(fd.FullName()) + "]"
+	}
+
+	// Group fields are named after their type in the text format
+	if fd.Kind() == protoreflect.GroupKind && fd.Message() != nil {
+		return string(fd.Message().Name())
+	}
+
+	return string(fd.Name())
+}
+
+// Returns the populated fields of a message in the declaration order, the
+// extensions last
+func populated(m protoreflect.Message) []protoreflect.FieldDescriptor {
+	fds := []protoreflect.FieldDescriptor{}
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		if m.Has(fields.Get(i)) {
+			fds = append(fds, fields.Get(i))
+		}
+	}
+
+	extensions := []protoreflect.FieldDescriptor{}
+	m.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		if fd.IsExtension() {
+			extensions = append(extensions, fd)
+		}
+		return true
+	})
+	slices.SortFunc(extensions, func(a, b protoreflect.FieldDescriptor) int {
+		return int(a.Number()) - int(b.Number())
+	})
+
+	return append(fds, extensions...)
+}
+
+// field is a populated field of a message
+type field struct {
+	fd    protoreflect.FieldDescriptor
+	value protoreflect.Value
+}
+
+// Returns the single populated field of a singular message field
+func (p *ProtoVisitor) single(f field) (string, field, bool) {
+	if f.fd.IsList() || f.fd.IsMap() || f.fd.Message() == nil {
+		return "", field{}, false
+	}
+
+	m := f.value.Message()
+	fds := populated(m)
+	if len(fds) != 1 {
+		return "", field{}, false
+	}
+
+	return fieldName(fds[0]), field{fds[0], m.Get(fds[0])}, true
+}
+
+func (p *ProtoVisitor) visitMessage(m protoreflect.Message) (string, error) {
+	paths := [][]string{}
+	values := []string{}
+	p.i.Indent()
+	for _, fd := range populated(m) {
+		path, f := nix.CollapsePath(fieldName(fd), field{fd, m.Get(fd)}, p.single, &p.options.NixOutput)
+
+		s, err := p.visitField(f.fd, f.value)
+		if err != nil {
+			p.i.UnIndent()
+			return "", err
+		}
+
+		paths = append(paths, path)
+		values = append(values, s)
+	}
+	p.i.UnIndent()
+
+	return p.buildSet(paths, values, p.options.SortIterators.SortHashmap), nil
+}
+
+func (p *ProtoVisitor) Visit() (string, error) {
+	out, err := p.visitMessage(p.message)
+	if err != nil {
+		return "", err
+	}
+
+	return p.limiter.Done(out)
+}
@@ -0,0 +1,211 @@
+package textproto
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/nix"
+	"github.com/theobori/nix-converter/internal/common"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+type TextprotoVisitor struct {
+	i       common.Indentation
+	message *Message
+	limiter *converter.Limiter
+	options *converter.ConverterOptions
+}
+
+func NewTextprotoVisitor(message *Message, options *converter.ConverterOptions) *TextprotoVisitor {
+	return &TextprotoVisitor{
+		i:       *common.NewIndentationUnit(options.NixIndentUnit()),
+		message: message,
+		limiter: converter.NewLimiter(options),
+		options: options,
+	}
+}
+
+func (t *TextprotoVisitor) visitString(s string) (string, error) {
+	if !utf8.ValidString(s) {
+		return "", fmt.Errorf("the string %s is not valid UTF-8", Quote(s))
+	}
+
+	if strings.Contains(s, "\n") {
+		return common.MakeIndentedString(s, t.i.IndentValue(), t.i.Unit()), nil
+	}
+
+	return common.MakeStringSafe(s), nil
+}
+
+func (t *TextprotoVisitor) visitValue(v any) (string, error) {
+	if err := t.limiter.Enter(); err != nil {
+		return "", err
+	}
+	defer t.limiter.Leave()
+
+	switch v := v.(type) {
+	case *Message:
+		return t.visitMessage(v)
+	case string:
+		return t.visitString(v)
+	case int64:
+		return nix.FormatInt(v), nil
+	case float64:
+		if math.IsInf(v, 0) {
+			return "", fmt.Errorf("the number %v is out of range", v)
+		}
+		return nix.FormatFloat(v), nil
+	case Identifier:
+		switch v {
+		case "true", "True":
+			return "true", nil
+		case "false", "False":
+			return "false", nil
+		}
+		return t.visitString(string(v))
+	default:
+		return "", fmt.Errorf("unsupported value %v", v)
+	}
+}
+
+func (t *TextprotoVisitor) visitList(values []any) (string, error) {
+	if len(values) == 0 {
+		return "[]", nil
+	}
+
+	e := []string{}
+	for _, v := range values {
+		t.i.Indent()
+		s, err := t.visitValue(v)
+		if err != nil {
+			return "", err
+		}
+
+		e = append(e, t.i.IndentValue()+nix.MakeElementSafe(s))
+		t.i.UnIndent()
+	}
+
+	if t.options.SortIterators.SortList {
+		slices.Sort(e)
+	}
+
+	return "[\n" + strings.Join(e, "\n") + "\n" + t.i.IndentValue() + "]", nil
+}
+
+// Returns the single field of a message, when it is not repeated
+func (t *TextprotoVisitor) single(v any) (string, any, bool) {
+	m, isMessage := v.(*Message)
+	if !isMessage || len(m.Fields) != 1 {
+		return "", nil, false
+	}
+
+	if _, isList := m.Fields[0].Value.(List); isList {
+		return "", nil, false
+	}
+
+	return m.Fields[0].Name, m.Fields[0].Value, true
+}
+
+func (t *TextprotoVisitor) visitMessage(m *Message) (string, error) {
+	if len(m.Fields) == 0 {
+		return "{}", nil
+	}
+
+	// The values of a repeated field are grouped under its first occurrence
+	names := []string{}
+	values := map[string][]any{}
+	isList := map[string]bool{}
+	for _, f := range m.Fields {
+		if _, exists := values[f.Name]; exists {
+			isList[f.Name] = true
+		} else {
+			names = append(names, f.Name)
+		}
+
+		if l, ok := f.Value.(List); ok {
+			isList[f.Name] = true
+			values[f.Name] = append(values[f.Name], l...)
+		} else {
+			values[f.Name] = append(values[f.Name], f.Value)
+		}
+	}
+
+	e := []string{}
+	for _, name := range names {
+		path := []string{name}
+
+		t.i.Indent()
+		var (
+			s   string
+			err error
+		)
+		if isList[name] {
+			s, err = t.visitList(values[name])
+		} else {
+			var value any
+			path, value = nix.CollapsePath(name, values[name][0], t.single, &t.options.NixOutput)
+			s, err = t.visitValue(value)
+		}
+		if err != nil {
+			return "", err
+		}
+
+		left := nix.MakeAttrPath(path, t.options.UnsafeKeys)
+		e = append(e, t.i.IndentValue()+left+" = "+s+";")
+		t.i.UnIndent()
+	}
+
+	if t.options.SortIterators.SortHashmap {
+		slices.Sort(e)
+	}
+
+	return "{\n" + strings.Join(e, "\n") + "\n" + t.i.IndentValue() + "}", nil
+}
+
+func (t *TextprotoVisitor) Visit() (string, error) {
+	out, err := t.visitMessage(t.message)
+	if err != nil {
+		return "", err
+	}
+
+	return t.limiter.Done(out)
+}
+
+func ToNix(data string, options *converter.ConverterOptions) (string, error) {
+	schema, err := NewSchema(&options.Textproto)
+	if err != nil {
+		return "", err
+	}
+
+	if schema == nil {
+		m, err := Parse(data)
+		if err != nil {
+			return "", err
+		}
+
+		out, err := NewTextprotoVisitor(m, options).Visit()
+		if err != nil {
+			return "", err
+		}
+
+		return nix.Layout(out, &options.NixOutput), nil
+	}
+
+	m := dynamicpb.NewMessage(schema.Message)
+	err = prototext.UnmarshalOptions{Resolver: schema.Types}.Unmarshal([]byte(data), m)
+	if err != nil {
+		return "", err
+	}
+
+	out, err := NewProtoVisitor(m, options).Visit()
+	if err != nil {
+		return "", err
+	}
+
+	return nix.Layout(out, &options.NixOutput), nil
+}
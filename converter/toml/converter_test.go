@@ -5,6 +5,7 @@ import (
 
 	"github.com/theobori/nix-converter/converter"
 	"github.com/theobori/nix-converter/converter/options"
+	"github.com/theobori/nix-converter/internal/common"
 )
 
 var tomlStrings = []string{
@@ -168,3 +169,57 @@ func TestTOMLFromNix(t *testing.T) {
 		}
 	}
 }
+
+func TestTOMLCollapsePaths(t *testing.T) {
+	t.Parallel()
+	options := converter.NewDefaultConverterOptions()
+	options.UnsafeKeys = true
+	options.NixOutput.CollapsePaths = true
+
+	input := `[servers.alpha]
+ip = "10.0.0.1"`
+
+	want := `{
+  servers.alpha.ip = "10.0.0.1";
+}`
+
+	output, err := ToNix(input, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if output != want {
+		t.Errorf("ToNix() = \n%v, want \n%v", output, want)
+	}
+}
+
+// The keys defined twice are always errors, located at the second key
+func TestTOMLErrorPosition(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x = 0\n\na = 1\n  a = 2\n", "line 4, column 3: key a is already defined"},
+		{"a = 1\n[a]\n", "line 2, column 2: key a should be a table, not a value"},
+		{"[t]\nb.c = 1\n[u]\nd = 2\n[t.b]\n", "line 5, column 2: table b already exists"},
+		{"a = ", "line 1, column 5: expected value, not eof"},
+	}
+
+	for _, policy := range []string{options.DuplicateKeysError, options.DuplicateKeysLastWins} {
+		o := converter.NewDefaultConverterOptions()
+		o.DuplicateKeys.Policy = policy
+
+		for _, tt := range tests {
+			_, err := ToNix(tt.input, o)
+			if err == nil || err.Error() != tt.expected {
+				t.Errorf("%q: expected the error %q, got %v", tt.input, tt.expected, err)
+			}
+		}
+	}
+}
+
+func TestTOMLToNixfmt(t *testing.T) {
+	t.Parallel()
+	common.TestHelperNixfmt(t, ".toml", ToNix)
+}
@@ -0,0 +1,44 @@
+package toml
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/json"
+	"github.com/theobori/nix-converter/converter/options"
+)
+
+// The limits count the values of a TOML document like the ones of the same
+// JSON document
+func TestLimitsParity(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		limits options.Limits
+		toml   string
+		json   string
+		err    bool
+	}{
+		{options.Limits{MaxDepth: 4}, `a = [[1]]`, `{"a": [[1]]}`, false},
+		{options.Limits{MaxDepth: 3}, `a = [[1]]`, `{"a": [[1]]}`, true},
+		{options.Limits{MaxNodes: 5}, `a = [[1], "b"]`, `{"a": [[1], "b"]}`, false},
+		{options.Limits{MaxNodes: 4}, `a = [[1], "b"]`, `{"a": [[1], "b"]}`, true},
+		{options.Limits{MaxDepth: 3}, "[a]\nb = { c = 1 }", `{"a": {"b": {"c": 1}}}`, true},
+		{options.Limits{MaxDepth: 4}, "[a]\nb = { c = 1 }", `{"a": {"b": {"c": 1}}}`, false},
+	}
+
+	for _, test := range tests {
+		o := converter.NewDefaultConverterOptions()
+		o.Limits = test.limits
+
+		_, tomlErr := ToNix(test.toml, o)
+		_, jsonErr := json.ToNix(test.json, o)
+
+		for _, err := range []error{tomlErr, jsonErr} {
+			var limitErr *converter.LimitError
+			if test.err != errors.As(err, &limitErr) {
+				t.Errorf("%s with %+v: expected a limit error: %t, got %v", test.toml, test.limits, test.err, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,25 @@
+{
+  "inline" = {
+    "a" = 1;
+  };
+  "nested" = [
+    [
+      1
+      2
+    ]
+    [ "x" ]
+  ];
+  "ports" = [
+    8000
+    (-8001)
+    8002
+  ];
+  "products" = [
+    { "name" = "hammer"; }
+    {
+      "name" = "nail";
+      "sku" = 284758393;
+    }
+  ];
+  "single" = [ 1 ];
+}
@@ -0,0 +1,11 @@
+{
+  "literal" = "C:\\path";
+  "script" = ''
+    set -e
+
+    echo ''${HOME}
+  '';
+  "text" = ''
+    first
+    second'';
+}
@@ -0,0 +1,13 @@
+{
+  "database" = {
+    "connection" = {
+      "enabled" = true;
+      "ratio" = 0.5;
+    };
+  };
+  "owner" = {
+    "age" = -3;
+    "name" = "Tom";
+  };
+  "title" = "example";
+}
@@ -1,6 +1,8 @@
 package toml
 
 import (
+	"strings"
+
 	"github.com/BurntSushi/toml"
 	"github.com/theobori/nix-converter/converter"
 	"github.com/theobori/nix-converter/converter/nix"
@@ -8,16 +10,19 @@ import (
 
 func FromNix(data string, options *converter.ConverterOptions) (string, error) {
 	// Get a Go value
-	v, err := nix.GoValue(data)
+	v, err := nix.GoValueWithOptions(data, options)
 	if err != nil {
 		return "", err
 	}
 
 	// Get a TOML representation of the Go value
-	tomlBytes, err := toml.Marshal(v)
-	if err != nil {
+	var b strings.Builder
+	encoder := toml.NewEncoder(&b)
+	encoder.Indent = options.Indentation.Unit()
+
+	if err := encoder.Encode(v); err != nil {
 		return "", err
 	}
 
-	return string(tomlBytes), nil
+	return b.String(), nil
 }
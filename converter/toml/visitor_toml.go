@@ -1,134 +1,96 @@
 package toml
 
 import (
-	"fmt"
-	"math"
-	"slices"
-	"strconv"
+	"bytes"
+	"errors"
+	"sort"
 	"strings"
-	"time"
 
 	"github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
 	"github.com/theobori/nix-converter/converter"
 	"github.com/theobori/nix-converter/converter/nix"
-	"github.com/theobori/nix-converter/internal/common"
 )
 
 type TOMLVisitor struct {
-	i       common.Indentation
 	node    any
 	options *converter.ConverterOptions
 }
 
-const (
-	MaxNixNumber = 9223372036854775807 // 64 bits signed - 1
-	MinNixNumber = -9223372036854775807
-)
-
 func NewTOMLVisitor(node any, options *converter.ConverterOptions) *TOMLVisitor {
 	return &TOMLVisitor{
-		i:       *common.NewDefaultIndentation(),
 		node:    node,
 		options: options,
 	}
 }
 
-func (t *TOMLVisitor) visitMap(node map[string]any) (string, error) {
-	if len(node) == 0 {
-		return "{}", nil
-	}
-
-	e := []string{}
-
-	for key, value := range node {
-		t.i.Indent()
-		left := nix.MakeNameSafe(key, t.options.UnsafeKeys)
-		valueResult, err := t.visit(value)
-		if err != nil {
-			return "", err
-		}
-
-		e = append(e, t.i.IndentValue()+left+" = "+valueResult+";")
-		t.i.UnIndent()
-	}
+// Visit writes the decoded document with a Nix encoder, the NaN and infinite
+// floats of TOML being null
+func (t *TOMLVisitor) Visit() (string, error) {
+	var b strings.Builder
 
-	if t.options.SortIterators.SortHashmap {
-		slices.Sort(e)
+	encoder := nix.NewEncoder(&b, t.options)
+	encoder.SetNonFiniteNull(true)
+	if err := encoder.Encode(t.node); err != nil {
+		return "", err
 	}
 
-	return "{\n" + strings.Join(e, "\n") + "\n" + t.i.IndentValue() + "}", nil
+	return b.String(), nil
 }
 
-func (t *TOMLVisitor) visitArray(node []any) (string, error) {
-	if len(node) == 0 {
-		return "[]", nil
+// decodeError locates the errors of the TOML decoder. The ones without a
+// position, like a key defined twice, are located at the key of the first
+// failing expression, found by decoding the prefixes of the document ending
+// before an expression.
+func decodeError(data []byte, err error) error {
+	var decodeErr *toml.DecodeError
+	if errors.As(err, &decodeErr) {
+		line, column := decodeErr.Position()
+		return converter.Errorf(line, column, "%s", strings.TrimPrefix(decodeErr.Error(), "toml: "))
 	}
 
-	e := []string{}
-	for _, item := range node {
-		t.i.Indent()
-		itemResult, err := t.visit(item)
-		if err != nil {
-			return "", err
+	offsets := []int{}
+	p := unstable.Parser{}
+	p.Reset(data)
+	for p.NextExpression() {
+		it := p.Expression().Key()
+		if it.Next() {
+			offsets = append(offsets, int(it.Node().Raw.Offset))
 		}
-
-		e = append(e, t.i.IndentValue()+nix.MakeElementSafe(itemResult))
-		t.i.UnIndent()
-	}
-
-	if t.options.SortIterators.SortList {
-		slices.Sort(e)
 	}
 
-	return "[\n" + strings.Join(e, "\n") + "\n" + t.i.IndentValue() + "]", nil
-}
-
-func (t *TOMLVisitor) visit(node any) (string, error) {
-	switch v := node.(type) {
-	case map[string]interface{}:
-		return t.visitMap(v)
-	case []any:
-		return t.visitArray(v)
-	case time.Time:
-		return common.MakeStringSafe(v.String()), nil
-	case float64:
-		if math.IsNaN(v) || math.IsInf(v, 0) {
-			return "null", nil
-		}
-
-		if v < MinNixNumber || v > MaxNixNumber {
-			return "", fmt.Errorf("number out of range")
+	// Every expression starts on a line of its own
+	prefixFails := func(i int) bool {
+		end := len(data)
+		if i+1 < len(offsets) {
+			end = bytes.LastIndexByte(data[:offsets[i+1]], '\n') + 1
 		}
 
-		return strconv.FormatFloat(v, 'f', -1, 64), nil
-	case int64:
-		if v < MinNixNumber || v > MaxNixNumber {
-			return "", fmt.Errorf("number out of range")
-		}
+		var node map[string]any
+		return toml.Unmarshal(data[:end], &node) != nil
+	}
 
-		return fmt.Sprintf("%d", v), nil
-	case bool:
-		return strconv.FormatBool(v), nil
-	default:
-		s := v.(string)
-		if strings.Contains(s, "\n") {
-			return common.MakeIndentedString(s, t.i.IndentValue()), nil
-		}
-		return common.MakeStringSafe(s), nil
+	i := sort.Search(len(offsets), prefixFails)
+	if i == len(offsets) {
+		return err
 	}
-}
 
-func (t *TOMLVisitor) Visit() (string, error) {
-	return t.visit(t.node)
+	line, column := converter.LineColumn(data, offsets[i])
+	return converter.Errorf(line, column, "%s", strings.TrimPrefix(err.Error(), "toml: "))
 }
 
 func ToNix(data string, options *converter.ConverterOptions) (string, error) {
 	var node map[string]any
 
 	err := toml.Unmarshal([]byte(data), &node)
+	if err != nil {
+		return "", decodeError([]byte(data), err)
+	}
+
+	out, err := NewTOMLVisitor(node, options).Visit()
 	if err != nil {
 		return "", err
 	}
 
-	return NewTOMLVisitor(node, options).Visit()
+	return out, nil
 }
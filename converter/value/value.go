@@ -0,0 +1,63 @@
+// Package value is the data model shared by the binary converters, CBOR and
+// MessagePack, with its Nix representation.
+//
+// A decoded value is nil, a bool, an int64, a uint64, a float64, a string, a
+// []any, a Map or one of the types below. Values without a Nix equivalent are
+// tagged sets, a set whose "_type" key names the kind of value.
+//
+//   - Bytes is { _type = "bytes"; value = "<base64>"; }.
+//   - Tag is { _type = "tag"; tag = 1; value = <value>; }.
+//   - Ext is { _type = "ext"; ext = -1; value = "<base64>"; }.
+//   - Simple is { _type = "simple"; value = 16; }.
+//   - Undefined is { _type = "undefined"; }.
+//   - A Map with a key that is not a string is
+//     { _type = "map"; value = [ { key = 1; value = "a"; } ]; }.
+//
+// A Map whose keys are all strings is a regular set. A set with a "_type" key
+// naming one of these kinds must have the matching shape.
+package value
+
+const (
+	TypeKey  = "_type"
+	ValueKey = "value"
+	TagKey   = "tag"
+	ExtKey   = "ext"
+	KeyKey   = "key"
+
+	TypeBytes     = "bytes"
+	TypeTag       = "tag"
+	TypeExt       = "ext"
+	TypeSimple    = "simple"
+	TypeUndefined = "undefined"
+	TypeMap       = "map"
+)
+
+// Pair is a map entry
+type Pair struct {
+	Key   any
+	Value any
+}
+
+// Map is a map keeping the order of its entries
+type Map []Pair
+
+// Bytes is a byte string
+type Bytes []byte
+
+// Tag is a CBOR tagged value
+type Tag struct {
+	Number  uint64
+	Content any
+}
+
+// Ext is a MessagePack extension value
+type Ext struct {
+	Type int8
+	Data []byte
+}
+
+// Simple is a CBOR simple value without a Nix equivalent
+type Simple uint8
+
+// Undefined is the CBOR undefined value
+type Undefined struct{}
@@ -0,0 +1,300 @@
+package value
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/orivej/go-nix/nix/parser"
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/nix"
+)
+
+// Keys of the tagged sets, the type key excluded
+var taggedKeys = map[string][]string{
+	TypeBytes:     {ValueKey},
+	TypeTag:       {TagKey, ValueKey},
+	TypeExt:       {ExtKey, ValueKey},
+	TypeSimple:    {ValueKey},
+	TypeUndefined: {},
+	TypeMap:       {ValueKey},
+}
+
+type NixVisitor struct {
+	p       *parser.Parser
+	errors  *nix.Collector
+	node    *parser.Node
+	options *converter.ConverterOptions
+}
+
+func NewNixVisitor(p *parser.Parser, node *parser.Node, options *converter.ConverterOptions) *NixVisitor {
+	return &NixVisitor{
+		node:    node,
+		p:       p,
+		errors:  nix.NewCollector(p, node, options),
+		options: options,
+	}
+}
+
+func (n *NixVisitor) keys(tree *nix.AttrNode) []string {
+	if !n.options.SortIterators.SortHashmap {
+		return tree.Order
+	}
+
+	keys := slices.Clone(tree.Order)
+	slices.Sort(keys)
+
+	return keys
+}
+
+func (n *NixVisitor) visitAttr(attr *nix.AttrNode) (any, error) {
+	tree, isSet, err := attr.Set(n.p)
+	if err != nil {
+		return nil, err
+	}
+
+	if isSet {
+		return n.visitSet(tree)
+	}
+
+	return n.visit(attr.Value)
+}
+
+func (n *NixVisitor) visitSet(tree *nix.AttrNode) (any, error) {
+	if kind, isTagged, err := n.taggedKind(tree); err != nil || isTagged {
+		if err != nil {
+			return nil, err
+		}
+		return n.visitTagged(kind, tree)
+	}
+
+	m := Map{}
+	for _, k := range n.keys(tree) {
+		v, err := n.visitAttr(tree.Children[k])
+		if err != nil {
+			return nil, converter.AtKey(err, k)
+		}
+
+		m = append(m, Pair{k, v})
+	}
+
+	return m, nil
+}
+
+func (n *NixVisitor) taggedKind(tree *nix.AttrNode) (string, bool, error) {
+	attr, exists := tree.Children[TypeKey]
+	if !exists || attr.Value == nil {
+		return "", false, nil
+	}
+
+	kind, err := nix.VisitStringValue(n.p, attr.Value)
+	if err != nil {
+		return "", false, nil
+	}
+
+	keys, isKnown := taggedKeys[kind]
+	if !isKnown {
+		return "", false, nil
+	}
+
+	if len(tree.Order) != len(keys)+1 {
+		return "", false, fmt.Errorf("a set of type '%s' must only have the keys %s and %v", kind, TypeKey, keys)
+	}
+	for _, key := range keys {
+		if _, exists := tree.Children[key]; !exists {
+			return "", false, fmt.Errorf("a set of type '%s' needs the key '%s'", kind, key)
+		}
+	}
+
+	return kind, true, nil
+}
+
+func (n *NixVisitor) visitInteger(attr *nix.AttrNode, min int64, max int64) (int64, error) {
+	v, err := n.visitAttr(attr)
+	if err != nil {
+		return 0, err
+	}
+
+	i, isInt := v.(int64)
+	if !isInt || i < min || i > max {
+		return 0, fmt.Errorf("expected an integer between %d and %d", min, max)
+	}
+
+	return i, nil
+}
+
+func (n *NixVisitor) visitBase64(attr *nix.AttrNode) ([]byte, error) {
+	if attr.Value == nil {
+		return nil, fmt.Errorf("expected a base64 string")
+	}
+
+	s, err := nix.VisitStringValue(n.p, attr.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	return base64.StdEncoding.DecodeString(s)
+}
+
+func (n *NixVisitor) visitTagged(kind string, tree *nix.AttrNode) (any, error) {
+	switch kind {
+	case TypeBytes:
+		b, err := n.visitBase64(tree.Children[ValueKey])
+		if err != nil {
+			return nil, converter.AtKey(err, ValueKey)
+		}
+		return Bytes(b), nil
+	case TypeTag:
+		number, err := n.visitInteger(tree.Children[TagKey], 0, math.MaxInt64)
+		if err != nil {
+			return nil, converter.AtKey(err, TagKey)
+		}
+		content, err := n.visitAttr(tree.Children[ValueKey])
+		if err != nil {
+			return nil, err
+		}
+		return Tag{uint64(number), content}, nil
+	case TypeExt:
+		t, err := n.visitInteger(tree.Children[ExtKey], math.MinInt8, math.MaxInt8)
+		if err != nil {
+			return nil, converter.AtKey(err, ExtKey)
+		}
+		b, err := n.visitBase64(tree.Children[ValueKey])
+		if err != nil {
+			return nil, converter.AtKey(err, ValueKey)
+		}
+		return Ext{int8(t), b}, nil
+	case TypeSimple:
+		v, err := n.visitInteger(tree.Children[ValueKey], 0, math.MaxUint8)
+		if err != nil {
+			return nil, converter.AtKey(err, ValueKey)
+		}
+		return Simple(v), nil
+	case TypeUndefined:
+		return Undefined{}, nil
+	default:
+		return n.visitTaggedMap(tree)
+	}
+}
+
+func (n *NixVisitor) visitTaggedMap(tree *nix.AttrNode) (any, error) {
+	v, err := n.visitAttr(tree.Children[ValueKey])
+	if err != nil {
+		return nil, err
+	}
+
+	entries, isList := v.([]any)
+	if !isList {
+		return nil, fmt.Errorf("map: expected a list of sets with the keys '%s' and '%s'", KeyKey, ValueKey)
+	}
+
+	m := Map{}
+	for _, entry := range entries {
+		pair, isMap := entry.(Map)
+		if !isMap || len(pair) != 2 {
+			return nil, fmt.Errorf("map: expected a list of sets with the keys '%s' and '%s'", KeyKey, ValueKey)
+		}
+
+		// The set keys may have been sorted
+		if pair[0].Key != KeyKey {
+			pair[0], pair[1] = pair[1], pair[0]
+		}
+		if pair[0].Key != KeyKey || pair[1].Key != ValueKey {
+			return nil, fmt.Errorf("map: expected a list of sets with the keys '%s' and '%s'", KeyKey, ValueKey)
+		}
+
+		m = append(m, Pair{pair[0].Value, pair[1].Value})
+	}
+
+	return m, nil
+}
+
+func (n *NixVisitor) visitList(node *parser.Node) (any, error) {
+	array := []any{}
+	for index, child := range node.Nodes {
+		v, err := n.visit(child)
+		if err != nil {
+			return nil, converter.AtIndex(err, index)
+		}
+
+		array = append(array, v)
+	}
+
+	return array, nil
+}
+
+func (n *NixVisitor) visitNegative(node *parser.Node) (any, error) {
+	v, err := n.visit(node)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := v.(type) {
+	case int64:
+		return -v, nil
+	case float64:
+		return -v, nil
+	default:
+		return nil, fmt.Errorf("only numbers can be negative")
+	}
+}
+
+func (n *NixVisitor) visit(node *parser.Node) (any, error) {
+	return nix.Visit(n.errors, node, n.visitNode, nil)
+}
+
+func (n *NixVisitor) visitNode(node *parser.Node) (any, error) {
+	switch node.Type {
+	case parser.SetNode:
+		tree, err := n.errors.BuildAttrTree(node, n.options.DuplicateKeys.Policy)
+		if err != nil {
+			return nil, err
+		}
+		return n.visitSet(tree)
+	case parser.ListNode:
+		return n.visitList(node)
+	case parser.StringNode, parser.IStringNode:
+		return nix.VisitStringValue(n.p, node)
+	case parser.IDNode:
+		id, err := nix.VisitID(n.p, node)
+		if err != nil {
+			return nil, err
+		}
+		switch id {
+		case "true", "false":
+			return id == "true", nil
+		case "null":
+			return nil, nil
+		default:
+			return nil, fmt.Errorf("unsupported identifier: %s", id)
+		}
+	case parser.IntNode:
+		return nix.VisitIntRaw(n.p, node)
+	case parser.FloatNode:
+		return nix.VisitFloatRaw(n.p, node)
+	case parser.ApplyNode:
+		return nix.VisitApplyRaw(n.p, node)
+	case parser.OpNode + 57378: // The negative unary operator
+		return n.visitNegative(node.Nodes[0])
+	case parser.ParensNode:
+		return n.visit(node.Nodes[0])
+	default:
+		return nil, fmt.Errorf("unsupported node type: %s", node.Type.String())
+	}
+}
+
+func (n *NixVisitor) Visit() (any, error) {
+	v, err := n.visit(n.node)
+	return v, n.errors.Err(err)
+}
+
+// FromNix reads a Nix expression as a value
+func FromNix(data string, options *converter.ConverterOptions) (any, error) {
+	p, err := nix.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewNixVisitor(p, p.Result, options).Visit()
+}
@@ -0,0 +1,177 @@
+package value
+
+import (
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/nix"
+)
+
+// ValueVisitor writes a decoded value as Nix with nix.Encoder, the values
+// without a Nix equivalent being written as tagged sets
+type ValueVisitor struct {
+	node    any
+	options *converter.ConverterOptions
+}
+
+func NewValueVisitor(node any, options *converter.ConverterOptions) *ValueVisitor {
+	return &ValueVisitor{
+		node:    node,
+		options: options,
+	}
+}
+
+// Returns the tagged set of a value without a Nix equivalent
+func (v *ValueVisitor) visitTagged(kind string, keys []string, values []any) (nix.OrderedMap, error) {
+	m := nix.OrderedMap{{Key: TypeKey, Value: kind}}
+	for i, key := range keys {
+		value, err := v.visit(values[i])
+		if err != nil {
+			return nil, converter.AtKey(err, key)
+		}
+		m = append(m, nix.KeyValue{Key: key, Value: value})
+	}
+
+	return m, nil
+}
+
+func hasStringKeys(m Map) bool {
+	for _, pair := range m {
+		if _, isString := pair.Key.(string); !isString {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Applies the duplicate keys policy to a map whose keys are strings
+func (v *ValueVisitor) mergeKeys(m Map) (Map, error) {
+	entries := make([]nix.Entry[any], len(m))
+	for i, pair := range m {
+		entries[i] = nix.Entry[any]{Key: pair.Key.(string), Value: pair.Value}
+	}
+
+	merged, err := nix.MergeEntries(entries, v.options.DuplicateKeys.Policy, v.mergeMaps)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(Map, len(merged))
+	for i, e := range merged {
+		out[i] = Pair{Key: e.Key, Value: e.Value}
+	}
+
+	return out, nil
+}
+
+// Returns the deep merge of two maps whose keys are strings
+func (v *ValueVisitor) mergeMaps(a any, b any) (any, bool, error) {
+	ma, isMap := a.(Map)
+	mb, isOtherMap := b.(Map)
+	if !isMap || !isOtherMap || !hasStringKeys(ma) || !hasStringKeys(mb) {
+		return nil, false, nil
+	}
+
+	merged, err := v.mergeKeys(append(slices.Clone(ma), mb...))
+	if err != nil {
+		return nil, false, err
+	}
+
+	return merged, true, nil
+}
+
+func (v *ValueVisitor) visitMap(m Map) (any, error) {
+	if !hasStringKeys(m) {
+		return v.visitTaggedMap(m)
+	}
+
+	m, err := v.mergeKeys(m)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(nix.OrderedMap, len(m))
+	for i, pair := range m {
+		key := pair.Key.(string)
+
+		value, err := v.visit(pair.Value)
+		if err != nil {
+			return nil, converter.AtKey(err, key)
+		}
+
+		out[i] = nix.KeyValue{Key: key, Value: value}
+	}
+
+	return out, nil
+}
+
+// A map with keys that are not strings is a list of key and value sets
+func (v *ValueVisitor) visitTaggedMap(m Map) (any, error) {
+	entries := []any{}
+	for _, pair := range m {
+		entries = append(entries, Map{{KeyKey, pair.Key}, {ValueKey, pair.Value}})
+	}
+
+	return v.visitTagged(TypeMap, []string{ValueKey}, []any{entries})
+}
+
+func (v *ValueVisitor) visitArray(array []any) (any, error) {
+	out := make([]any, len(array))
+	for i, item := range array {
+		value, err := v.visit(item)
+		if err != nil {
+			return nil, converter.AtIndex(err, i)
+		}
+
+		out[i] = value
+	}
+
+	return out, nil
+}
+
+// Returns the Go value written by nix.Encoder for a decoded value
+func (v *ValueVisitor) visit(node any) (any, error) {
+	switch n := node.(type) {
+	case nil, bool, int64, uint64, float64, string:
+		return n, nil
+	case []any:
+		return v.visitArray(n)
+	case Map:
+		return v.visitMap(n)
+	case Bytes:
+		return v.visitTagged(TypeBytes, []string{ValueKey}, []any{base64.StdEncoding.EncodeToString(n)})
+	case Tag:
+		return v.visitTagged(TypeTag, []string{TagKey, ValueKey}, []any{n.Number, n.Content})
+	case Ext:
+		return v.visitTagged(TypeExt, []string{ExtKey, ValueKey}, []any{int64(n.Type), base64.StdEncoding.EncodeToString(n.Data)})
+	case Simple:
+		return v.visitTagged(TypeSimple, []string{ValueKey}, []any{int64(n)})
+	case Undefined:
+		return v.visitTagged(TypeUndefined, nil, nil)
+	default:
+		return nil, fmt.Errorf("unsupported value type: %T", n)
+	}
+}
+
+func (v *ValueVisitor) Visit() (string, error) {
+	value, err := v.visit(v.node)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if err := nix.NewEncoder(&b, v.options).Encode(value); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+// ToNix writes a decoded value as Nix
+func ToNix(node any, options *converter.ConverterOptions) (string, error) {
+	return NewValueVisitor(node, options).Visit()
+}
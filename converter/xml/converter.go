@@ -0,0 +1,27 @@
+package xml
+
+import "github.com/theobori/nix-converter/converter"
+
+type XMLConverter struct {
+	data    string
+	options *converter.ConverterOptions
+}
+
+func NewXMLConverter(data string, options *converter.ConverterOptions) *XMLConverter {
+	return &XMLConverter{
+		data,
+		options,
+	}
+}
+
+func (x *XMLConverter) FromNix() (string, error) {
+	return FromNix(x.data, x.options)
+}
+
+func (x *XMLConverter) ToNix() (string, error) {
+	return ToNix(x.data, x.options)
+}
+
+func (x *XMLConverter) Type() string {
+	return "xml"
+}
@@ -0,0 +1,163 @@
+package xml
+
+import (
+	"testing"
+
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/options"
+	"github.com/theobori/nix-converter/internal/common"
+)
+
+var xmlStrings = []string{
+	`<?xml version="1.0" encoding="UTF-8"?>
+<manifest xmlns:android="http://schemas.android.com/apk/res/android" package="com.example">
+  <uses-permission android:name="android.permission.INTERNET"/>
+  <uses-permission android:name="android.permission.CAMERA"/>
+  <application android:label="A &amp; B &lt;3">
+    <activity android:name=".Main"/>
+  </application>
+</manifest>`,
+	`<?xml version="1.0" encoding="UTF-8"?>
+<fontconfig>
+  <dir>/usr/share/fonts</dir>
+  <alias binding="same">
+    <family>sans-serif</family>
+    <prefer>
+      <family>Inter</family>
+      <family>Noto Sans</family>
+    </prefer>
+  </alias>
+  <empty/>
+</fontconfig>`,
+}
+
+var nixStrings = []string{
+	`{
+  project = {
+    "@xmlns" = "http://maven.apache.org/POM/4.0.0";
+    modelVersion = "4.0.0";
+    dependencies = {
+      dependency = [
+        {
+          groupId = "junit";
+          scope = "test";
+        }
+        {
+          "@optional" = "true";
+          groupId = "org.example";
+        }
+      ];
+    };
+    description = ''
+      First line
+      Second line'';
+    note = {
+      "@lang" = "en";
+      "#text" = "x < y";
+    };
+    relativePath = "";
+  };
+}`,
+}
+
+func TestXMLToNix(t *testing.T) {
+	t.Parallel()
+	common.TestHelperToNixStrings(t, xmlStrings, FromNix, ToNix, converter.NewDefaultConverterOptions())
+}
+
+func TestXMLFromNix(t *testing.T) {
+	t.Parallel()
+	options := converter.ConverterOptions{
+		SortIterators: *options.NewDefaultSortIterators(),
+		UnsafeKeys:    true,
+	}
+
+	common.TestHelperFromNixStrings(t, nixStrings, FromNix, ToNix, &options)
+}
+
+func TestXMLMapping(t *testing.T) {
+	t.Parallel()
+	options := converter.NewDefaultConverterOptions()
+	options.UnsafeKeys = true
+
+	input := `<!DOCTYPE policy>
+<!-- D-Bus policy -->
+<busconfig>
+  <policy user="root">
+    <allow own="org.example"/>
+    <deny send_type="method_call"/>
+    <allow send_destination="org.example"/>
+  </policy>
+  <p>Hello <b>world</b> !</p>
+</busconfig>`
+
+	want := `{
+  busconfig = {
+    policy = {
+      "@user" = "root";
+      allow = [
+        {
+          "@own" = "org.example";
+        }
+        {
+          "@send_destination" = "org.example";
+        }
+      ];
+      deny = {
+        "@send_type" = "method_call";
+      };
+    };
+    p = {
+      "#text" = "Hello  !";
+      b = "world";
+    };
+  };
+}`
+
+	output, err := ToNix(input, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if output != want {
+		t.Errorf("ToNix() = \n%v, want \n%v", output, want)
+	}
+}
+
+func TestXMLErrors(t *testing.T) {
+	t.Parallel()
+	xmlInputs := []string{
+		``,
+		`<a></b>`,
+		`<a/><b/>`,
+		`<a>`,
+		`text<a/>`,
+	}
+
+	for _, input := range xmlInputs {
+		if _, err := ToNix(input, converter.NewDefaultConverterOptions()); err == nil {
+			t.Errorf("ToNix(%q) expected an error", input)
+		}
+	}
+
+	nixInputs := []string{
+		`[ "a" ]`,
+		`{ a = "x"; b = "y"; }`,
+		`{ a = 1; }`,
+		`{ a = { b = true; }; }`,
+		`{ a = { b = [ "x" ]; }; }`,
+		`{ a = { b = [ [ "x" "y" ] "z" ]; }; }`,
+		`{ a = { }; }`,
+		`{ a = { "#text" = "x"; }; }`,
+		`{ a = { "@id" = "1"; "#text" = " x"; }; }`,
+		`{ a = { "1b" = "x"; }; }`,
+		`{ a = { "@" = "x"; }; }`,
+		`{ a = "${"x"}"; }`,
+	}
+
+	for _, input := range nixInputs {
+		if _, err := FromNix(input, converter.NewDefaultConverterOptions()); err == nil {
+			t.Errorf("FromNix(%q) expected an error", input)
+		}
+	}
+}
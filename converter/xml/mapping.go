@@ -0,0 +1,29 @@
+// Package xml converts XML documents to Nix and vice versa.
+//
+// The document is represented by a set with a single key, the root element
+// name. An element is mapped to a Nix value with the following rules.
+//
+//   - An element without attributes and child elements is a string holding its
+//     text, an empty element is an empty string.
+//   - Any other element is a set. Attributes are keys prefixed with "@", the
+//     text is stored under the "#text" key without its surrounding whitespace
+//     and child elements are keys named after them.
+//   - Repeated child elements are grouped in a list, in document order.
+//   - Namespace prefixes are kept in the names, like "android:name", and
+//     namespace declarations are regular attributes, like "@xmlns:android".
+//
+// Comments, processing instructions and directives are dropped. The interleaving
+// of different child elements is not kept, repeated elements are grouped at the
+// position of the first one.
+//
+// Converting Nix to XML then back to Nix gives the original value, with the
+// keys of a set ordered as attributes, text and child elements. Nix values
+// without an XML representation in this mapping are rejected, it concerns
+// numbers, booleans, null, empty sets, lists with less than two elements
+// and nested lists.
+package xml
+
+const (
+	AttributePrefix = "@"
+	TextKey         = "#text"
+)
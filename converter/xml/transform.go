@@ -0,0 +1,28 @@
+package xml
+
+import "strings"
+
+var textReplacer = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	"\r", "&#xD;",
+)
+
+var attributeReplacer = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	"\"", "&quot;",
+	"\r", "&#xD;",
+	"\n", "&#xA;",
+	"\t", "&#x9;",
+)
+
+func EscapeText(s string) string {
+	return textReplacer.Replace(s)
+}
+
+func EscapeAttribute(s string) string {
+	return "\"" + attributeReplacer.Replace(s) + "\""
+}
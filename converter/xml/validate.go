@@ -0,0 +1,33 @@
+package xml
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+func isNameStartChar(r rune) bool {
+	return unicode.IsLetter(r) || r == '_' || r == ':'
+}
+
+func isNameChar(r rune) bool {
+	return isNameStartChar(r) || unicode.IsDigit(r) || r == '-' || r == '.'
+}
+
+func IsNameValid(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	r, size := utf8.DecodeRuneInString(s)
+	if !isNameStartChar(r) {
+		return false
+	}
+
+	for _, r := range s[size:] {
+		if !isNameChar(r) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,224 @@
+package xml
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/orivej/go-nix/nix/parser"
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/nix"
+	"github.com/theobori/nix-converter/internal/common"
+)
+
+const Header = `<?xml version="1.0" encoding="UTF-8"?>`
+
+type NixVisitor struct {
+	i       common.Indentation
+	p       *parser.Parser
+	errors  *nix.Collector
+	node    *parser.Node
+	options *converter.ConverterOptions
+}
+
+func NewNixVisitor(p *parser.Parser, node *parser.Node, options *converter.ConverterOptions) *NixVisitor {
+	return &NixVisitor{
+		i:       *common.NewIndentationUnit(options.Indentation.Unit()),
+		node:    node,
+		p:       p,
+		errors:  nix.NewCollector(p, node, options),
+		options: options,
+	}
+}
+
+func (n *NixVisitor) buildElement(name string, tree *nix.AttrNode) (string, error) {
+	attrs := []string{}
+	children := []string{}
+	text := ""
+	hasText := false
+
+	keys := tree.Order
+	if n.options.SortIterators.SortHashmap {
+		keys = slices.Clone(tree.Order)
+		slices.Sort(keys)
+	}
+
+	n.i.Indent()
+	for _, k := range keys {
+		child := tree.Children[k]
+
+		switch {
+		case k == TextKey:
+			if child.Value == nil {
+				return "", fmt.Errorf("element <%s>: %s must be a string", name, TextKey)
+			}
+			s, err := nix.VisitStringValue(n.p, child.Value)
+			if err != nil {
+				return "", fmt.Errorf("element <%s>: %w", name, err)
+			}
+			text, hasText = s, true
+		case strings.HasPrefix(k, AttributePrefix):
+			attrName := strings.TrimPrefix(k, AttributePrefix)
+			if !IsNameValid(attrName) {
+				return "", fmt.Errorf("element <%s>: invalid attribute name '%s'", name, attrName)
+			}
+			if child.Value == nil {
+				return "", fmt.Errorf("element <%s>: attribute '%s' must be a string", name, attrName)
+			}
+			s, err := nix.VisitStringValue(n.p, child.Value)
+			if err != nil {
+				return "", fmt.Errorf("element <%s>: %w", name, err)
+			}
+			attrs = append(attrs, " "+attrName+"="+EscapeAttribute(s))
+		default:
+			var (
+				s   string
+				err error
+			)
+			if child.Value != nil {
+				s, err = n.visitElement(k, child.Value)
+			} else {
+				s, err = n.buildElement(k, child)
+			}
+			if err != nil {
+				return "", converter.AtKey(err, k)
+			}
+			children = append(children, s)
+		}
+	}
+	n.i.UnIndent()
+
+	if len(children) == 0 {
+		if len(attrs) == 0 {
+			return "", fmt.Errorf("element <%s>: a set needs attributes or child elements, use a string instead", name)
+		}
+		if !hasText {
+			return n.i.IndentValue() + "<" + name + strings.Join(attrs, "") + "/>", nil
+		}
+	}
+
+	if hasText && (text == "" || strings.TrimSpace(text) != text) {
+		return "", fmt.Errorf("element <%s>: %s cannot be empty, start or end with whitespace", name, TextKey)
+	}
+
+	start := n.i.IndentValue() + "<" + name + strings.Join(attrs, "") + ">"
+	end := "</" + name + ">"
+	if len(children) == 0 {
+		return start + EscapeText(text) + end, nil
+	}
+
+	return start + EscapeText(text) + "\n" + strings.Join(children, "\n") + "\n" + n.i.IndentValue() + end, nil
+}
+
+func (n *NixVisitor) visitList(name string, node *parser.Node) (string, error) {
+	if len(node.Nodes) < 2 {
+		return "", fmt.Errorf("element <%s>: a list needs at least two elements", name)
+	}
+
+	e := []string{}
+	for index, child := range node.Nodes {
+		if child.Type == parser.ListNode {
+			return "", fmt.Errorf("element <%s>: nested lists are not supported", name)
+		}
+
+		s, err := n.visitElement(name, child)
+		if err != nil {
+			return "", converter.AtIndex(err, index)
+		}
+
+		e = append(e, s)
+	}
+
+	if n.options.SortIterators.SortList {
+		slices.Sort(e)
+	}
+
+	return strings.Join(e, "\n"), nil
+}
+
+func (n *NixVisitor) visitElement(name string, node *parser.Node) (string, error) {
+	return nix.Visit(n.errors, node, func(node *parser.Node) (string, error) {
+		return n.visitElementNode(name, node)
+	}, "")
+}
+
+func (n *NixVisitor) visitElementNode(name string, node *parser.Node) (string, error) {
+	if !IsNameValid(name) {
+		return "", fmt.Errorf("invalid element name '%s'", name)
+	}
+
+	switch node.Type {
+	case parser.SetNode:
+		tree, err := n.errors.BuildAttrTree(node, n.options.DuplicateKeys.Policy)
+		if err != nil {
+			return "", err
+		}
+		return n.buildElement(name, tree)
+	case parser.ListNode:
+		return n.visitList(name, node)
+	case parser.ParensNode:
+		return n.visitElement(name, node.Nodes[0])
+	default:
+		s, err := nix.VisitStringValue(n.p, node)
+		if err != nil {
+			return "", fmt.Errorf("element <%s>: %w", name, err)
+		}
+		if s == "" {
+			return n.i.IndentValue() + "<" + name + "/>", nil
+		}
+		return n.i.IndentValue() + "<" + name + ">" + EscapeText(s) + "</" + name + ">", nil
+	}
+}
+
+func (n *NixVisitor) visitRoot() (string, error) {
+	errRoot := fmt.Errorf("the Nix expression must be a set with a single key, the root element")
+	if n.node.Type != parser.SetNode {
+		return "", errRoot
+	}
+
+	tree, err := n.errors.BuildAttrTree(n.node, n.options.DuplicateKeys.Policy)
+	if err != nil {
+		return "", err
+	}
+
+	if len(tree.Order) != 1 {
+		return "", errRoot
+	}
+
+	name := tree.Order[0]
+	root := tree.Children[name]
+
+	var s string
+	if root.Value != nil {
+		if root.Value.Type == parser.ListNode {
+			return "", fmt.Errorf("the root element cannot be a list")
+		}
+		s, err = n.visitElement(name, root.Value)
+	} else {
+		s, err = n.buildElement(name, root)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return Header + "\n" + s, nil
+}
+
+func (n *NixVisitor) Visit() (string, error) {
+	v, err := n.visitRoot()
+	return n.errors.Done(v, err)
+}
+
+func FromNix(data string, options *converter.ConverterOptions) (string, error) {
+	p, err := nix.Parse(data)
+	if err != nil {
+		return "", err
+	}
+
+	out, err := NewNixVisitor(p, p.Result, options).Visit()
+	if err != nil {
+		return "", err
+	}
+
+	return out, nil
+}
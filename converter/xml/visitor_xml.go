@@ -0,0 +1,237 @@
+package xml
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/nix"
+	"github.com/theobori/nix-converter/internal/common"
+)
+
+// Element is a parsed XML element
+type Element struct {
+	name     string
+	attrs    []xml.Attr
+	children []*Element
+	text     strings.Builder
+}
+
+type XMLVisitor struct {
+	i       common.Indentation
+	root    *Element
+	limiter *converter.Limiter
+	options *converter.ConverterOptions
+}
+
+func NewXMLVisitor(root *Element, options *converter.ConverterOptions) *XMLVisitor {
+	return &XMLVisitor{
+		i:       *common.NewIndentationUnit(options.NixIndentUnit()),
+		root:    root,
+		limiter: converter.NewLimiter(options),
+		options: options,
+	}
+}
+
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+
+	return name.Space + ":" + name.Local
+}
+
+func (x *XMLVisitor) visitText(s string) string {
+	if strings.Contains(s, "\n") {
+		return common.MakeIndentedString(s, x.i.IndentValue(), x.i.Unit())
+	}
+
+	return common.MakeStringSafe(s)
+}
+
+// Returns the name of the single child group of an element written as a set,
+// an element with attributes or text is never collapsed
+func (x *XMLVisitor) single(group []*Element) (string, []*Element, bool) {
+	if len(group) != 1 {
+		return "", nil, false
+	}
+
+	el := group[0]
+	if len(el.attrs) > 0 || len(el.children) == 0 || strings.TrimSpace(el.text.String()) != "" {
+		return "", nil, false
+	}
+
+	for _, child := range el.children {
+		if child.name != el.children[0].name {
+			return "", nil, false
+		}
+	}
+
+	return el.children[0].name, el.children, true
+}
+
+func (x *XMLVisitor) visitChildren(children []*Element) string {
+	if len(children) == 1 {
+		return x.visitElement(children[0])
+	}
+
+	e := []string{}
+	for _, child := range children {
+		x.i.Indent()
+		e = append(e, x.i.IndentValue()+x.visitElement(child))
+		x.i.UnIndent()
+	}
+
+	if x.options.SortIterators.SortList {
+		slices.Sort(e)
+	}
+
+	return "[\n" + strings.Join(e, "\n") + "\n" + x.i.IndentValue() + "]"
+}
+
+// visitElement stops at the first exceeded limit, the error is returned by Err
+func (x *XMLVisitor) visitElement(el *Element) string {
+	if x.limiter.Enter() != nil {
+		return ""
+	}
+	defer x.limiter.Leave()
+
+	if len(el.attrs) == 0 && len(el.children) == 0 {
+		return x.visitText(el.text.String())
+	}
+
+	e := []string{}
+	x.i.Indent()
+
+	for _, attr := range el.attrs {
+		key := nix.MakeNameSafe(AttributePrefix+qualifiedName(attr.Name), x.options.UnsafeKeys)
+		e = append(e, x.i.IndentValue()+key+" = "+x.visitText(attr.Value)+";")
+	}
+
+	text := strings.TrimSpace(el.text.String())
+	if text != "" {
+		key := nix.MakeNameSafe(TextKey, x.options.UnsafeKeys)
+		e = append(e, x.i.IndentValue()+key+" = "+x.visitText(text)+";")
+	}
+
+	// Repeated elements are grouped at the position of the first one
+	groups := map[string][]*Element{}
+	order := []string{}
+	for _, child := range el.children {
+		if _, exists := groups[child.name]; !exists {
+			order = append(order, child.name)
+		}
+		groups[child.name] = append(groups[child.name], child)
+	}
+
+	for _, name := range order {
+		path, group := nix.CollapsePath(name, groups[name], x.single, &x.options.NixOutput)
+		key := nix.MakeAttrPath(path, x.options.UnsafeKeys)
+		e = append(e, x.i.IndentValue()+key+" = "+x.visitChildren(group)+";")
+	}
+
+	x.i.UnIndent()
+
+	if x.options.SortIterators.SortHashmap {
+		slices.Sort(e)
+	}
+
+	return "{\n" + strings.Join(e, "\n") + "\n" + x.i.IndentValue() + "}"
+}
+
+func (x *XMLVisitor) Visit() string {
+	x.i.Indent()
+	path, group := nix.CollapsePath(x.root.name, []*Element{x.root}, x.single, &x.options.NixOutput)
+	key := nix.MakeAttrPath(path, x.options.UnsafeKeys)
+	line := x.i.IndentValue() + key + " = " + x.visitChildren(group) + ";"
+	x.i.UnIndent()
+
+	return "{\n" + line + "\n}"
+}
+
+// Err returns the error of the first limit exceeded by Visit
+func (x *XMLVisitor) Err() error {
+	return x.limiter.Err()
+}
+
+// Parse reads the root element of an XML document
+func Parse(data string) (*Element, error) {
+	var (
+		root  *Element
+		stack []*Element
+	)
+
+	d := xml.NewDecoder(strings.NewReader(data))
+
+	for {
+		token, err := d.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			el := &Element{
+				name:  qualifiedName(t.Name),
+				attrs: t.Attr,
+			}
+
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, el)
+			} else if root != nil {
+				return nil, converter.Errorf(inputLine(d, data), 0, "multiple root elements")
+			} else {
+				root = el
+			}
+
+			stack = append(stack, el)
+		case xml.EndElement:
+			// Raw tokens are not checked by the decoder
+			name := qualifiedName(t.Name)
+			if len(stack) == 0 || stack[len(stack)-1].name != name {
+				return nil, converter.Errorf(inputLine(d, data), 0, "unexpected end element </%s>", name)
+			}
+
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			} else if strings.TrimSpace(string(t)) != "" {
+				return nil, converter.Errorf(inputLine(d, data), 0, "text outside of the root element")
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, fmt.Errorf("missing root element")
+	}
+
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("unclosed element <%s>", stack[len(stack)-1].name)
+	}
+
+	return root, nil
+}
+
+func inputLine(d *xml.Decoder, data string) int {
+	return strings.Count(data[:d.InputOffset()], "\n") + 1
+}
+
+func ToNix(data string, options *converter.ConverterOptions) (string, error) {
+	root, err := Parse(data)
+	if err != nil {
+		return "", err
+	}
+
+	visitor := NewXMLVisitor(root, options)
+
+	return visitor.limiter.Done(nix.Layout(visitor.Visit(), &options.NixOutput))
+}
@@ -1,9 +1,12 @@
 package yaml
 
 import (
+	"context"
+	"strings"
 	"testing"
 
 	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/nix"
 	"github.com/theobori/nix-converter/converter/options"
 	"github.com/theobori/nix-converter/internal/common"
 )
@@ -295,4 +298,185 @@ in
 	if output != want {
 		t.Errorf("ToNix() = \n%v, want \n%v", output, want)
 	}
+
+	// The Nix formatter reads the let expression back
+	formatted, err := nix.Format(output, &options)
+	if err != nil || formatted != want {
+		t.Errorf("Format() = \n%v, %v, want \n%v", formatted, err, want)
+	}
+}
+
+func TestYAMLCollapsePaths(t *testing.T) {
+	t.Parallel()
+	options := converter.NewDefaultConverterOptions()
+	options.UnsafeKeys = true
+	options.NixOutput.CollapsePaths = true
+
+	// A mapping with an anchor is referenced by its name, it is not collapsed
+	input := `services:
+  nginx: &nginx
+    enable: true
+hosts:
+  main:
+    server: *nginx`
+
+	want := `let
+  nginx = {
+    enable = true;
+  };
+in
+{
+  services.nginx = nginx;
+  hosts.main.server = nginx;
+}`
+
+	output, err := ToNix(input, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if output != want {
+		t.Errorf("ToNix() = \n%v, want \n%v", output, want)
+	}
+}
+
+func TestYAMLIndentation(t *testing.T) {
+	t.Parallel()
+	options := converter.NewDefaultConverterOptions()
+	options.Indentation.Size = 4
+
+	options.UnsafeKeys = true
+
+	input := `{ a = [ { b = 1; c = ''
+      x
+      y''; } 2 ]; }`
+	want := `a:
+    -   b: 1
+        c: |-
+            x
+            y
+    -   2`
+
+	output, err := FromNix(input, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if output != want {
+		t.Errorf("FromNix() = \n%v, want \n%v", output, want)
+	}
+
+	options.Indentation.UseTabs = true
+	if _, err := FromNix(input, options); err == nil {
+		t.Error("FromNix() expected an error with tabs")
+	}
+}
+
+func TestYAMLDuplicateKeys(t *testing.T) {
+	t.Parallel()
+	input := `a:
+  b: 1
+  c: 2
+a:
+  b: 3`
+
+	_, err := ToNix(input, converter.NewDefaultConverterOptions())
+	if err == nil || err.Error() != "line 4, column 1: the key 'a' is defined twice" {
+		t.Errorf("ToNix() expected the duplicated key error, got %v", err)
+	}
+
+	duplicateKeys := options.DuplicateKeys{Policy: options.DuplicateKeysDeepMerge}
+	options := converter.NewDefaultConverterOptions()
+	options.UnsafeKeys = true
+	options.DuplicateKeys = duplicateKeys
+	want := `{
+  a = {
+    b = 3;
+    c = 2;
+  };
+}`
+
+	output, err := ToNix(input, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if output != want {
+		t.Errorf("ToNix() = \n%v, want \n%v", output, want)
+	}
+}
+
+func TestYAMLAliasLimit(t *testing.T) {
+	t.Parallel()
+	input := `a: &a [1, 2]
+b: &b [*a, *a]
+c: [*b, *b]`
+
+	options := converter.NewDefaultConverterOptions()
+	options.Limits.MaxAliasExpansions = 3
+
+	_, err := ToNix(input, options)
+	if err == nil || err.Error() != "the alias expansions limit of 3 is exceeded" {
+		t.Errorf("ToNix() expected the alias expansions limit error, got %v", err)
+	}
+}
+
+func TestYAMLDocuments(t *testing.T) {
+	t.Parallel()
+	input := `a: &x
+  b: 1
+c: *x
+---
+- -1
+---
+foo`
+
+	want := `[
+  (let
+    x = {
+      b = 1;
+    };
+  in
+  {
+    a = x;
+    c = x;
+  })
+  [
+    (-1)
+  ]
+  "foo"
+]`
+
+	options := converter.NewDefaultConverterOptions()
+	options.UnsafeKeys = true
+
+	output, err := ToNix(input, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if output != want {
+		t.Errorf("ToNix() = \n%v, want \n%v", output, want)
+	}
+
+	// A document is written once the next one is decoded
+	var b strings.Builder
+	err = ToNixStream(context.Background(), strings.NewReader("a: 1\n---\n2\n---\nb: [\n"), &b, options)
+	if err == nil || !strings.HasPrefix(err.Error(), "line 5: ") || !strings.HasSuffix(err.Error(), "(at [2])") {
+		t.Errorf("ToNixStream() expected an error at the third document, got %v", err)
+	}
+	if b.String() != "[\n  {\n    a = 1;\n  }\n" {
+		t.Errorf("ToNixStream() wrote %q before the error", b.String())
+	}
+
+	// The documents share the limits
+	options.Limits.MaxNodes = 2
+	if _, err := ToNix("1\n---\n2\n---\n3", options); err == nil || err.Error() != "the nodes limit of 2 is exceeded (at [2])" {
+		t.Errorf("ToNix() expected the nodes limit error, got %v", err)
+	}
+}
+
+func TestYAMLToNixfmt(t *testing.T) {
+	t.Parallel()
+	common.TestHelperNixfmt(t, ".yaml", ToNix)
 }
@@ -0,0 +1,11 @@
+let
+  base = {
+    "user" = "root";
+    "shell" = "bash";
+  };
+in
+{
+  "base" = base;
+  "first" = base;
+  "second" = base;
+}
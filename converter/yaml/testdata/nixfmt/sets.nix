@@ -0,0 +1,10 @@
+{
+  "service" = {
+    "name" = "web";
+    "ports" = {
+      "http" = 80;
+    };
+    "enabled" = true;
+  };
+  "weird key" = -1;
+}
@@ -0,0 +1,17 @@
+{
+  "script" = ''
+    set -e
+
+    echo ''${HOME}
+  '';
+  "folded" = ''
+    first second
+  '';
+  "quoted" = "a \"b\" c";
+  "list" = [
+    ''
+      a
+      b
+    ''
+  ];
+}
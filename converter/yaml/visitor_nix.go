@@ -14,89 +14,32 @@ import (
 type NixVisitor struct {
 	i       common.Indentation
 	p       *parser.Parser
+	errors  *nix.Collector
 	node    *parser.Node
 	options *converter.ConverterOptions
 }
 
 func NewNixVisitor(p *parser.Parser, node *parser.Node, options *converter.ConverterOptions) *NixVisitor {
 	return &NixVisitor{
-		i:       *common.NewDefaultIndentation(),
+		i:       *common.NewIndentationUnit(options.Indentation.Unit()),
 		node:    node,
 		p:       p,
+		errors:  nix.NewCollector(p, node, options),
 		options: options,
 	}
 }
 
-type mapNode struct {
-	children  map[string]*mapNode
-	childKeys []string // Preserve insertion order
-	value     *parser.Node
-}
-
 func (n *NixVisitor) visitSet(node *parser.Node) (string, error) {
 	if len(node.Nodes) == 0 {
 		return "{}", nil
 	}
 
-	// Build nested structure from attribute paths while preserving order
-	type nodeInfo struct {
-		keys  []string
-		value *parser.Node
-	}
-	items := []nodeInfo{}
-
-	for _, child := range node.Nodes {
-		attrPathNode := child.Nodes[0]
-		valueNode := child.Nodes[1]
-
-		var keys []string
-		if attrPathNode.Type == parser.AttrPathNode && len(attrPathNode.Nodes) > 1 {
-			for _, keyNode := range attrPathNode.Nodes {
-				key, err := n.visit(keyNode)
-				if err != nil {
-					return "", err
-				}
-				keys = append(keys, key)
-			}
-		} else {
-			key, err := n.visit(attrPathNode)
-			if err != nil {
-				return "", err
-			}
-			keys = []string{key}
-		}
-
-		items = append(items, nodeInfo{keys: keys, value: valueNode})
-	}
-
-	root := &mapNode{children: make(map[string]*mapNode), childKeys: []string{}}
-
-	for _, item := range items {
-		current := root
-		for i, key := range item.keys {
-			if i == len(item.keys)-1 {
-				// Leaf node
-				if current.children[key] == nil {
-					current.children[key] = &mapNode{value: item.value}
-					current.childKeys = append(current.childKeys, key)
-				} else {
-					current.children[key].value = item.value
-				}
-				continue
-			}
-			// Intermediate node
-			if current.children[key] == nil {
-				current.children[key] = &mapNode{children: make(map[string]*mapNode), childKeys: []string{}}
-				current.childKeys = append(current.childKeys, key)
-			} else if current.children[key].children == nil {
-				current.children[key].children = make(map[string]*mapNode)
-				current.children[key].childKeys = []string{}
-			}
-			current = current.children[key]
-		}
+	tree, err := n.errors.BuildAttrTree(node, n.options.DuplicateKeys.Policy)
+	if err != nil {
+		return "", err
 	}
 
-	lines, err := n.buildYAML(root)
+	lines, err := n.buildYAML(tree)
 	if err != nil {
 		return "", err
 	}
@@ -104,40 +47,34 @@ func (n *NixVisitor) visitSet(node *parser.Node) (string, error) {
 	return strings.Join(lines, "\n"), nil
 }
 
-func (n *NixVisitor) buildYAML(m *mapNode) ([]string, error) {
+func (n *NixVisitor) buildYAML(tree *nix.AttrNode) ([]string, error) {
 	e := []string{}
-	keys := m.childKeys
+	keys := tree.Order
 	if n.options.SortIterators.SortHashmap {
-		keys = make([]string, len(m.childKeys))
-		copy(keys, m.childKeys)
+		keys = slices.Clone(tree.Order)
 		slices.Sort(keys)
 	}
 
 	for _, key := range keys {
-		node := m.children[key]
+		node := tree.Children[key]
 		safeKey := MakeNameSafe(key, n.options.UnsafeKeys)
 		keyString := n.i.IndentValue() + safeKey + ":"
 
-		var valueNode *parser.Node
-		if node.value != nil {
-			valueNode = node.value
-		}
-
-		if valueNode != nil {
+		if valueNode := node.Value; valueNode != nil {
 			// Has a value node
 			switch valueNode.Type {
 			case parser.SetNode, parser.ListNode:
 				if len(valueNode.Nodes) == 0 {
 					value, err := n.visit(valueNode)
 					if err != nil {
-						return nil, err
+						return nil, converter.AtKey(err, key)
 					}
 					e = append(e, keyString+" "+value)
 				} else {
 					n.i.Indent()
 					value, err := n.visit(valueNode)
 					if err != nil {
-						return nil, err
+						return nil, converter.AtKey(err, key)
 					}
 					n.i.UnIndent()
 					e = append(e, keyString+"\n"+value)
@@ -145,16 +82,16 @@ func (n *NixVisitor) buildYAML(m *mapNode) ([]string, error) {
 			default:
 				value, err := n.visit(valueNode)
 				if err != nil {
-					return nil, err
+					return nil, converter.AtKey(err, key)
 				}
 				e = append(e, keyString+" "+value)
 			}
-		} else if len(node.children) > 0 {
+		} else if len(node.Children) > 0 {
 			// Has nested children (from expanded paths)
 			n.i.Indent()
 			nested, err := n.buildYAML(node)
 			if err != nil {
-				return nil, err
+				return nil, converter.AtKey(err, key)
 			}
 			n.i.UnIndent()
 			e = append(e, keyString+"\n"+strings.Join(nested, "\n"))
@@ -170,17 +107,19 @@ func (n *NixVisitor) visitList(node *parser.Node) (string, error) {
 	}
 
 	e := []string{}
-	for _, child := range node.Nodes {
+	for index, child := range node.Nodes {
 		n.i.Indent()
 
 		s, err := n.visit(child)
 		if err != nil {
-			return "", err
+			return "", converter.AtIndex(err, index)
 		}
 
 		n.i.UnIndent()
 
-		e = append(e, n.i.IndentValue()+"- "+strings.TrimLeft(s, " "))
+		// The dash is padded so the item is aligned with its next lines
+		dash := "-" + strings.Repeat(" ", len(n.i.Unit())-1)
+		e = append(e, n.i.IndentValue()+dash+strings.TrimLeft(s, " "))
 	}
 
 	if n.options.SortIterators.SortList {
@@ -231,6 +170,10 @@ func (n *NixVisitor) visitIndentedString(node *parser.Node) (string, error) {
 }
 
 func (n *NixVisitor) visit(node *parser.Node) (string, error) {
+	return nix.Visit(n.errors, node, n.visitNode, "null")
+}
+
+func (n *NixVisitor) visitNode(node *parser.Node) (string, error) {
 	switch node.Type {
 	case parser.SetNode:
 		return n.visitSet(node)
@@ -262,11 +205,21 @@ func (n *NixVisitor) visit(node *parser.Node) (string, error) {
 }
 
 func (n *NixVisitor) Visit() (string, error) {
-	return n.visit(n.node)
+	v, err := n.visit(n.node)
+	return n.errors.Done(v, err)
 }
 
 func FromNix(data string, options *converter.ConverterOptions) (string, error) {
-	p, err := parser.ParseString(data)
+	if options.Indentation.UseTabs {
+		return "", fmt.Errorf("YAML does not allow tabs for indentation")
+	}
+
+	// The dash of a sequence item and its space fill a whole level
+	if len(options.Indentation.Unit()) < 2 {
+		return "", fmt.Errorf("the YAML indentation size must be at least 2, got %d", options.Indentation.Size)
+	}
+
+	p, err := nix.Parse(data)
 	if err != nil {
 		return "", err
 	}
@@ -1,8 +1,13 @@
 package yaml
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"regexp"
 	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/theobori/nix-converter/converter"
@@ -13,26 +18,48 @@ import (
 
 type YAMLVisitor struct {
 	anchors map[string]string
+	// The indentation of the document
+	base    common.Indentation
 	i       common.Indentation
 	node    *yaml.Node
+	limiter *converter.Limiter
 	options *converter.ConverterOptions
 }
 
 func NewYAMLVisitor(node *yaml.Node, options *converter.ConverterOptions) *YAMLVisitor {
+	return newYAMLVisitor(node, common.NewIndentationUnit(options.NixIndentUnit()), converter.NewLimiter(options), options)
+}
+
+// Returns a visitor whose output starts at the given indentation level, the
+// documents of a stream share their limits
+func newYAMLVisitor(node *yaml.Node, i *common.Indentation, limiter *converter.Limiter, options *converter.ConverterOptions) *YAMLVisitor {
 	return &YAMLVisitor{
 		anchors: make(map[string]string),
-		i:       *common.NewDefaultIndentation(),
+		base:    *i,
+		i:       *i,
 		node:    node,
+		limiter: limiter,
 		options: options,
 	}
 }
 
-func newAnchorIndentation() *common.Indentation {
-	i := common.NewDefaultIndentation()
+// Returns the indentation of the let bindings, one level below the document
+func (y *YAMLVisitor) anchorIndentation() common.Indentation {
+	i := y.base
 	i.Indent()
 	return i
 }
 
+// Returns the single key of a mapping and its value, a mapping with an
+// anchor is kept as it is referenced by its name
+func (y *YAMLVisitor) single(node *yaml.Node) (string, *yaml.Node, bool) {
+	if node.Kind != yaml.MappingNode || len(node.Content) != 2 || node.Anchor != "" {
+		return "", nil, false
+	}
+
+	return node.Content[0].Value, node.Content[1], true
+}
+
 func (y *YAMLVisitor) visitMapping(node *yaml.Node) string {
 	n := len(node.Content)
 	if n == 0 {
@@ -41,8 +68,8 @@ func (y *YAMLVisitor) visitMapping(node *yaml.Node) string {
 
 	e := []string{}
 	for i := 0; i < n; i += 2 {
-		key := nix.MakeNameSafe(node.Content[i].Value, y.options.UnsafeKeys)
-		value := node.Content[i+1]
+		path, value := nix.CollapsePath(node.Content[i].Value, node.Content[i+1], y.single, &y.options.NixOutput)
+		key := nix.MakeAttrPath(path, y.options.UnsafeKeys)
 
 		y.i.Indent()
 		e = append(e, y.i.IndentValue()+key+" = "+y.visit(value)+";")
@@ -77,27 +104,42 @@ func (y *YAMLVisitor) visitSequence(node *yaml.Node) string {
 }
 
 func (y *YAMLVisitor) visitScalar(node *yaml.Node) string {
-	if node.Tag == "!!int" || node.Tag == "!!float" || node.Tag == "!!bool" {
+	if node.Tag == "!!int" {
+		return nix.FormatIntLiteral(node.Value)
+	}
+
+	if node.Tag == "!!float" || node.Tag == "!!bool" {
 		return node.Value
 	}
 
 	if strings.Contains(node.Value, "\n") {
-		return common.MakeIndentedString(node.Value, y.i.IndentValue())
+		return common.MakeIndentedString(node.Value, y.i.IndentValue(), y.i.Unit())
 	}
 
 	return common.MakeStringSafe(node.Value)
 }
 
+// An alias references the let binding of its anchor, Nix expands it
 func (y *YAMLVisitor) visitAlias(node *yaml.Node) string {
+	if y.limiter.Alias() != nil {
+		return ""
+	}
+
 	return node.Alias.Anchor
 }
 
+// visit stops at the first exceeded limit, the error is returned by Err
 func (y *YAMLVisitor) visit(node *yaml.Node) string {
+	if y.limiter.Enter() != nil {
+		return ""
+	}
+	defer y.limiter.Leave()
+
 	var output string
 	indent := y.i
 
 	if node.Anchor != "" {
-		y.i = *newAnchorIndentation()
+		y.i = y.anchorIndentation()
 	}
 
 	switch node.Kind {
@@ -129,28 +171,203 @@ func (y *YAMLVisitor) Visit() string {
 	}
 
 	secondPass := "let\n"
-	y.i = *newAnchorIndentation()
+	y.i = y.anchorIndentation()
 	for k, v := range y.anchors {
 		secondPass += y.i.IndentValue() + k + " = " + v + ";\n"
 	}
-	secondPass += "in\n" + firstPass
+	y.i = y.base
+	secondPass += y.i.IndentValue() + "in\n" + y.i.IndentValue() + firstPass
 
 	return secondPass
 }
 
-func ToNix(data string, options *converter.ConverterOptions) (string, error) {
+// Err returns the error of the first limit exceeded by Visit
+func (y *YAMLVisitor) Err() error {
+	return y.limiter.Err()
+}
+
+// MergeDuplicateKeys applies a duplicate keys policy of options.DuplicateKeys
+// to the mappings of a node, the aliases are left as they are
+func MergeDuplicateKeys(node *yaml.Node, policy string) error {
+	for _, child := range node.Content {
+		if err := MergeDuplicateKeys(child, policy); err != nil {
+			return err
+		}
+	}
+
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	merged, err := nix.MergeEntries(mappingEntries(node), policy, mergeMappings(policy))
+	if err != nil {
+		return err
+	}
+
+	node.Content = mappingContent(merged)
+
+	return nil
+}
+
+// Returns the entries of a mapping, the keys are kept with their values
+func mappingEntries(node *yaml.Node) []nix.Entry[[2]*yaml.Node] {
+	entries := []nix.Entry[[2]*yaml.Node]{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		entries = append(entries, nix.Entry[[2]*yaml.Node]{
+			Key:    key.Value,
+			Value:  [2]*yaml.Node{key, node.Content[i+1]},
+			Line:   key.Line,
+			Column: key.Column,
+		})
+	}
+
+	return entries
+}
+
+func mappingContent(entries []nix.Entry[[2]*yaml.Node]) []*yaml.Node {
+	content := []*yaml.Node{}
+	for _, e := range entries {
+		content = append(content, e.Value[0], e.Value[1])
+	}
+
+	return content
+}
+
+// Returns the deep merge of two mappings
+func mergeMappings(policy string) func(a [2]*yaml.Node, b [2]*yaml.Node) ([2]*yaml.Node, bool, error) {
+	var merge func(a [2]*yaml.Node, b [2]*yaml.Node) ([2]*yaml.Node, bool, error)
+	merge = func(a [2]*yaml.Node, b [2]*yaml.Node) ([2]*yaml.Node, bool, error) {
+		if a[1].Kind != yaml.MappingNode || b[1].Kind != yaml.MappingNode {
+			return a, false, nil
+		}
+
+		merged, err := nix.MergeEntries(append(mappingEntries(a[1]), mappingEntries(b[1])...), policy, merge)
+		if err != nil {
+			return a, false, err
+		}
+
+		m := *a[1]
+		m.Content = mappingContent(merged)
+
+		return [2]*yaml.Node{a[0], &m}, true, nil
+	}
+
+	return merge
+}
+
+var lineError = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// unmarshalError locates the errors of the YAML decoder
+func unmarshalError(err error) error {
+	m := lineError.FindStringSubmatch(err.Error())
+	if m == nil {
+		return err
+	}
+
+	line, _ := strconv.Atoi(m[1])
+	return converter.Errorf(line, 0, "%s", m[2])
+}
+
+// Decodes the next document of a stream, io.EOF is returned after the last one
+func decodeDocument(ctx context.Context, d *yaml.Decoder, options *converter.ConverterOptions) (*yaml.Node, error) {
 	var node yaml.Node
 
-	err := yaml.Unmarshal([]byte(data), &node)
+	err := d.Decode(&node)
+	if errors.Is(err, io.EOF) {
+		return nil, err
+	}
 	if err != nil {
-		return "", err
+		return nil, unmarshalError(err)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := MergeDuplicateKeys(&node, options.DuplicateKeys.Policy); err != nil {
+		return nil, err
 	}
 
+	// An empty document is a null scalar, like the decoder returns it
 	if len(node.Content) == 0 {
-		return "", fmt.Errorf("empty node")
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"}, nil
+	}
+
+	return node.Content[0], nil
+}
+
+// ToNixStream converts the YAML documents read from r and writes them to w.
+// A single document is written as its value, the documents of a stream with
+// several of them are the elements of a list, each one being written once the
+// next one is decoded. When a document fails, the ones before it may already
+// be written to w, in a list that is not closed. A document is decoded whole
+// in a node tree, as its anchors and duplicate keys are only known at its
+// end, and the limits apply to all the documents.
+func ToNixStream(ctx context.Context, r io.Reader, w io.Writer, options *converter.ConverterOptions) error {
+	d := yaml.NewDecoder(r)
+	limiter := converter.NewLimiter(options.WithContext(ctx))
+
+	first, err := decodeDocument(ctx, d, options)
+	if errors.Is(err, io.EOF) {
+		return fmt.Errorf("empty node")
+	}
+	if err != nil {
+		return err
+	}
+
+	next, err := decodeDocument(ctx, d, options)
+	if errors.Is(err, io.EOF) {
+		visitor := newYAMLVisitor(first, common.NewIndentationUnit(options.NixIndentUnit()), limiter, options)
+		out, err := limiter.Done(nix.Layout(visitor.Visit(), &options.NixOutput))
+		if err != nil {
+			return err
+		}
+
+		_, err = io.WriteString(w, out)
+		return err
+	}
+	if err != nil {
+		return converter.AtIndex(err, 1)
+	}
+
+	i := common.NewIndentationUnit(options.NixIndentUnit())
+	i.Indent()
+
+	if _, err := io.WriteString(w, "[\n"); err != nil {
+		return err
 	}
 
-	out := NewYAMLVisitor(node.Content[0], options).Visit()
+	for index, node := 0, first; node != nil; index++ {
+		visitor := newYAMLVisitor(node, i, limiter, options)
+		element := visitor.Visit()
+		if len(visitor.anchors) > 0 {
+			element = "(" + element + ")"
+		} else {
+			element = nix.MakeElementSafe(element)
+		}
 
-	return out, nil
+		out, err := limiter.Done(nix.Layout(i.IndentValue()+element, &options.NixOutput))
+		if err != nil {
+			return converter.AtIndex(err, index)
+		}
+		if _, err := io.WriteString(w, out+"\n"); err != nil {
+			return err
+		}
+
+		node = next
+		next, err = decodeDocument(ctx, d, options)
+		if errors.Is(err, io.EOF) {
+			next = nil
+		} else if err != nil {
+			return converter.AtIndex(err, index+2)
+		}
+	}
+
+	_, err = io.WriteString(w, "]")
+	return err
+}
+
+func ToNix(data string, options *converter.ConverterOptions) (string, error) {
+	return converter.ConvertString(ToNixStream, data, options)
 }
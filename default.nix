@@ -5,7 +5,7 @@ buildGoModule {
 
   src = ./.;
 
-  vendorHash = "sha256-Ay1f9sk8RuJyOS7hl/lrscpxdlIgm9dMow/xTFoR+H4=";
+  vendorHash = "sha256-zO/J6ZjxAgce8uut1I8H+V/IuRlHcsNUmRbB8t6BHGo=";
 
   ldflags = [
     "-s"
@@ -37,6 +37,13 @@
 
         checks = {
           formatting = treefmtEval.config.build.check self;
+          # Building the package catches a vendorHash left stale by go.mod
+          package = self.packages.${system}.default;
+          # The golden outputs of the nixfmt style must be left unchanged by nixfmt
+          nixfmt-golden = pkgs.runCommand "nixfmt-golden" { } ''
+            ${pkgs.nixfmt-rfc-style}/bin/nixfmt --check ${./converter}/*/testdata/nixfmt/*.nix
+            touch $out
+          '';
         };
       }
     );
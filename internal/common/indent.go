@@ -9,17 +9,26 @@ type Indentation struct {
 	spaces       string
 }
 
+// DefaultIndentUnit is the text of an indentation level by default
+const DefaultIndentUnit = "  "
+
 func NewIndentation(indentSize int) *Indentation {
+	return NewIndentationUnit(strings.Repeat(" ", indentSize))
+}
+
+// NewIndentationUnit creates an indentation whose levels are the given text,
+// like spaces or a tab
+func NewIndentationUnit(unit string) *Indentation {
 	return &Indentation{
 		indentAmount: 0,
-		indentSize:   indentSize,
+		indentSize:   len(unit),
 		indentValue:  "",
-		spaces:       strings.Repeat(" ", indentSize),
+		spaces:       unit,
 	}
 }
 
 func NewDefaultIndentation() *Indentation {
-	return NewIndentation(2)
+	return NewIndentationUnit(DefaultIndentUnit)
 }
 
 func (i *Indentation) Indent() {
@@ -39,3 +48,8 @@ func (i *Indentation) UnIndent() {
 func (i *Indentation) IndentValue() string {
 	return i.indentValue
 }
+
+// Unit returns the text of an indentation level
+func (i *Indentation) Unit() string {
+	return i.spaces
+}
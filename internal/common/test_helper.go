@@ -1,9 +1,13 @@
 package common
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/options"
 )
 
 type ConvertFn func(string, *converter.ConverterOptions) (string, error)
@@ -80,3 +84,43 @@ func TestHelperToNixStrings(
 		TestHelperToNix(t, s, fromNix, toNix, options)
 	}
 }
+
+// TestHelperNixfmt tests the nixfmt style against the golden corpus in
+// testdata/nixfmt, every input file with the extension having the expected
+// Nix output next to it. nixfmt itself is not run, the flake checks that it
+// leaves the golden outputs unchanged.
+func TestHelperNixfmt(t *testing.T, extension string, toNix ConvertFn) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "nixfmt", "*"+extension))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("the golden corpus is empty")
+	}
+
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			t.Parallel()
+			input, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := os.ReadFile(strings.TrimSuffix(path, extension) + ".nix")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			o := converter.NewDefaultConverterOptions()
+			o.NixOutput.Style = options.NixStyleNixfmt
+
+			output, err := toNix(string(input), o)
+			if err != nil {
+				t.Fatalf("toNix() error = %v", err)
+			}
+
+			if output+"\n" != string(want) {
+				t.Errorf("toNix() = \n%v, want \n%v", output, string(want))
+			}
+		})
+	}
+}
@@ -1,11 +1,18 @@
 package common
 
-import "strings"
+import (
+	"regexp"
+	"strings"
+)
+
+// The dollars before an escape sequence. The parser reads a dollar with the
+// character after it, they are escaped to keep the backslash in its sequence.
+var dollarsBeforeEscape = regexp.MustCompile(`\$+\\`)
 
 func MakeStringSafe(s string) string {
 	// Use indented string syntax for multiline strings
 	if strings.Contains(s, "\n") {
-		return MakeIndentedString(s, "")
+		return MakeIndentedString(s, "", DefaultIndentUnit)
 	}
 
 	// Escape special characters for Nix string literals
@@ -15,10 +22,13 @@ func MakeStringSafe(s string) string {
 	escaped = strings.ReplaceAll(escaped, "\r", "\\r")  // Carriage returns
 	escaped = strings.ReplaceAll(escaped, "\t", "\\t")  // Tabs
 	escaped = strings.ReplaceAll(escaped, "${", "\\${") // Nix interpolation
+	escaped = dollarsBeforeEscape.ReplaceAllStringFunc(escaped, func(s string) string {
+		return strings.ReplaceAll(s, "$", "\\$")
+	})
 	return "\"" + escaped + "\""
 }
 
-func MakeIndentedString(s string, indent string) string {
+func MakeIndentedString(s string, indent string, unit string) string {
 	escaped := s
 	escaped = strings.ReplaceAll(escaped, "''", "'''")
 	escaped = strings.ReplaceAll(escaped, "${", "''${")
@@ -30,7 +40,7 @@ func MakeIndentedString(s string, indent string) string {
 	var result strings.Builder
 	result.WriteString("''\n")
 
-	contentIndent := indent + "  "
+	contentIndent := indent + unit
 
 	for i, line := range lines {
 		if line != "" || len(lines) > 1 {
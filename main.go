@@ -1,17 +1,32 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"io"
 	"log"
 	"os"
 	"strings"
 
 	"github.com/theobori/nix-converter/converter"
+	"github.com/theobori/nix-converter/converter/cbor"
+	"github.com/theobori/nix-converter/converter/csv"
+	"github.com/theobori/nix-converter/converter/dotenv"
+	"github.com/theobori/nix-converter/converter/edn"
+	"github.com/theobori/nix-converter/converter/hcl"
+	"github.com/theobori/nix-converter/converter/ini"
 	"github.com/theobori/nix-converter/converter/json"
+	"github.com/theobori/nix-converter/converter/jsonl"
+	"github.com/theobori/nix-converter/converter/kdl"
+	"github.com/theobori/nix-converter/converter/msgpack"
+	"github.com/theobori/nix-converter/converter/nix"
 	"github.com/theobori/nix-converter/converter/options"
+	"github.com/theobori/nix-converter/converter/plist"
+	"github.com/theobori/nix-converter/converter/properties"
+	"github.com/theobori/nix-converter/converter/textproto"
 	"github.com/theobori/nix-converter/converter/toml"
+	"github.com/theobori/nix-converter/converter/xml"
 	"github.com/theobori/nix-converter/converter/yaml"
 )
 
@@ -19,12 +34,42 @@ func ConverterFromLanguage(language string, data string, options *converter.Conv
 	var c converter.Converter
 
 	switch language {
+	case "cbor":
+		c = cbor.NewCBORConverter(data, options)
+	case "csv":
+		c = csv.NewCSVConverter(data, options)
+	case "dotenv":
+		c = dotenv.NewDotenvConverter(data, options)
+	case "edn":
+		c = edn.NewEDNConverter(data, options)
+	case "hcl":
+		c = hcl.NewHCLConverter(data, options)
+	case "ini":
+		c = ini.NewINIConverter(data, options)
 	case "json":
 		c = json.NewJSONConverter(data, options)
+	case "jsonl":
+		c = jsonl.NewJSONLConverter(data, options)
+	case "kdl":
+		c = kdl.NewKDLConverter(data, options)
+	case "msgpack":
+		c = msgpack.NewMsgpackConverter(data, options)
+	case "nix":
+		c = nix.NewNixConverter(data, options)
 	case "yaml":
 		c = yaml.NewYAMLConverter(data, options)
+	case "plist":
+		c = plist.NewPlistConverter(data, options)
+	case "properties":
+		c = properties.NewPropertiesConverter(data, options)
+	case "textproto":
+		c = textproto.NewTextprotoConverter(data, options)
 	case "toml":
 		c = toml.NewTOMLConverter(data, options)
+	case "tsv":
+		c = csv.NewTSVConverter(data, options)
+	case "xml":
+		c = xml.NewXMLConverter(data, options)
 	default:
 		return nil, fmt.Errorf("this configuration language is not implemented")
 	}
@@ -32,6 +77,71 @@ func ConverterFromLanguage(language string, data string, options *converter.Conv
 	return &c, nil
 }
 
+// StreamConverterFromLanguage returns the conversion of a language from or to
+// Nix. JSON and JSON Lines inputs are converted while they are read, and YAML
+// streams one whole document at a time. The other inputs are read as a whole
+// before being converted.
+func StreamConverterFromLanguage(language string, fromNix bool, options *converter.ConverterOptions) (converter.StreamConverter, error) {
+	if !fromNix {
+		switch language {
+		case "json":
+			return converter.NewStreamConverter(json.ToNixStream, options), nil
+		case "jsonl":
+			return converter.NewStreamConverter(jsonl.ToNixStream, options), nil
+		case "yaml":
+			return converter.NewStreamConverter(yaml.ToNixStream, options), nil
+		}
+	}
+
+	if _, err := ConverterFromLanguage(language, "", options); err != nil {
+		return nil, err
+	}
+
+	convert := func(data string, options *converter.ConverterOptions) (string, error) {
+		c, err := ConverterFromLanguage(language, data, options)
+		if err != nil {
+			return "", err
+		}
+
+		if fromNix {
+			return (*c).FromNix()
+		}
+		return (*c).ToNix()
+	}
+
+	return converter.NewStreamConverter(converter.Buffered(convert), options), nil
+}
+
+// Returns true when the language is a binary data format
+func isBinary(language string) bool {
+	c, err := ConverterFromLanguage(language, "", converter.NewDefaultConverterOptions())
+	if err != nil {
+		return false
+	}
+
+	_, isBinary := (*c).(converter.BinaryConverter)
+	return isBinary
+}
+
+// Prints the conversion errors in the file:line:col form and exits
+func fatalConversion(err error, filename string) {
+	if filename == "" {
+		filename = "<stdin>"
+	}
+
+	var errs converter.Errors
+	if !errors.As(err, &errs) {
+		errs = converter.Errors{converter.AsError(err)}
+	}
+
+	for _, e := range errs {
+		e.File = filename
+		fmt.Fprintln(os.Stderr, e)
+	}
+
+	os.Exit(1)
+}
+
 func main() {
 	var (
 		err               error
@@ -40,6 +150,26 @@ func main() {
 		fromNix           bool
 		sortIteratorsLine string
 		unsafeKeys        bool
+		allErrors         bool
+		jsonStyle         string
+		jsonIndent        int
+		csvDelimiter      string
+		csvNoHeader       bool
+		csvInferTypes     bool
+		iniInlineComments bool
+		protoDescriptors  string
+		protoMessage      string
+		nixCollapsePaths  bool
+		nixCollapseDepth  int
+		nixLineWidth      int
+		nixStyle          string
+		indentSize        int
+		indentTabs        bool
+		duplicateKeys     string
+		maxDepth          int
+		maxNodes          int
+		maxOutputBytes    int
+		maxAliases        int
 	)
 
 	flag.StringVar(&language, "language", "json", "Configuration language name")
@@ -52,6 +182,34 @@ func main() {
 	flag.BoolVar(&fromNix, "from-nix", false, "Convert Nix to a data format, instead of data format to Nix")
 	flag.StringVar(&sortIteratorsLine, "sort-iterators", "", "If possible, it sorts iterators, specify them separated by ',' like 'list,hashmap'")
 	flag.BoolVar(&unsafeKeys, "unsafe-keys", false, "If possible, it skips double quotes around hashmaps keys")
+	flag.BoolVar(&allErrors, "all-errors", false, "Report every error of the Nix input instead of stopping at the first")
+
+	flag.IntVar(&indentSize, "indent", options.DefaultIndentSize, "Amount of spaces per indentation level of the Nix, JSON and YAML outputs")
+	flag.BoolVar(&indentTabs, "indent-tabs", false, "Indent the Nix and JSON outputs with tabs instead of spaces")
+
+	flag.StringVar(&duplicateKeys, "duplicate-keys", options.DuplicateKeysError, "Policy for the keys defined twice, it must be 'error', 'last-wins', 'first-wins' or 'deep-merge'")
+
+	flag.IntVar(&maxDepth, "max-depth", 0, "Maximum nesting of the values, 0 means no limit")
+	flag.IntVar(&maxNodes, "max-nodes", 0, "Maximum amount of values, 0 means no limit")
+	flag.IntVar(&maxOutputBytes, "max-output-bytes", 0, "Maximum size of the output in bytes, 0 means no limit")
+	flag.IntVar(&maxAliases, "max-alias-expansions", 0, "Maximum amount of YAML aliases, 0 means no limit")
+
+	flag.StringVar(&jsonStyle, "json-style", options.JSONStylePretty, "JSON output style, it must be 'pretty', 'compact' or 'minified'")
+	flag.IntVar(&jsonIndent, "json-indent", 0, "Amount of spaces per indentation level for the pretty JSON output, 0 is the default size")
+
+	flag.StringVar(&csvDelimiter, "csv-delimiter", "", "CSV and TSV field delimiter, ',' for CSV and a tab for TSV by default")
+	flag.BoolVar(&csvNoHeader, "csv-no-header", false, "The CSV and TSV tables have no header, records are lists instead of sets")
+	flag.BoolVar(&csvInferTypes, "csv-infer-types", false, "Read CSV and TSV fields as numbers, booleans and null when possible, instead of strings")
+
+	flag.BoolVar(&iniInlineComments, "ini-inline-comments", false, "A ';' or a '#' after a whitespace starts a comment in the INI values, like in git-config")
+
+	flag.StringVar(&protoDescriptors, "proto-descriptor-set", "", "FileDescriptorSet file checking the text format documents, like the output of protoc --descriptor_set_out")
+	flag.StringVar(&protoMessage, "proto-message", "", "Full name of the message type of the text format documents, like 'foo.v1.Config'")
+
+	flag.StringVar(&nixStyle, "nix-style", options.NixStyleDefault, "Nix output style, it must be 'default' or 'nixfmt', whose output is left unchanged by nixfmt")
+	flag.BoolVar(&nixCollapsePaths, "nix-collapse-paths", false, "Write the chains of sets with a single key as attribute paths, like 'a.b.c = 1;'")
+	flag.IntVar(&nixCollapseDepth, "nix-collapse-depth", 0, "Maximum amount of keys of the collapsed attribute paths, 0 means no limit")
+	flag.IntVar(&nixLineWidth, "nix-line-width", 0, "Write the Nix lists and sets holding only scalars on a single line when it fits this width, 0 disables it")
 
 	flag.Parse()
 
@@ -65,39 +223,93 @@ func main() {
 		sortIterators = options.NewDefaultSortIterators()
 	}
 
-	converterOptions := converter.ConverterOptions{
-		SortIterators: *sortIterators,
-		UnsafeKeys:    unsafeKeys,
+	jsonOutput, err := options.NewJSONOutput(jsonStyle, jsonIndent)
+	if err != nil {
+		log.Fatalln(err)
 	}
 
-	var bytes []byte
-	if filename == "" {
-		bytes, err = io.ReadAll(os.Stdin)
-	} else {
-		bytes, err = os.ReadFile(filename)
+	csvOptions, err := options.NewCSV(csvDelimiter, csvNoHeader, csvInferTypes)
+	if err != nil {
+		log.Fatalln(err)
 	}
 
+	var descriptorSet []byte
+	if protoDescriptors != "" {
+		descriptorSet, err = os.ReadFile(protoDescriptors)
+		if err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	textprotoOptions, err := options.NewTextproto(descriptorSet, protoMessage)
 	if err != nil {
 		log.Fatalln(err)
 	}
 
-	data := string(bytes)
+	nixOutput, err := options.NewNixOutput(nixStyle, nixCollapsePaths, nixCollapseDepth, nixLineWidth)
+	if err != nil {
+		log.Fatalln(err)
+	}
 
-	c, err := ConverterFromLanguage(language, data, &converterOptions)
+	indentation, err := options.NewIndentation(indentSize, indentTabs)
 	if err != nil {
 		log.Fatalln(err)
 	}
 
-	var s string
-	if fromNix {
-		s, err = (*c).FromNix()
-	} else {
-		s, err = (*c).ToNix()
+	// nixfmt indents with two spaces, the Nix output would not be left
+	// unchanged otherwise
+	writesNix := !fromNix || language == "nix"
+	if writesNix && nixStyle == options.NixStyleNixfmt && (indentSize != options.DefaultIndentSize || indentTabs) {
+		log.Fatalln("the Nix style 'nixfmt' indents with two spaces, it cannot be used with -indent or -indent-tabs")
+	}
+
+	duplicateKeysOptions, err := options.NewDuplicateKeys(duplicateKeys)
+	if err != nil {
+		log.Fatalln(err)
 	}
 
+	limits, err := options.NewLimits(maxDepth, maxNodes, maxOutputBytes, maxAliases)
 	if err != nil {
 		log.Fatalln(err)
 	}
 
-	fmt.Println(s)
+	converterOptions := converter.ConverterOptions{
+		SortIterators: *sortIterators,
+		UnsafeKeys:    unsafeKeys,
+		AllErrors:     allErrors,
+		JSONOutput:    *jsonOutput,
+		CSV:           *csvOptions,
+		INI:           *options.NewINI(iniInlineComments),
+		Textproto:     *textprotoOptions,
+		NixOutput:     *nixOutput,
+		Indentation:   *indentation,
+		DuplicateKeys: *duplicateKeysOptions,
+		Limits:        *limits,
+	}
+
+	c, err := StreamConverterFromLanguage(language, fromNix, &converterOptions)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	r := os.Stdin
+	if filename != "" {
+		f, err := os.Open(filename)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		defer f.Close()
+
+		r = f
+	}
+
+	err = c.Convert(context.Background(), r, os.Stdout)
+	if err != nil {
+		fatalConversion(err, filename)
+	}
+
+	// Binary outputs are written as they are, without a trailing newline
+	if !fromNix || !isBinary(language) {
+		fmt.Println()
+	}
 }